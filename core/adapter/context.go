@@ -93,3 +93,18 @@ type RequestContext interface {
 	// IsAborted checks if the request is aborted | 检查请求是否已中止
 	IsAborted() bool
 }
+
+// HostProvider Optional interface of request contexts that expose the request host | 暴露请求主机名的可选请求上下文接口
+// Contexts without it fall back to the Host header | 未实现时回退为Host请求头
+type HostProvider interface {
+	// GetHost gets request host, may include port | 获取请求主机名，可能包含端口
+	GetHost() string
+}
+
+// GetHost Gets the request host of any request context | 获取任意请求上下文的请求主机名
+func GetHost(ctx RequestContext) string {
+	if provider, ok := ctx.(HostProvider); ok {
+		return provider.GetHost()
+	}
+	return ctx.GetHeader("Host")
+}
@@ -37,3 +37,27 @@ type Storage interface {
 	// Ping checks if storage is accessible | 检查存储是否可访问
 	Ping() error
 }
+
+// AtomicStorage Optional interface of storages with an atomic set-if-absent | 支持原子"不存在时设置"的可选存储接口
+// Refresh token rotation uses it so concurrent requests cannot consume one token twice | 刷新令牌轮换使用它，防止并发请求重复消费同一令牌
+type AtomicStorage interface {
+	Storage
+
+	// SetNX atomically sets key only if it does not exist, reports whether it was set | 仅当键不存在时原子地设置键值对，返回是否设置成功
+	SetNX(key string, value any, expiration time.Duration) (bool, error)
+}
+
+// SetNX Sets key only if it does not exist, reports whether it was set | 仅当键不存在时设置键值对，返回是否设置成功
+// Storages without AtomicStorage fall back to Exists then Set, which is not atomic | 未实现AtomicStorage的存储回退为先Exists再Set，不是原子操作
+func SetNX(storage Storage, key string, value any, expiration time.Duration) (bool, error) {
+	if atomic, ok := storage.(AtomicStorage); ok {
+		return atomic.SetNX(key, value, expiration)
+	}
+	if storage.Exists(key) {
+		return false, nil
+	}
+	if err := storage.Set(key, value, expiration); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -32,8 +32,23 @@ type Builder struct {
 	dataRefreshPeriod      int64
 	tokenSessionCheckLogin bool
 	keyPrefix              string
+	tokenHashAtRest        bool
+	tokenHashSecret        string
+	refreshTimeout         int64
+	refreshSliding         bool
+	refreshMaxLifetime     int64
+	refreshBindFingerprint bool
+	cascadeRefreshToken    bool
+	acceptOAuth2Token      bool
+	permissionCacheTimeout int64
+	permissionIgnoreCase   bool
+	loginType              string
 	cookieConfig           *config.CookieConfig
 	renewPoolConfig        *pool.RenewPoolConfig
+	permissionProvider     manager.PermissionProvider
+	tenantResolver         manager.TenantResolver
+	tenantConfigs          map[string]manager.TenantConfigFunc
+	tenantValidator        manager.TenantValidator
 }
 
 // NewBuilder creates a new builder with default configuration | 创建新的构建器（使用默认配置）
@@ -55,6 +70,9 @@ func NewBuilder() *Builder {
 		dataRefreshPeriod:      config.NoLimit,
 		tokenSessionCheckLogin: true,
 		keyPrefix:              "satoken:",
+		loginType:              config.DefaultLoginType,
+		refreshTimeout:         config.DefaultRefreshTimeout,
+		refreshMaxLifetime:     config.NoLimit,
 		cookieConfig: &config.CookieConfig{
 			Domain:   "",
 			Path:     config.DefaultCookiePath,
@@ -254,6 +272,116 @@ func (b *Builder) KeyPrefix(prefix string) *Builder {
 	return b
 }
 
+// TokenHashAtRest sets whether to store token digests instead of raw tokens | 设置是否存储Token摘要而非原始Token
+func (b *Builder) TokenHashAtRest(hash bool) *Builder {
+	b.tokenHashAtRest = hash
+	return b
+}
+
+// TokenHashSecret sets HMAC key for token digests and enables hashing | 设置Token摘要的HMAC密钥并启用摘要存储
+func (b *Builder) TokenHashSecret(secret string) *Builder {
+	b.tokenHashSecret = secret
+	b.tokenHashAtRest = true
+	return b
+}
+
+// RefreshTimeout sets refresh token timeout in seconds | 设置刷新令牌超时时间（秒）
+func (b *Builder) RefreshTimeout(seconds int64) *Builder {
+	b.refreshTimeout = seconds
+	return b
+}
+
+// RefreshTimeoutDuration sets refresh token timeout using time.Duration | 使用time.Duration设置刷新令牌超时时间
+func (b *Builder) RefreshTimeoutDuration(d time.Duration) *Builder {
+	b.refreshTimeout = int64(d.Seconds())
+	return b
+}
+
+// RefreshSliding sets whether refresh token expiration slides on every refresh | 设置刷新令牌是否在每次刷新时滑动过期
+func (b *Builder) RefreshSliding(sliding bool) *Builder {
+	b.refreshSliding = sliding
+	return b
+}
+
+// RefreshMaxLifetime sets maximum absolute lifetime of refresh tokens in seconds | 设置刷新令牌的最长绝对寿命（秒）
+func (b *Builder) RefreshMaxLifetime(seconds int64) *Builder {
+	b.refreshMaxLifetime = seconds
+	return b
+}
+
+// RefreshBindFingerprint sets whether refresh tokens are bound to client fingerprint | 设置刷新令牌是否绑定客户端指纹
+func (b *Builder) RefreshBindFingerprint(bind bool) *Builder {
+	b.refreshBindFingerprint = bind
+	return b
+}
+
+// CascadeRefreshToken sets whether Logout, Kickout and Disable revoke refresh tokens | 设置登出、踢人下线和封禁时是否撤销刷新令牌
+func (b *Builder) CascadeRefreshToken(cascade bool) *Builder {
+	b.cascadeRefreshToken = cascade
+	return b
+}
+
+// AcceptOAuth2Token sets whether OAuth2 access tokens are accepted in permission checks | 设置权限校验时是否接受OAuth2访问令牌
+func (b *Builder) AcceptOAuth2Token(accept bool) *Builder {
+	b.acceptOAuth2Token = accept
+	return b
+}
+
+// PermissionCacheTimeout sets how many seconds permissions and roles stay cached, 0 disables caching | 设置权限和角色的缓存秒数，0代表不缓存
+func (b *Builder) PermissionCacheTimeout(seconds int64) *Builder {
+	b.permissionCacheTimeout = seconds
+	return b
+}
+
+// PermissionIgnoreCase sets whether permission codes are compared ignoring case | 设置比较权限码时是否忽略大小写
+func (b *Builder) PermissionIgnoreCase(ignoreCase bool) *Builder {
+	b.permissionIgnoreCase = ignoreCase
+	return b
+}
+
+// PermissionProvider sets where permissions and roles are loaded from | 设置权限和角色的加载来源
+func (b *Builder) PermissionProvider(provider manager.PermissionProvider) *Builder {
+	b.permissionProvider = provider
+	return b
+}
+
+// LoginType sets the account type, e.g. "admin" next to the default "login" | 设置账号类型，例如与默认的"login"并存的"admin"
+func (b *Builder) LoginType(loginType string) *Builder {
+	b.loginType = loginType
+	return b
+}
+
+// TenantResolver sets how requests resolve their tenant | 设置请求解析租户的方式
+func (b *Builder) TenantResolver(resolver manager.TenantResolver) *Builder {
+	b.tenantResolver = resolver
+	return b
+}
+
+// TenantHeader resolves the tenant from a request header | 从请求头解析租户
+func (b *Builder) TenantHeader(header string) *Builder {
+	return b.TenantResolver(manager.HeaderTenantResolver(header))
+}
+
+// TenantSubdomain resolves the tenant from the subdomain below baseDomain | 从baseDomain下的子域名解析租户
+func (b *Builder) TenantSubdomain(baseDomain string) *Builder {
+	return b.TenantResolver(manager.SubdomainTenantResolver(baseDomain))
+}
+
+// TenantValidator sets which tenants requests may resolve to | 设置请求可以解析到的租户
+func (b *Builder) TenantValidator(validator manager.TenantValidator) *Builder {
+	b.tenantValidator = validator
+	return b
+}
+
+// TenantConfig sets configuration overrides for a tenant | 设置租户的配置覆盖项
+func (b *Builder) TenantConfig(tenantID string, fn manager.TenantConfigFunc) *Builder {
+	if b.tenantConfigs == nil {
+		b.tenantConfigs = make(map[string]manager.TenantConfigFunc)
+	}
+	b.tenantConfigs[tenantID] = fn
+	return b
+}
+
 // NeverExpire sets token to never expire | 设置Token永不过期
 func (b *Builder) NeverExpire() *Builder {
 	b.timeout = config.NoLimit
@@ -286,6 +414,10 @@ func (b *Builder) Validate() error {
 		return fmt.Errorf("jwtSecretKey is required when TokenStyle is JWT")
 	}
 
+	if err := config.ValidateLoginType(b.loginType); err != nil {
+		return err
+	}
+
 	if !b.isReadHeader && !b.isReadCookie && !b.isReadBody {
 		return fmt.Errorf("at least one of IsReadHeader, IsReadCookie, or IsReadBody must be true")
 	}
@@ -346,6 +478,17 @@ func (b *Builder) Build() *manager.Manager {
 		IsLog:                  b.isLog,
 		IsPrintBanner:          b.isPrintBanner,
 		KeyPrefix:              b.keyPrefix,
+		TokenHashAtRest:        b.tokenHashAtRest,
+		TokenHashSecret:        b.tokenHashSecret,
+		RefreshTimeout:         b.refreshTimeout,
+		RefreshSliding:         b.refreshSliding,
+		RefreshMaxLifetime:     b.refreshMaxLifetime,
+		RefreshBindFingerprint: b.refreshBindFingerprint,
+		CascadeRefreshToken:    b.cascadeRefreshToken,
+		AcceptOAuth2Token:      b.acceptOAuth2Token,
+		PermissionCacheTimeout: b.permissionCacheTimeout,
+		PermissionIgnoreCase:   b.permissionIgnoreCase,
+		LoginType:              b.loginType,
 		CookieConfig:           b.cookieConfig,
 		RenewPoolConfig:        b.renewPoolConfig,
 	}
@@ -357,6 +500,18 @@ func (b *Builder) Build() *manager.Manager {
 	}
 
 	mgr := manager.NewManager(b.storage, cfg)
+	if b.permissionProvider != nil {
+		mgr.SetPermissionProvider(b.permissionProvider)
+	}
+	if b.tenantResolver != nil {
+		mgr.SetTenantResolver(b.tenantResolver)
+	}
+	if b.tenantValidator != nil {
+		mgr.SetTenantValidator(b.tenantValidator)
+	}
+	for tenantID, fn := range b.tenantConfigs {
+		mgr.SetTenantConfig(tenantID, fn)
+	}
 
 	// Note: If you use the stputil package, it will automatically set the global Manager | 注意：如果你使用了 stputil 包，它会自动设置全局 Manager
 	// We don't directly call stputil.SetManager here to avoid hard dependencies | 这里不直接调用 stputil.SetManager，避免强依赖
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/click33/sa-token-go/core/pool"
 )
 
@@ -43,13 +45,35 @@ const (
 
 // Default configuration constants | 默认配置常量
 const (
-	DefaultTokenName     = "satoken"
-	DefaultTimeout       = 2592000 // 30 days in seconds | 30天（秒）
-	DefaultMaxLoginCount = 12      // Maximum concurrent logins | 最大并发登录数
-	DefaultCookiePath    = "/"
-	NoLimit              = -1 // No limit flag | 不限制标志
+	DefaultTokenName      = "satoken"
+	DefaultTimeout        = 2592000 // 30 days in seconds | 30天（秒）
+	DefaultRefreshTimeout = 2592000 // 30 days in seconds | 30天（秒）
+	DefaultMaxLoginCount  = 12      // Maximum concurrent logins | 最大并发登录数
+	DefaultCookiePath     = "/"
+	DefaultLoginType      = "login"
+	NoLimit               = -1 // No limit flag | 不限制标志
 )
 
+// reservedLoginTypes First key segments used by the default namespace, a login type named like one would share its keys | 默认命名空间使用的首段键名，同名的登录类型会与其共用键
+var reservedLoginTypes = []string{
+	"token", "account", "active", "session", "role", "tenant",
+	"disable", "disable-service", "permission-cache", "nonce", "oauth2",
+	"refresh", "refresh-family", "refresh-user", "refresh-used",
+}
+
+// ValidateLoginType Checks that a login type can be used as a key segment | 检查登录类型能否作为键段使用
+func ValidateLoginType(loginType string) error {
+	if strings.ContainsAny(loginType, ":*") {
+		return fmt.Errorf("LoginType cannot contain ':' or '*', got: %s", loginType)
+	}
+	for _, reserved := range reservedLoginTypes {
+		if loginType == reserved {
+			return fmt.Errorf("LoginType %q is reserved for storage keys", loginType)
+		}
+	}
+	return nil
+}
+
 // IsValid checks if the TokenStyle is valid | 检查TokenStyle是否有效
 func (ts TokenStyle) IsValid() bool {
 	switch ts {
@@ -100,7 +124,7 @@ type Config struct {
 	// TokenSessionCheckLogin Check if Token-Session is kicked out when logging in (true=check on login, false=skip check) | Token-Session在登录时是否检查（true=登录时验证是否被踢下线，false=不作此检查）
 	TokenSessionCheckLogin bool
 
-	// AutoRenew Auto-renew Token expiration time on each validation | 是否自动续期（每次验证Token时，都会延长Token的有效期）
+	// AutoRenew Auto-renew Token expiration time on validation, skipped while the TTL is within a tenth of the timeout (at most one minute) of full | 是否自动续期（验证Token时延长有效期，剩余有效期距完整有效期不足超时时间的十分之一（最多一分钟）时跳过）
 	AutoRenew bool
 
 	// JwtSecretKey JWT secret key (only effective when TokenStyle=JWT) | JWT密钥（只有TokenStyle=JWT时，此配置才生效）
@@ -116,6 +140,39 @@ type Config struct {
 	// Set to empty "" to be compatible with Java sa-token default behavior | 设置为空""以兼容Java sa-token默认行为
 	KeyPrefix string
 
+	// LoginType Account type of this manager, e.g. "login" or "admin"; other types than "login" get their own key namespace "<KeyPrefix><LoginType>:" (default: "login") | 该管理器的账号类型，例如"login"或"admin"；"login"之外的类型拥有独立的键命名空间"<KeyPrefix><LoginType>:"（默认："login"）
+	LoginType string
+
+	// TokenHashAtRest Store token digests instead of raw token values in storage keys and the account index (default: false) | 在存储键和账号索引中保存Token摘要而非原始Token（默认：false）
+	TokenHashAtRest bool
+
+	// TokenHashSecret HMAC key for token digests, plain SHA-256 is used when empty (only effective when TokenHashAtRest=true) | Token摘要的HMAC密钥，为空时使用SHA-256（只有TokenHashAtRest=true时才生效）
+	TokenHashSecret string
+
+	// RefreshTimeout Refresh token expiration time in seconds (default: 30 days) | 刷新令牌超时时间（单位：秒，默认：30天）
+	RefreshTimeout int64
+
+	// RefreshSliding Extend refresh token expiration by RefreshTimeout on every refresh instead of keeping the original expiration (default: false) | 每次刷新时将刷新令牌有效期延长RefreshTimeout，而非保持原有过期时间（默认：false）
+	RefreshSliding bool
+
+	// RefreshMaxLifetime Maximum absolute lifetime of a refresh token family in seconds, -1 means no limit | 刷新令牌家族的最长绝对寿命（单位：秒），-1代表不限制
+	RefreshMaxLifetime int64
+
+	// RefreshBindFingerprint Bind refresh tokens to the client fingerprint captured at issuance (default: false) | 将刷新令牌绑定到签发时采集的客户端指纹（默认：false）
+	RefreshBindFingerprint bool
+
+	// CascadeRefreshToken Revoke refresh tokens on Logout, Kickout and Disable (default: false) | 登出、踢人下线和封禁时同时撤销刷新令牌（默认：false）
+	CascadeRefreshToken bool
+
+	// AcceptOAuth2Token Accept OAuth2 access tokens in permission checks, scopes map to permissions (default: false) | 权限校验时接受OAuth2访问令牌，权限范围映射为权限（默认：false）
+	AcceptOAuth2Token bool
+
+	// PermissionCacheTimeout Seconds an account's permissions and roles stay cached in storage, 0 disables caching (default: 0) | 账号权限和角色在存储中的缓存时间（单位：秒），0代表不缓存（默认：0）
+	PermissionCacheTimeout int64
+
+	// PermissionIgnoreCase Compare permission codes ignoring case (default: false) | 比较权限码时忽略大小写（默认：false）
+	PermissionIgnoreCase bool
+
 	// CookieConfig Cookie configuration | Cookie配置
 	CookieConfig *CookieConfig
 
@@ -164,6 +221,16 @@ func DefaultConfig() *Config {
 		IsLog:                  false,
 		IsPrintBanner:          true,
 		KeyPrefix:              "satoken:",
+		LoginType:              DefaultLoginType,
+		TokenHashAtRest:        false,
+		RefreshTimeout:         DefaultRefreshTimeout,
+		RefreshSliding:         false,
+		RefreshMaxLifetime:     NoLimit,
+		RefreshBindFingerprint: false,
+		CascadeRefreshToken:    false,
+		AcceptOAuth2Token:      false,
+		PermissionCacheTimeout: 0,
+		PermissionIgnoreCase:   false,
 		CookieConfig: &CookieConfig{
 			Domain:   "",
 			Path:     DefaultCookiePath,
@@ -202,6 +269,26 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("ActiveTimeout must be >= -1, got: %d", c.ActiveTimeout)
 	}
 
+	// Check RefreshTimeout
+	if c.RefreshTimeout < 0 {
+		return fmt.Errorf("RefreshTimeout must be >= 0, got: %d", c.RefreshTimeout)
+	}
+
+	// Check RefreshMaxLifetime
+	if c.RefreshMaxLifetime < NoLimit {
+		return fmt.Errorf("RefreshMaxLifetime must be >= -1, got: %d", c.RefreshMaxLifetime)
+	}
+
+	// Check LoginType, it becomes a key segment
+	if err := ValidateLoginType(c.LoginType); err != nil {
+		return err
+	}
+
+	// Check PermissionCacheTimeout
+	if c.PermissionCacheTimeout < 0 {
+		return fmt.Errorf("PermissionCacheTimeout must be >= 0, got: %d", c.PermissionCacheTimeout)
+	}
+
 	// Check MaxLoginCount
 	if c.MaxLoginCount < NoLimit {
 		return fmt.Errorf("MaxLoginCount must be >= -1, got: %d", c.MaxLoginCount)
@@ -356,6 +443,72 @@ func (c *Config) SetKeyPrefix(prefix string) *Config {
 	return c
 }
 
+// SetLoginType Set the account type | 设置账号类型
+func (c *Config) SetLoginType(loginType string) *Config {
+	c.LoginType = loginType
+	return c
+}
+
+// SetTokenHashAtRest Set whether to store token digests instead of raw tokens | 设置是否存储Token摘要而非原始Token
+func (c *Config) SetTokenHashAtRest(hash bool) *Config {
+	c.TokenHashAtRest = hash
+	return c
+}
+
+// SetTokenHashSecret Set HMAC key for token digests | 设置Token摘要的HMAC密钥
+func (c *Config) SetTokenHashSecret(secret string) *Config {
+	c.TokenHashSecret = secret
+	return c
+}
+
+// SetRefreshTimeout Set refresh token timeout | 设置刷新令牌超时时间
+func (c *Config) SetRefreshTimeout(timeout int64) *Config {
+	c.RefreshTimeout = timeout
+	return c
+}
+
+// SetRefreshSliding Set whether refresh token expiration slides on refresh | 设置刷新令牌是否滑动过期
+func (c *Config) SetRefreshSliding(sliding bool) *Config {
+	c.RefreshSliding = sliding
+	return c
+}
+
+// SetRefreshMaxLifetime Set maximum absolute lifetime of refresh tokens | 设置刷新令牌的最长绝对寿命
+func (c *Config) SetRefreshMaxLifetime(lifetime int64) *Config {
+	c.RefreshMaxLifetime = lifetime
+	return c
+}
+
+// SetRefreshBindFingerprint Set whether refresh tokens are bound to client fingerprint | 设置刷新令牌是否绑定客户端指纹
+func (c *Config) SetRefreshBindFingerprint(bind bool) *Config {
+	c.RefreshBindFingerprint = bind
+	return c
+}
+
+// SetCascadeRefreshToken Set whether Logout, Kickout and Disable revoke refresh tokens | 设置登出、踢人下线和封禁时是否撤销刷新令牌
+func (c *Config) SetCascadeRefreshToken(cascade bool) *Config {
+	c.CascadeRefreshToken = cascade
+	return c
+}
+
+// SetAcceptOAuth2Token Set whether OAuth2 access tokens are accepted in permission checks | 设置权限校验时是否接受OAuth2访问令牌
+func (c *Config) SetAcceptOAuth2Token(accept bool) *Config {
+	c.AcceptOAuth2Token = accept
+	return c
+}
+
+// SetPermissionCacheTimeout Set how many seconds permissions and roles stay cached, 0 disables caching | 设置权限和角色的缓存秒数，0代表不缓存
+func (c *Config) SetPermissionCacheTimeout(timeout int64) *Config {
+	c.PermissionCacheTimeout = timeout
+	return c
+}
+
+// SetPermissionIgnoreCase Set whether permission codes are compared ignoring case | 设置比较权限码时是否忽略大小写
+func (c *Config) SetPermissionIgnoreCase(ignoreCase bool) *Config {
+	c.PermissionIgnoreCase = ignoreCase
+	return c
+}
+
 // SetCookieConfig Set cookie configuration | 设置Cookie配置
 func (c *Config) SetCookieConfig(cookieConfig *CookieConfig) *Config {
 	c.CookieConfig = cookieConfig
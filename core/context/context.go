@@ -5,6 +5,8 @@ import (
 
 	"github.com/click33/sa-token-go/core/adapter"
 	"github.com/click33/sa-token-go/core/manager"
+	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/click33/sa-token-go/core/security"
 )
 
 const (
@@ -16,16 +18,41 @@ const (
 type SaTokenContext struct {
 	ctx     adapter.RequestContext
 	manager *manager.Manager
+
+	oauth2Token    *oauth2.AccessToken // Bridged OAuth2 access token | 桥接的OAuth2访问令牌
+	oauth2Resolved bool
 }
 
 // NewContext creates a new Sa-Token context | 创建新的Sa-Token上下文
+// The manager is switched to the request's tenant when a tenant resolver is set | 设置了租户解析器时切换到请求所属租户的管理器
 func NewContext(ctx adapter.RequestContext, mgr *manager.Manager) *SaTokenContext {
 	return &SaTokenContext{
 		ctx:     ctx,
-		manager: mgr,
+		manager: mgr.ResolveTenant(ctx),
 	}
 }
 
+// NewOAuth2Handler creates OAuth2 endpoint handlers resolving the user from the current login | 创建OAuth2端点处理器，使用当前登录用户作为资源所有者
+// Only a Sa-Token login is the resource owner, OAuth2 access tokens never are | 只有Sa-Token登录可作为资源所有者，OAuth2访问令牌永远不行
+// auth_time of ID tokens is the login time of the user's session | ID令牌的auth_time为用户会话的登录时间
+func NewOAuth2Handler(mgr *manager.Manager) *oauth2.Handler {
+	handler := oauth2.NewHandler(mgr.GetOAuth2Server(), func(ctx adapter.RequestContext) (string, error) {
+		return NewContext(ctx, mgr).GetLoginID()
+	})
+	// Codes and tokens are issued by the server of the request's tenant | 授权码和令牌由请求所属租户的服务器签发
+	handler.SetServerResolver(func(ctx adapter.RequestContext) *oauth2.OAuth2Server {
+		return mgr.ResolveTenant(ctx).GetOAuth2Server()
+	})
+	handler.SetAuthTimeResolver(func(ctx adapter.RequestContext, userID string) int64 {
+		sess, err := mgr.ResolveTenant(ctx).GetSession(userID)
+		if err != nil {
+			return 0
+		}
+		return sess.GetInt64(manager.SessionKeyLoginTime)
+	})
+	return handler
+}
+
 // extractBearerToken 从 Authorization 头中提取 Bearer Token
 func extractBearerToken(auth string) string {
 	auth = strings.TrimSpace(auth)
@@ -75,42 +102,174 @@ func (c *SaTokenContext) GetTokenValue() string {
 	return ""
 }
 
-// IsLogin 检查当前请求是否已登录
+// IsLogin 检查当前请求是否已登录（OAuth2访问令牌不视为登录）
 func (c *SaTokenContext) IsLogin() bool {
 	token := c.GetTokenValue()
 	return c.manager.IsLogin(token)
 }
 
-// CheckLogin 检查登录（未登录抛出错误）
+// CheckLogin 检查登录（未登录抛出错误，OAuth2访问令牌不视为登录）
 func (c *SaTokenContext) CheckLogin() error {
 	token := c.GetTokenValue()
 	return c.manager.CheckLogin(token)
 }
 
-// GetLoginID 获取当前登录ID
+// GetLoginID 获取当前登录ID（只返回Sa-Token登录，OAuth2访问令牌永远不会作为登录ID）
 func (c *SaTokenContext) GetLoginID() (string, error) {
 	token := c.GetTokenValue()
 	return c.manager.GetLoginID(token)
 }
 
-// HasPermission 检查是否有指定权限
-func (c *SaTokenContext) HasPermission(permission string) bool {
+// GetCallerID 获取权限校验调用方的登录ID
+// Sa-Token登录优先；启用AcceptOAuth2Token时，代表用户签发的OAuth2访问令牌以其UserID通过
+// OAuth2调用方只能通过权限校验，调用方必须随后调用HasPermission等方法
+func (c *SaTokenContext) GetCallerID() (string, error) {
 	loginID, err := c.GetLoginID()
 	if err != nil {
-		return false
+		if accessToken := c.resolveOAuth2Token(); accessToken != nil {
+			return accessToken.UserID, nil
+		}
 	}
-	return c.manager.HasPermission(loginID, permission)
+	return loginID, err
 }
 
-// HasRole 检查是否有指定角色
+// HasPermission 检查是否有指定权限（OAuth2调用方按令牌权限范围检查）
+func (c *SaTokenContext) HasPermission(permission string) bool {
+	loginID, err := c.manager.GetLoginID(c.GetTokenValue())
+	if err == nil {
+		return c.manager.HasPermission(loginID, permission)
+	}
+	if accessToken := c.resolveOAuth2Token(); accessToken != nil {
+		return c.manager.HasOAuth2Permission(accessToken, permission)
+	}
+	return false
+}
+
+// HasPermissionsAnd 检查是否拥有所有权限（AND，账号权限只编译一次）
+func (c *SaTokenContext) HasPermissionsAnd(permissions []string) bool {
+	loginID, err := c.manager.GetLoginID(c.GetTokenValue())
+	if err == nil {
+		return c.manager.HasPermissionsAnd(loginID, permissions)
+	}
+	for _, perm := range permissions {
+		if !c.HasPermission(perm) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasPermissionsOr 检查是否拥有任一权限（OR，账号权限只编译一次）
+func (c *SaTokenContext) HasPermissionsOr(permissions []string) bool {
+	loginID, err := c.manager.GetLoginID(c.GetTokenValue())
+	if err == nil {
+		return c.manager.HasPermissionsOr(loginID, permissions)
+	}
+	for _, perm := range permissions {
+		if c.HasPermission(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole 检查是否有指定角色（角色不会委托给OAuth2调用方）
 func (c *SaTokenContext) HasRole(role string) bool {
-	loginID, err := c.GetLoginID()
+	loginID, err := c.manager.GetLoginID(c.GetTokenValue())
 	if err != nil {
 		return false
 	}
 	return c.manager.HasRole(loginID, role)
 }
 
+// HasRolesAnd 检查是否拥有所有角色（AND）
+func (c *SaTokenContext) HasRolesAnd(roles []string) bool {
+	for _, role := range roles {
+		if !c.HasRole(role) {
+			return false
+		}
+	}
+	return true
+}
+
+// HasRolesOr 检查是否拥有任一角色（OR）
+func (c *SaTokenContext) HasRolesOr(roles []string) bool {
+	for _, role := range roles {
+		if c.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查当前登录账号能否对资源执行操作（基于属性的策略）
+// 请求的method、path、ip会放入策略上下文，attributes可追加额外属性（如路由参数）
+func (c *SaTokenContext) Check(action string, resource any, attributes ...map[string]any) error {
+	loginID, err := c.GetLoginID()
+	if err != nil {
+		return err
+	}
+
+	ctx := map[string]any{
+		"method": c.ctx.GetMethod(),
+		"path":   c.ctx.GetPath(),
+		"ip":     c.ctx.GetClientIP(),
+	}
+	for _, attrs := range attributes {
+		for key, value := range attrs {
+			ctx[key] = value
+		}
+	}
+	return c.manager.CheckWithContext(loginID, action, resource, ctx)
+}
+
+// GetOAuth2Token 获取当前请求作为登录凭证的OAuth2访问令牌（未启用AcceptOAuth2Token或已通过Sa-Token登录时返回nil）
+func (c *SaTokenContext) GetOAuth2Token() *oauth2.AccessToken {
+	if c.manager.IsLogin(c.GetTokenValue()) {
+		return nil
+	}
+	return c.resolveOAuth2Token()
+}
+
+// resolveOAuth2Token 解析并缓存Bearer头中代表用户的OAuth2访问令牌
+func (c *SaTokenContext) resolveOAuth2Token() *oauth2.AccessToken {
+	if !c.manager.GetConfig().AcceptOAuth2Token {
+		return nil
+	}
+	if !c.oauth2Resolved {
+		c.oauth2Resolved = true
+		c.oauth2Token, _ = c.manager.GetOAuth2AccessToken(c.GetBearerToken())
+	}
+	return c.oauth2Token
+}
+
+// GetBearerToken 获取Authorization头中的Bearer Token（OAuth2访问令牌）
+func (c *SaTokenContext) GetBearerToken() string {
+	return extractBearerToken(c.ctx.GetHeader(authHeader))
+}
+
+// CheckOAuth2Scope 校验请求携带的OAuth2访问令牌，并检查是否覆盖所需权限范围
+func (c *SaTokenContext) CheckOAuth2Scope(scopes ...string) (*oauth2.AccessToken, error) {
+	return c.manager.GetOAuth2Server().ValidateAccessTokenWithScope(c.GetBearerToken(), scopes...)
+}
+
+// GetFingerprint 获取客户端指纹（基于IP和User-Agent，用于绑定刷新令牌）
+func (c *SaTokenContext) GetFingerprint() string {
+	return security.ClientFingerprint(c.ctx.GetClientIP(), c.ctx.GetUserAgent())
+}
+
+// GetTenantID 获取当前请求所属租户ID（无租户时为空）
+func (c *SaTokenContext) GetTenantID() string {
+	return c.manager.GetTenantID()
+}
+
+// SetTenantID 手动指定当前请求所属租户（覆盖解析结果，""表示根管理器）
+func (c *SaTokenContext) SetTenantID(tenantID string) *SaTokenContext {
+	c.manager = c.manager.Tenant(tenantID)
+	c.oauth2Token, c.oauth2Resolved = nil, false
+	return c
+}
+
 // GetRequestContext 获取原始请求上下文
 func (c *SaTokenContext) GetRequestContext() adapter.RequestContext {
 	return c.ctx
@@ -3,6 +3,10 @@ package core
 import (
 	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/click33/sa-token-go/core/manager"
+	"github.com/click33/sa-token-go/core/oauth2"
 )
 
 // Common error definitions for better error handling and internationalization support
@@ -35,6 +39,15 @@ var (
 
 	// ErrRoleDenied indicates insufficient role | 角色权限不足
 	ErrRoleDenied = fmt.Errorf("role denied: you don't have the required role")
+
+	// ErrScopeDenied indicates the OAuth2 access token lacks a required scope | OAuth2访问令牌缺少所需权限范围
+	ErrScopeDenied = fmt.Errorf("scope denied: the access token does not grant the required scope")
+
+	// ErrPolicyDenied indicates an access policy rejected the action | 访问策略拒绝了该操作
+	ErrPolicyDenied = fmt.Errorf("policy denied: you are not allowed to perform this action")
+
+	// ErrResourceNotFound indicates the resource of a policy check could not be loaded | 无法加载策略检查的目标资源
+	ErrResourceNotFound = fmt.Errorf("resource not found: the requested resource does not exist")
 )
 
 // ============ Account Errors | 账号错误 ============
@@ -56,6 +69,9 @@ var (
 	// ErrKickedOut indicates the user has been kicked out | 用户已被踢下线
 	ErrKickedOut = fmt.Errorf("kicked out: this session has been forcibly terminated")
 
+	// ErrTokenReplaced indicates the token was replaced by a login on another client | Token已被其他客户端的登录顶下线
+	ErrTokenReplaced = fmt.Errorf("replaced: this session has been replaced by a new login")
+
 	// ErrActiveTimeout indicates the session has been inactive for too long | Session活跃超时
 	ErrActiveTimeout = fmt.Errorf("session inactive: the session has exceeded the inactivity timeout")
 
@@ -159,12 +175,103 @@ func NewRoleDeniedError(role string) *SaTokenError {
 		WithContext("role", role)
 }
 
+// NewScopeDeniedError Creates an OAuth2 scope denied error | 创建OAuth2权限范围拒绝错误
+func NewScopeDeniedError(scopes ...string) *SaTokenError {
+	return NewError(CodePermissionDenied, "scope denied", ErrScopeDenied).
+		WithContext("scope", strings.Join(scopes, " "))
+}
+
+// NewOAuth2ScopeError Converts an OAuth2 scope check error | 转换OAuth2权限范围校验错误
+// Missing scope maps to permission denied, anything else to an invalid token | 缺少权限范围视为权限拒绝，其他情况视为Token无效
+func NewOAuth2ScopeError(err error, scopes ...string) *SaTokenError {
+	if errors.Is(err, oauth2.ErrInsufficientScope) {
+		return NewScopeDeniedError(scopes...)
+	}
+	return NewError(CodeNotLogin, "invalid access token", ErrTokenInvalid)
+}
+
+// NewPolicyDeniedError Creates a policy denied error | 创建策略拒绝错误
+func NewPolicyDeniedError(action string) *SaTokenError {
+	return NewError(CodePermissionDenied, "policy denied", ErrPolicyDenied).
+		WithContext("action", action)
+}
+
+// NewPolicyError Converts a policy check error | 转换策略检查错误
+// Login errors keep their codes, policy rejections map to permission denied | 登录错误保留各自错误码，策略拒绝视为权限拒绝
+func NewPolicyError(err error, action string) *SaTokenError {
+	if loginErr, ok := AsLoginError(err); ok {
+		return loginErr
+	}
+	if manager.IsPolicyError(err) {
+		return NewPolicyDeniedError(action)
+	}
+	return NewError(CodeServerError, "policy check failed", err)
+}
+
+// NewResourceError Converts a resource loading error | 转换资源加载错误
+// SaTokenErrors pass through, anything else maps to not found | SaTokenError原样返回，其他情况视为资源未找到
+func NewResourceError(err error) *SaTokenError {
+	var saErr *SaTokenError
+	if errors.As(err, &saErr) {
+		return saErr
+	}
+	return NewError(CodeNotFound, "resource not found", ErrResourceNotFound)
+}
+
 // NewAccountDisabledError Creates an account disabled error | 创建账号禁用错误
 func NewAccountDisabledError(loginID string) *SaTokenError {
 	return NewError(CodeAccountDisabled, "account disabled", ErrAccountDisabled).
 		WithContext("loginID", loginID)
 }
 
+// NewDisableError Converts a ban returned by the manager's CheckDisable | 转换管理器CheckDisable返回的封禁错误
+// The ban's service, level and reason are attached as context | 附带封禁的服务、等级和原因作为上下文
+func NewDisableError(err error) *SaTokenError {
+	var disableErr *manager.DisableError
+	if !errors.As(err, &disableErr) {
+		if loginErr, ok := AsLoginError(err); ok {
+			return loginErr
+		}
+		return NewError(CodeServerError, "disable check failed", err)
+	}
+
+	info := disableErr.Info
+	message := "account disabled"
+	if info.Service != manager.DefaultDisableService {
+		message = "service disabled: " + info.Service
+	}
+	saErr := NewError(CodeAccountDisabled, message, ErrAccountDisabled).
+		WithContext("loginID", info.LoginID).
+		WithContext("service", info.Service).
+		WithContext("level", info.Level)
+	if info.Reason != "" {
+		saErr.WithContext("reason", info.Reason)
+	}
+	return saErr
+}
+
+// AsLoginError Converts a typed login state error from the manager | 转换管理器返回的登录状态错误
+// Returns false when err is not a login state error | err不是登录状态错误时返回false
+func AsLoginError(err error) (*SaTokenError, bool) {
+	switch {
+	case errors.Is(err, manager.ErrNotToken):
+		return NewError(CodeNotLogin, "token not provided", ErrNotLogin), true
+	case errors.Is(err, manager.ErrTokenInvalid):
+		return NewError(CodeTokenInvalid, "invalid token", ErrTokenInvalid), true
+	case errors.Is(err, manager.ErrTokenExpired):
+		return NewError(CodeTokenExpired, "token expired", ErrTokenExpired), true
+	case errors.Is(err, manager.ErrTokenKickedOut):
+		return NewError(CodeKickedOut, "kicked out", ErrKickedOut), true
+	case errors.Is(err, manager.ErrTokenReplaced):
+		return NewError(CodeTokenReplaced, "replaced by another login", ErrTokenReplaced), true
+	case errors.Is(err, manager.ErrTokenFrozen):
+		return NewError(CodeActiveTimeout, "token frozen", ErrActiveTimeout), true
+	case errors.Is(err, manager.ErrNotLogin):
+		return NewNotLoginError(), true
+	}
+	return nil, false
+}
+
 // ============ Error Checking Helpers | 错误检查辅助函数 ============
 
 // IsNotLoginError Checks if error is a not login error | 检查是否为未登录错误
@@ -184,7 +291,8 @@ func IsAccountDisabledError(err error) bool {
 
 // IsTokenError Checks if error is a token-related error | 检查是否为Token相关错误
 func IsTokenError(err error) bool {
-	return errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrTokenExpired)
+	return errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrKickedOut) || errors.Is(err, ErrTokenReplaced) || errors.Is(err, ErrActiveTimeout)
 }
 
 // GetErrorCode Extracts error code from SaTokenError | 从SaTokenError中提取错误码
@@ -217,4 +325,5 @@ const (
 	CodeStorageError     = 10007 // Storage backend error | 存储后端错误
 	CodeInvalidParameter = 10008 // Invalid parameter | 无效参数
 	CodeSessionError     = 10009 // Session operation error | Session操作错误
+	CodeTokenReplaced    = 10010 // Token replaced by a new login | Token已被新登录顶下线
 )
@@ -40,6 +40,9 @@ const (
 	// EventRoleCheck fired when a role check is performed | 角色检查事件
 	EventRoleCheck Event = "roleCheck"
 
+	// EventRefreshTokenReuse fired when a rotated refresh token is presented again | 已轮换的刷新令牌被再次使用事件
+	EventRefreshTokenReuse Event = "refreshTokenReuse"
+
 	// EventAll is a wildcard event that matches all events | 通配符事件（匹配所有事件）
 	EventAll Event = "*"
 )
@@ -51,6 +54,8 @@ type EventData struct {
 	Device    string         // Device identifier | 设备标识
 	Token     string         // Authentication token | 认证Token
 	Extra     map[string]any // Additional custom data | 额外的自定义数据
+	LoginType string         // Account type of the manager | 管理器的账号类型
+	TenantID  string         // Tenant ID, empty outside tenants | 租户ID，非租户时为空
 	Timestamp int64          // Unix timestamp when event was triggered | 事件触发的Unix时间戳
 }
 
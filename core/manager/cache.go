@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/click33/sa-token-go/core/listener"
+)
+
+// Permission cache | 权限缓存
+//
+// With PermissionCacheTimeout > 0, GetPermissions() and GetRoles() keep the provider's answer in
+// adapter.Storage so every node shares it. SetPermissions(), SetRoles() and login, logout and kickout
+// events drop an account's entries; InvalidatePermissions() and InvalidateAll() do so explicitly.
+// PermissionCacheTimeout > 0 时，GetPermissions()和GetRoles()将提供者的结果保存在adapter.Storage中供所有节点共享。
+// SetPermissions()、SetRoles()以及登录、登出、踢人下线事件会清除账号的缓存；InvalidatePermissions()和InvalidateAll()用于显式清除。
+
+// PermissionCacheKeyPrefix Permission cache key prefix | 权限缓存键前缀
+const PermissionCacheKeyPrefix = "permission-cache:"
+
+// Cached lists of an account | 账号的缓存列表
+const (
+	cacheKindPermissions = "permissions"
+	cacheKindRoles       = "roles"
+)
+
+// permissionCacheListenerID Listener that drops cached entries on account events | 在账号事件时清除缓存的监听器
+const permissionCacheListenerID = "sa-token:permission-cache"
+
+// InvalidatePermissions Drops the cached permissions and roles of an account | 清除账号缓存的权限和角色
+func (m *Manager) InvalidatePermissions(loginID string) error {
+	return m.storage.Delete(
+		m.getPermissionCacheKey(loginID, cacheKindPermissions),
+		m.getPermissionCacheKey(loginID, cacheKindRoles),
+	)
+}
+
+// InvalidateAll Drops every cached permission and role list of this login type | 清除该登录类型下所有缓存的权限和角色
+func (m *Manager) InvalidateAll() error {
+	keys, err := m.storage.Keys(m.prefix + PermissionCacheKeyPrefix + m.loginType + PermissionSeparator + "*")
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return m.storage.Delete(keys...)
+}
+
+// cachedList Loads a list through the cache when PermissionCacheTimeout is set | 设置了PermissionCacheTimeout时通过缓存加载列表
+func (m *Manager) cachedList(loginID, kind string, load func(loginID, loginType string) ([]string, error)) ([]string, error) {
+	if m.config.PermissionCacheTimeout <= 0 {
+		return load(loginID, m.loginType)
+	}
+
+	key := m.getPermissionCacheKey(loginID, kind)
+	if data, err := m.storage.Get(key); err == nil && data != nil {
+		if list, ok := decodeCachedList(data); ok {
+			return list, nil
+		}
+	}
+
+	list, err := load(loginID, m.loginType)
+	if err != nil {
+		return nil, err // Failures are not cached | 失败结果不缓存
+	}
+	if list == nil {
+		list = []string{}
+	}
+	if encoded, err := json.Marshal(list); err == nil {
+		m.storage.Set(key, string(encoded), time.Duration(m.config.PermissionCacheTimeout)*time.Second)
+	}
+	return list, nil
+}
+
+// decodeCachedList Decodes a cached list stored as JSON | 解码以JSON保存的缓存列表
+func decodeCachedList(data any) ([]string, bool) {
+	var raw []byte
+	switch v := data.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		return nil, false
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, false
+	}
+	return list, true
+}
+
+// registerPermissionCacheListener Drops an account's cache on login, logout and kickout | 在登录、登出和踢人下线时清除账号缓存
+// Registered once on the root, events are dispatched to the manager of their tenant | 只在根管理器上注册一次，事件分发给所属租户的管理器
+func (m *Manager) registerPermissionCacheListener() {
+	root := m.root()
+	root.cacheListener.Do(func() {
+		invalidate := func(data *listener.EventData) {
+			if data.LoginID == "" {
+				return
+			}
+			if target := root.Tenant(data.TenantID); target.config.PermissionCacheTimeout > 0 {
+				target.InvalidatePermissions(data.LoginID)
+			}
+		}
+		for _, event := range []listener.Event{listener.EventLogin, listener.EventLogout, listener.EventKickout} {
+			root.eventManager.RegisterFuncWithConfig(event, invalidate, listener.ListenerConfig{
+				Async:    false,
+				Priority: 100,
+				ID:       permissionCacheListenerID + PermissionSeparator + string(event),
+			})
+		}
+	})
+}
+
+// getPermissionCacheKey Gets the cache key of an account's list | 获取账号列表的缓存键
+func (m *Manager) getPermissionCacheKey(loginID, kind string) string {
+	return m.prefix + PermissionCacheKeyPrefix + m.loginType + PermissionSeparator + loginID + PermissionSeparator + kind
+}
@@ -0,0 +1,352 @@
+package manager
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/click33/sa-token-go/core/listener"
+	"github.com/click33/sa-token-go/core/utils"
+)
+
+// Account disable | 账号封禁
+//
+// A ban targets one service of an account ("comment", "payment", ...) with a level, mirroring Java
+// sa-token's disable(loginId, service, level, time). The default service "login" bans the whole
+// account: it blocks Login() and is what Disable(), IsDisable() and Untie() work on. Every ban keeps
+// its reason and operator so support staff can see why an account was restricted.
+// 封禁针对账号的某个服务（"comment"、"payment"等）并带有等级，对应Java sa-token的disable(loginId, service, level, time)。
+// 默认服务"login"封禁整个账号：它会阻止Login()，也是Disable()、IsDisable()和Untie()操作的对象。每条封禁都会保存原因和操作人，
+// 便于客服查看账号受限的原因。
+
+// Disable constants | 封禁常量
+const (
+	DisableServiceKeyPrefix = "disable-service:"
+
+	DefaultDisableService = "login" // Service that bans the whole account | 封禁整个账号的服务
+	DefaultDisableLevel   = 1       // Level used when none is given | 未指定时使用的等级
+	MinDisableLevel       = 1       // Lowest level, checking it matches any ban | 最低等级，按此等级检查时任何封禁都命中
+	NotDisableLevel       = -2      // Level reported for services that are not disabled | 未封禁服务返回的等级
+)
+
+// Disable errors | 封禁错误
+var (
+	ErrNotDisabled           = fmt.Errorf("account is not disabled")
+	ErrInvalidDisableLevel   = fmt.Errorf("disable level must be at least %d", MinDisableLevel)
+	ErrInvalidDisableService = fmt.Errorf("disable service cannot contain ':' or '*'")
+	ErrInvalidDisableLoginID = fmt.Errorf("disable login ID cannot be empty")
+)
+
+// DisableInfo Ban record of one service of an account | 账号某个服务的封禁记录
+type DisableInfo struct {
+	LoginID     string `json:"loginId"`            // Banned account | 被封禁的账号
+	Service     string `json:"service"`            // Banned service, "login" for the whole account | 被封禁的服务，"login"表示整个账号
+	Level       int    `json:"level"`              // Disable level, higher is stricter | 封禁等级，越高越严格
+	Reason      string `json:"reason,omitempty"`   // Why the account was banned | 封禁原因
+	Operator    string `json:"operator,omitempty"` // Who banned the account | 操作人
+	DisableTime int64  `json:"disableTime"`        // When the ban started | 封禁开始时间
+	ExpireTime  int64  `json:"expireTime"`         // When the ban ends, 0 for permanent | 封禁结束时间，0为永久
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (d *DisableInfo) MarshalBinary() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (d *DisableInfo) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, d)
+}
+
+// DisableError Returned when a check hits a ban, wraps ErrAccountDisabled | 检查命中封禁时返回，包装ErrAccountDisabled
+type DisableError struct {
+	Info *DisableInfo
+}
+
+// Error implements error | 实现error接口
+func (e *DisableError) Error() string {
+	if e.Info.Service == DefaultDisableService {
+		return fmt.Sprintf("%s: level %d", ErrAccountDisabled, e.Info.Level)
+	}
+	return fmt.Sprintf("%s: service %s, level %d", ErrAccountDisabled, e.Info.Service, e.Info.Level)
+}
+
+// Unwrap returns ErrAccountDisabled | 返回ErrAccountDisabled
+func (e *DisableError) Unwrap() error {
+	return ErrAccountDisabled
+}
+
+// ============ Account Disable | 账号封禁 ============
+
+// Disable Disables an account | 封禁账号
+func (m *Manager) Disable(loginID string, duration time.Duration) error {
+	return m.DisableWithInfo(&DisableInfo{LoginID: loginID}, duration)
+}
+
+// DisableService Disables one service of an account | 封禁账号的指定服务
+func (m *Manager) DisableService(loginID, service string, duration time.Duration) error {
+	return m.DisableWithInfo(&DisableInfo{LoginID: loginID, Service: service}, duration)
+}
+
+// DisableLevel Disables one service of an account at a level | 按等级封禁账号的指定服务
+func (m *Manager) DisableLevel(loginID, service string, level int, duration time.Duration) error {
+	if level < MinDisableLevel {
+		return ErrInvalidDisableLevel
+	}
+	return m.DisableWithInfo(&DisableInfo{LoginID: loginID, Service: service, Level: level}, duration)
+}
+
+// DisableWithInfo Disables an account with a full ban record, duration 0 is permanent | 使用完整封禁记录封禁账号，时长为0表示永久
+// Service defaults to "login" and Level to DefaultDisableLevel | Service默认为"login"，Level默认为DefaultDisableLevel
+func (m *Manager) DisableWithInfo(info *DisableInfo, duration time.Duration) error {
+	if info == nil || info.LoginID == "" {
+		return ErrInvalidDisableLoginID
+	}
+
+	service, err := normalizeDisableService(info.Service)
+	if err != nil {
+		return err
+	}
+	level := info.Level
+	if level == 0 {
+		level = DefaultDisableLevel
+	}
+	if level < MinDisableLevel {
+		return ErrInvalidDisableLevel
+	}
+
+	now := time.Now()
+	stored := &DisableInfo{
+		LoginID:     info.LoginID,
+		Service:     service,
+		Level:       level,
+		Reason:      info.Reason,
+		Operator:    info.Operator,
+		DisableTime: now.Unix(),
+	}
+	if duration > 0 {
+		stored.ExpireTime = now.Add(duration).Unix()
+	}
+
+	if err := m.storage.Set(m.getDisableServiceKey(info.LoginID, service), stored, duration); err != nil {
+		return err
+	}
+
+	// Trigger disable event | 触发封禁事件
+	m.TriggerEvent(&listener.EventData{
+		Event:   listener.EventDisable,
+		LoginID: info.LoginID,
+		Extra: map[string]any{
+			"service":    service,
+			"level":      level,
+			"duration":   int64(duration / time.Second),
+			"reason":     stored.Reason,
+			"operator":   stored.Operator,
+			"expireTime": stored.ExpireTime,
+		},
+	})
+
+	// Revoke refresh tokens of all devices when the whole account is banned | 封禁整个账号时撤销所有设备的刷新令牌
+	if service == DefaultDisableService && m.config.CascadeRefreshToken {
+		return m.refreshManager.RevokeAllRefreshTokens(info.LoginID)
+	}
+
+	return nil
+}
+
+// Untie Re-enables a disabled account | 解封账号
+func (m *Manager) Untie(loginID string) error {
+	return m.UntieService(loginID, DefaultDisableService)
+}
+
+// UntieService Lifts the bans of the given services | 解除指定服务的封禁
+func (m *Manager) UntieService(loginID string, services ...string) error {
+	for _, service := range services {
+		service, err := normalizeDisableService(service)
+		if err != nil {
+			return err
+		}
+		key := m.getDisableServiceKey(loginID, service)
+		if !m.storage.Exists(key) {
+			continue
+		}
+		if err := m.storage.Delete(key); err != nil {
+			return err
+		}
+
+		// Trigger untie event | 触发解封事件
+		m.TriggerEvent(&listener.EventData{
+			Event:   listener.EventUntie,
+			LoginID: loginID,
+			Extra: map[string]any{
+				"service": service,
+			},
+		})
+	}
+	return nil
+}
+
+// IsDisable Checks if account is disabled | 检查账号是否被封禁
+func (m *Manager) IsDisable(loginID string) bool {
+	return m.IsDisableService(loginID, DefaultDisableService)
+}
+
+// IsDisableService Checks if a service of the account is disabled | 检查账号的指定服务是否被封禁
+func (m *Manager) IsDisableService(loginID, service string) bool {
+	return m.IsDisableLevel(loginID, service, MinDisableLevel)
+}
+
+// IsDisableLevel Checks if a service of the account is disabled at level or above | 检查账号的指定服务是否被封禁且等级不低于level
+func (m *Manager) IsDisableLevel(loginID, service string, level int) bool {
+	return errors.Is(m.CheckDisable(loginID, service, level), ErrAccountDisabled)
+}
+
+// CheckDisable Returns a *DisableError when the service is disabled at level or above | 服务被封禁且等级不低于level时返回*DisableError
+// An empty service means "login", a level below MinDisableLevel matches any ban | service为空表示"login"，level低于MinDisableLevel时任何封禁都命中
+func (m *Manager) CheckDisable(loginID, service string, level int) error {
+	info, err := m.GetDisableInfo(loginID, service)
+	if err != nil {
+		if errors.Is(err, ErrNotDisabled) {
+			return nil
+		}
+		return err
+	}
+	if info.Level < level {
+		return nil
+	}
+	return &DisableError{Info: info}
+}
+
+// GetDisableLevel Gets the disable level of a service, NotDisableLevel when not disabled | 获取服务的封禁等级，未封禁时返回NotDisableLevel
+func (m *Manager) GetDisableLevel(loginID, service string) int {
+	info, err := m.GetDisableInfo(loginID, service)
+	if err != nil {
+		return NotDisableLevel
+	}
+	return info.Level
+}
+
+// GetDisableInfo Gets the ban record of a service, ErrNotDisabled when not disabled | 获取服务的封禁记录，未封禁时返回ErrNotDisabled
+func (m *Manager) GetDisableInfo(loginID, service string) (*DisableInfo, error) {
+	service, err := normalizeDisableService(service)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := m.storage.Get(m.getDisableServiceKey(loginID, service))
+	if err != nil || data == nil {
+		return nil, ErrNotDisabled
+	}
+
+	info := &DisableInfo{}
+	if err := decodeDisableInfo(data, info); err != nil {
+		// Bans written before records existed only stored DisableValue | 旧版本的封禁仅保存DisableValue
+		info = &DisableInfo{Level: DefaultDisableLevel}
+	}
+	info.LoginID = loginID
+	info.Service = service
+	return info, nil
+}
+
+// GetDisableList Lists the ban records of an account sorted by service | 按服务排序列出账号的封禁记录
+func (m *Manager) GetDisableList(loginID string) ([]*DisableInfo, error) {
+	var list []*DisableInfo
+	if info, err := m.GetDisableInfo(loginID, DefaultDisableService); err == nil {
+		list = append(list, info)
+	}
+
+	prefix := m.prefix + DisableServiceKeyPrefix
+	keys, err := m.storage.Keys(prefix + "*:" + escapeKeyPattern(loginID))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		service, id, ok := strings.Cut(strings.TrimPrefix(key, prefix), ":")
+		if !ok || id != loginID {
+			continue
+		}
+		if info, err := m.GetDisableInfo(loginID, service); err == nil {
+			list = append(list, info)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Service < list[j].Service })
+	return list, nil
+}
+
+// escapeKeyPattern Escapes glob characters for Storage.Keys | 转义Keys模式中的通配字符
+func escapeKeyPattern(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '*', '?', '[', ']':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// GetDisableTime Gets remaining disable time in seconds | 获取账号剩余封禁时间（秒）
+func (m *Manager) GetDisableTime(loginID string) (int64, error) {
+	return m.GetDisableServiceTime(loginID, DefaultDisableService)
+}
+
+// GetDisableServiceTime Gets remaining disable time of a service in seconds | 获取服务剩余封禁时间（秒）
+func (m *Manager) GetDisableServiceTime(loginID, service string) (int64, error) {
+	service, err := normalizeDisableService(service)
+	if err != nil {
+		return -2, err
+	}
+	ttl, err := m.storage.TTL(m.getDisableServiceKey(loginID, service))
+	if err != nil {
+		return -2, err
+	}
+	return int64(ttl.Seconds()), nil
+}
+
+// getDisableKey Gets disable storage key | 获取禁用存储键
+func (m *Manager) getDisableKey(loginID string) string {
+	return m.prefix + DisableKeyPrefix + loginID
+}
+
+// getDisableServiceKey Gets the key of a service ban, "login" keeps the account key | 获取服务封禁的存储键，"login"沿用账号封禁键
+func (m *Manager) getDisableServiceKey(loginID, service string) string {
+	if service == DefaultDisableService {
+		return m.getDisableKey(loginID)
+	}
+	return m.prefix + DisableServiceKeyPrefix + service + ":" + loginID
+}
+
+// normalizeDisableService Defaults the service to "login" and validates it | 将服务默认为"login"并校验
+func normalizeDisableService(service string) (string, error) {
+	service = strings.TrimSpace(service)
+	if service == "" {
+		return DefaultDisableService, nil
+	}
+	if strings.ContainsAny(service, ":*") {
+		return "", ErrInvalidDisableService
+	}
+	return service, nil
+}
+
+// decodeDisableInfo Decodes a ban record from whatever the storage returned | 从存储返回的数据中解码封禁记录
+func decodeDisableInfo(data any, info *DisableInfo) error {
+	var (
+		raw []byte
+		err error
+	)
+	if marshaler, ok := data.(encoding.BinaryMarshaler); ok {
+		raw, err = marshaler.MarshalBinary()
+	} else {
+		raw, err = utils.ToBytes(data)
+	}
+	if err != nil {
+		return err
+	}
+	return info.UnmarshalBinary(raw)
+}
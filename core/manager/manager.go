@@ -1,19 +1,24 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+
 	"github.com/click33/sa-token-go/core/pool"
 
 	"github.com/click33/sa-token-go/core/adapter"
 	"github.com/click33/sa-token-go/core/config"
 	"github.com/click33/sa-token-go/core/listener"
 	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/click33/sa-token-go/core/permission"
 	"github.com/click33/sa-token-go/core/security"
 	"github.com/click33/sa-token-go/core/session"
 	"github.com/click33/sa-token-go/core/token"
+	"github.com/click33/sa-token-go/core/utils"
 )
 
 // Constants for storage keys and default values | 存储键和默认值常量
@@ -27,6 +32,11 @@ const (
 	TokenKeyPrefix   = "token:"
 	AccountKeyPrefix = "account:"
 	DisableKeyPrefix = "disable:"
+	ActiveKeyPrefix  = "active:"
+
+	// Tombstones kept under the token key for its remaining TTL (Java sa-token -4/-5) | 在Token键剩余有效期内保留的墓碑标记（对应Java sa-token的-4/-5）
+	TokenValueReplaced  = "-4" // Token was replaced by a new login | Token已被新登录顶下线
+	TokenValueKickedOut = "-5" // Token was kicked out | Token已被踢下线
 
 	// Session keys | Session键
 	SessionKeyLoginID     = "loginId"
@@ -48,6 +58,16 @@ var (
 	ErrInvalidTokenData = fmt.Errorf("invalid token data")
 )
 
+// Login state errors, all wrapping ErrNotLogin | 登录状态错误，均包装ErrNotLogin
+var (
+	ErrNotToken       = fmt.Errorf("%w: token not provided", ErrNotLogin)
+	ErrTokenInvalid   = fmt.Errorf("%w: token invalid", ErrNotLogin)
+	ErrTokenExpired   = fmt.Errorf("%w: token expired", ErrNotLogin)
+	ErrTokenKickedOut = fmt.Errorf("%w: token kicked out", ErrNotLogin)
+	ErrTokenReplaced  = fmt.Errorf("%w: token replaced by another login", ErrNotLogin)
+	ErrTokenFrozen    = fmt.Errorf("%w: token frozen after inactivity", ErrNotLogin)
+)
+
 // TokenInfo Token information | Token信息
 type TokenInfo struct {
 	LoginID    string `json:"loginId"`
@@ -66,6 +86,20 @@ type Manager struct {
 	nonceManager   *security.NonceManager
 	refreshManager *security.RefreshTokenManager
 	oauth2Server   *oauth2.OAuth2Server
+	scopeMapper    ScopePermissionMapper
+	permProvider   PermissionProvider
+	loginType      string
+	policies       map[string][]Policy
+	policyMu       sync.RWMutex
+	parent         *Manager // Root manager of a tenant manager | 租户管理器的根管理器
+	tenantID       string
+	tenants        map[string]*Manager
+	tenantConfigs  map[string]TenantConfigFunc
+	tenantResolver TenantResolver
+	tenantAllowed  TenantValidator
+	maxTenants     int
+	tenantMu       sync.RWMutex
+	cacheListener  sync.Once
 	renewPool      *pool.RenewPoolManager
 	eventManager   *listener.Manager
 }
@@ -77,9 +111,14 @@ func NewManager(storage adapter.Storage, cfg *config.Config) *Manager {
 	}
 
 	// Use configured prefix, fallback to default | 使用配置的前缀，回退到默认值
-	prefix := cfg.KeyPrefix
-	if prefix == "" {
-		prefix = DefaultPrefix
+	// Account types other than the default get their own namespace | 非默认账号类型拥有独立的命名空间
+	loginType := cfg.LoginType
+	if loginType == "" {
+		loginType = DefaultLoginType
+	}
+	prefix := basePrefix(cfg)
+	if loginType != DefaultLoginType {
+		prefix += loginType + PermissionSeparator
 	}
 
 	// Initialize renew pool manager if configuration is provided | 如果配置了续期池，初始化续期池管理器
@@ -98,21 +137,46 @@ func NewManager(storage adapter.Storage, cfg *config.Config) *Manager {
 		})
 	}
 
-	return &Manager{
+	eventManager := listener.NewManager()
+
+	// Refresh token manager reports reuse through the shared event manager | 刷新令牌管理器通过共享的事件管理器上报重用
+	refreshManager := security.NewRefreshTokenManager(storage, prefix, TokenKeyPrefix, cfg)
+	refreshManager.SetEventManager(eventManager)
+
+	mgr := &Manager{
 		storage:        storage,
 		config:         cfg,
 		generator:      token.NewGenerator(cfg),
 		prefix:         prefix,
 		nonceManager:   security.NewNonceManager(storage, prefix, DefaultNonceTTL),
-		refreshManager: security.NewRefreshTokenManager(storage, prefix, TokenKeyPrefix, cfg),
+		refreshManager: refreshManager,
 		oauth2Server:   oauth2.NewOAuth2Server(storage, prefix),
-		eventManager:   listener.NewManager(),
+		permProvider:   NewSessionPermissionProvider(storage, prefix),
+		loginType:      loginType,
+		policies:       make(map[string][]Policy),
+		tenants:        make(map[string]*Manager),
+		tenantConfigs:  make(map[string]TenantConfigFunc),
+		maxTenants:     DefaultMaxTenants,
+		eventManager:   eventManager,
 		renewPool:      renewPoolManager,
 	}
+
+	// Refreshed access tokens go through the login path | 刷新出的访问令牌走登录流程
+	refreshManager.SetAccessTokenIssuer(&refreshIssuer{m: mgr})
+
+	if cfg.PermissionCacheTimeout > 0 {
+		mgr.registerPermissionCacheListener()
+	}
+
+	return mgr
 }
 
 // Close closes the Manager and releases resources | 关闭Manager并释放资源
 func (m *Manager) Close() {
+	// Tenant managers borrow the root's pool | 租户管理器借用根管理器的续期池
+	if m.parent != nil {
+		return
+	}
 	if m.renewPool != nil {
 		m.renewPool.Stop() // 安全关闭 renewPool
 		m.renewPool = nil
@@ -129,6 +193,14 @@ func getDevice(device []string) string {
 	return DefaultDevice
 }
 
+// basePrefix gets the configured key prefix, fallback to default | 获取配置的键前缀，回退到默认值
+func basePrefix(cfg *config.Config) string {
+	if cfg.KeyPrefix == "" {
+		return DefaultPrefix
+	}
+	return cfg.KeyPrefix
+}
+
 // getExpiration calculates expiration duration from config | 从配置计算过期时间
 func (m *Manager) getExpiration() time.Duration {
 	if m.config.Timeout > 0 {
@@ -149,14 +221,44 @@ func assertString(v any) (string, bool) {
 func (m *Manager) Login(loginID string, device ...string) (string, error) {
 	deviceType := getDevice(device)
 
+	tokenValue, err := m.createToken(loginID, deviceType)
+	if err != nil {
+		return "", err
+	}
+
+	// Create session, the event only fires when none existed | 创建Session，仅在此前不存在时触发事件
+	created := !m.storage.Exists(m.getSessionKey(loginID))
+	sess := session.NewSession(loginID, m.storage, m.prefix)
+	sess.Set(SessionKeyLoginID, loginID)
+	sess.Set(SessionKeyDevice, deviceType)
+	sess.Set(SessionKeyLoginTime, time.Now().Unix())
+	if created {
+		m.triggerSessionEvent(listener.EventCreateSession, loginID)
+	}
+
+	// Trigger login event | 触发登录事件
+	if m.eventManager != nil {
+		m.TriggerEvent(&listener.EventData{
+			Event:   listener.EventLogin,
+			LoginID: loginID,
+			Token:   tokenValue,
+			Device:  deviceType,
+		})
+	}
+
+	return tokenValue, nil
+}
+
+// createToken Checks disable state, enforces concurrency and stores a new token | 检查封禁状态、处理并发登录并保存新Token
+func (m *Manager) createToken(loginID, deviceType string) (string, error) {
 	// Check if account is disabled | 检查是否被封禁
-	if m.IsDisable(loginID) {
-		return "", ErrAccountDisabled
+	if err := m.CheckDisable(loginID, DefaultDisableService, MinDisableLevel); err != nil {
+		return "", err
 	}
 
-	// Kick out old session if concurrent login is not allowed | 如果不允许并发登录，先踢掉旧的
+	// Replace old session if concurrent login is not allowed | 如果不允许并发登录，先顶掉旧的
 	if !m.config.IsConcurrent {
-		m.kickout(loginID, deviceType)
+		m.endToken(loginID, deviceType, TokenValueReplaced)
 	}
 
 	// Generate token | 生成Token
@@ -173,27 +275,12 @@ func (m *Manager) Login(loginID string, device ...string) (string, error) {
 		return "", fmt.Errorf("failed to save token: %w", err)
 	}
 
-	// Save account-token mapping | 保存账号-Token映射
+	// Save account-token mapping (digest when TokenHashAtRest is enabled) | 保存账号-Token映射（启用TokenHashAtRest时保存摘要）
 	accountKey := m.getAccountKey(loginID, deviceType)
-	if err := m.storage.Set(accountKey, tokenValue, expiration); err != nil {
+	if err := m.storage.Set(accountKey, m.generator.Digest(tokenValue), expiration); err != nil {
 		return "", fmt.Errorf("failed to save account mapping: %w", err)
 	}
-
-	// Create session | 创建Session
-	sess := session.NewSession(loginID, m.storage, m.prefix)
-	sess.Set(SessionKeyLoginID, loginID)
-	sess.Set(SessionKeyDevice, deviceType)
-	sess.Set(SessionKeyLoginTime, time.Now().Unix())
-
-	// Trigger login event | 触发登录事件
-	if m.eventManager != nil {
-		m.eventManager.Trigger(&listener.EventData{
-			Event:   listener.EventLogin,
-			LoginID: loginID,
-			Token:   tokenValue,
-			Device:  deviceType,
-		})
-	}
+	m.touchActive(m.generator.Digest(tokenValue))
 
 	return tokenValue, nil
 }
@@ -210,7 +297,11 @@ func (m *Manager) LoginByToken(loginID string, tokenValue string, device ...stri
 	}
 
 	accountKey := m.getAccountKey(loginID, deviceType)
-	return m.storage.Set(accountKey, tokenValue, expiration)
+	if err := m.storage.Set(accountKey, m.generator.Digest(tokenValue), expiration); err != nil {
+		return err
+	}
+	m.touchActive(m.generator.Digest(tokenValue))
+	return nil
 }
 
 // Logout Performs user logout | 登出
@@ -219,6 +310,12 @@ func (m *Manager) Logout(loginID string, device ...string) error {
 	accountKey := m.getAccountKey(loginID, deviceType)
 
 	tokenValue, err := m.storage.Get(accountKey)
+
+	// Revoke refresh tokens of this device, even if the access token already expired | 撤销该设备的刷新令牌，即使访问令牌已过期
+	if m.config.CascadeRefreshToken {
+		m.refreshManager.RevokeAllRefreshTokens(loginID, deviceType)
+	}
+
 	if err != nil || tokenValue == nil {
 		return nil // Already logged out | 已经登出
 	}
@@ -229,15 +326,15 @@ func (m *Manager) Logout(loginID string, device ...string) error {
 		return nil
 	}
 
-	tokenKey := m.getTokenKey(tokenStr)
-	m.storage.Delete(tokenKey)
+	tokenKey := m.getStoredTokenKey(tokenStr)
+	m.storage.Delete(tokenKey, m.getActiveKey(tokenStr))
 
 	// Delete account mapping | 删除账号映射
 	m.storage.Delete(accountKey)
 
 	// Trigger logout event | 触发登出事件
 	if m.eventManager != nil {
-		m.eventManager.Trigger(&listener.EventData{
+		m.TriggerEvent(&listener.EventData{
 			Event:   listener.EventLogout,
 			LoginID: loginID,
 			Device:  deviceType,
@@ -253,15 +350,18 @@ func (m *Manager) LogoutByToken(tokenValue string) error {
 		return nil
 	}
 
-	// Get loginID before deletion for event | 删除前获取loginID用于事件
+	// Get loginID before deletion for event, tombstones have none | 删除前获取loginID用于事件，墓碑标记没有loginID
 	loginID, _ := m.getLoginIDByToken(tokenValue)
+	if isTombstone(loginID) {
+		loginID = ""
+	}
 
 	tokenKey := m.getTokenKey(tokenValue)
-	err := m.storage.Delete(tokenKey)
+	err := m.storage.Delete(tokenKey, m.getActiveKey(m.generator.Digest(tokenValue)))
 
 	// Trigger logout event | 触发登出事件
 	if m.eventManager != nil && loginID != "" {
-		m.eventManager.Trigger(&listener.EventData{
+		m.TriggerEvent(&listener.EventData{
 			Event:   listener.EventLogout,
 			LoginID: loginID,
 			Token:   tokenValue,
@@ -271,8 +371,9 @@ func (m *Manager) LogoutByToken(tokenValue string) error {
 	return err
 }
 
-// kickout Kick user offline (private) | 踢人下线（私有）
-func (m *Manager) kickout(loginID string, device string) error {
+// endToken Ends a device's login and leaves a tombstone under its token key | 结束设备的登录并在其Token键下保留墓碑标记
+// The tombstone lives for the token's remaining TTL so the client learns why it was logged out | 墓碑标记保留至Token剩余有效期结束，以便客户端得知下线原因
+func (m *Manager) endToken(loginID string, device string, tombstone string) error {
 	accountKey := m.getAccountKey(loginID, device)
 	tokenValue, err := m.storage.Get(accountKey)
 	if err != nil || tokenValue == nil {
@@ -284,84 +385,167 @@ func (m *Manager) kickout(loginID string, device string) error {
 		return nil
 	}
 
-	tokenKey := m.getTokenKey(tokenStr)
+	// Expired or already ended tokens need no tombstone, but the index still goes | 已过期或已结束的Token无需墓碑标记，但仍需删除索引
+	tokenKey := m.getStoredTokenKey(tokenStr)
+	if current, err := m.storage.Get(tokenKey); err != nil || current == nil {
+		return m.storage.Delete(accountKey, m.getActiveKey(tokenStr))
+	} else if value, ok := assertString(current); ok && isTombstone(value) {
+		return m.storage.Delete(accountKey, m.getActiveKey(tokenStr))
+	}
 
 	// Trigger kickout event | 触发踢出事件
 	if m.eventManager != nil {
-		m.eventManager.Trigger(&listener.EventData{
+		reason := "kickout"
+		if tombstone == TokenValueReplaced {
+			reason = "replaced"
+		}
+		m.TriggerEvent(&listener.EventData{
 			Event:   listener.EventKickout,
 			LoginID: loginID,
 			Token:   tokenStr,
 			Device:  device,
+			Extra: map[string]any{
+				"reason": reason,
+			},
 		})
 	}
 
-	return m.storage.Delete(tokenKey)
+	m.storage.Delete(accountKey, m.getActiveKey(tokenStr))
+	return m.storage.Set(tokenKey, tombstone, m.getRemainingTTL(tokenKey))
 }
 
 // Kickout Kick user offline (public method) | 踢人下线（公开方法）
+// The token keeps answering ErrTokenKickedOut until it would have expired | Token在原本过期之前持续返回ErrTokenKickedOut
 func (m *Manager) Kickout(loginID string, device ...string) error {
 	deviceType := getDevice(device)
-	return m.kickout(loginID, deviceType)
+	if err := m.endToken(loginID, deviceType, TokenValueKickedOut); err != nil {
+		return err
+	}
+
+	// Revoke refresh tokens of this device | 撤销该设备的刷新令牌
+	if m.config.CascadeRefreshToken {
+		return m.refreshManager.RevokeAllRefreshTokens(loginID, deviceType)
+	}
+
+	return nil
 }
 
 // ============ Token Validation | Token验证 ============
 
 // IsLogin Checks if user is logged in | 检查是否登录
 func (m *Manager) IsLogin(tokenValue string) bool {
+	_, err := m.checkToken(tokenValue)
+	return err == nil
+}
+
+// checkToken Resolves the login ID of a token, or why it is not logged in | 解析Token的登录ID，或其未登录的原因
+func (m *Manager) checkToken(tokenValue string) (string, error) {
 	if tokenValue == "" {
-		return false
+		return "", ErrNotToken
 	}
 
-	tokenKey := m.getTokenKey(tokenValue)
-	if !m.storage.Exists(tokenKey) {
-		return false
+	loginID, err := m.getLoginIDByToken(tokenValue)
+	if err != nil {
+		if m.isExpiredJWT(tokenValue) {
+			return "", ErrTokenExpired
+		}
+		return "", ErrTokenInvalid
+	}
+
+	switch loginID {
+	case TokenValueKickedOut:
+		return "", ErrTokenKickedOut
+	case TokenValueReplaced:
+		return "", ErrTokenReplaced
+	}
+
+	digest := m.generator.Digest(tokenValue)
+	if m.config.ActiveTimeout > 0 {
+		if !m.storage.Exists(m.getActiveKey(digest)) {
+			return "", ErrTokenFrozen
+		}
+		m.touchActive(digest)
 	}
 
 	// Async auto-renew for better performance | 异步自动续期（提高性能）
-	// Note: ActiveTimeout feature removed to comply with Java sa-token design
 	if m.config.AutoRenew && m.config.Timeout > 0 {
+		tokenKey := m.getStoredTokenKey(digest)
 		if m.renewPool != nil {
 			// Submit token renewal task to the pool | 提交续期任务到续期池
 			_ = m.renewPool.Submit(func() {
-				m.renewToken(tokenKey)
+				m.renewToken(tokenKey, loginID, tokenValue)
 			})
 		} else {
 			// Fallback to go routine if pool is not configured | 如果续期池未配置，使用普通协程
-			go m.renewToken(tokenKey)
+			go m.renewToken(tokenKey, loginID, tokenValue)
 		}
 	}
 
-	return true
+	return loginID, nil
+}
+
+// isExpiredJWT Checks if a JWT style token carries an expired exp claim | 检查JWT风格的Token是否已超过exp声明
+func (m *Manager) isExpiredJWT(tokenValue string) bool {
+	if m.config.TokenStyle != config.TokenStyleJWT {
+		return false
+	}
+	_, err := m.generator.ParseJWT(tokenValue)
+	return errors.Is(err, jwt.ErrTokenExpired)
+}
+
+// touchActive Records token activity, a token untouched for ActiveTimeout seconds is frozen | 记录Token活跃，超过ActiveTimeout秒未活跃的Token会被冻结
+func (m *Manager) touchActive(storedToken string) {
+	if m.config.ActiveTimeout > 0 {
+		m.storage.Set(m.getActiveKey(storedToken), time.Now().Unix(), time.Duration(m.config.ActiveTimeout)*time.Second)
+	}
 }
 
 // renewToken Renews token expiration asynchronously | 异步续期Token
-func (m *Manager) renewToken(tokenKey string) {
+func (m *Manager) renewToken(tokenKey, loginID, tokenValue string) {
 	expiration := m.getExpiration()
+	// Skip when the TTL would barely move, busy tokens renew at most once per step | TTL几乎不变时跳过，频繁请求的Token每个步长最多续期一次
+	if ttl, err := m.storage.TTL(tokenKey); err == nil && ttl > 0 && expiration-ttl < minRenewStep(expiration) {
+		return
+	}
+
 	// Extend token storage expiration | 延长Token存储的过期时间
-	m.storage.Expire(tokenKey, expiration)
+	if err := m.storage.Expire(tokenKey, expiration); err != nil {
+		return
+	}
+
+	// Trigger renew event | 触发续期事件
+	m.TriggerEvent(&listener.EventData{
+		Event:   listener.EventRenew,
+		LoginID: loginID,
+		Token:   tokenValue,
+		Extra: map[string]any{
+			"source":   "autoRenew",
+			"duration": m.config.Timeout,
+		},
+	})
+}
+
+// minRenewStep Smallest extension worth a renewal, a tenth of the timeout capped at one minute | 值得续期的最小延长量，为超时时间的十分之一，最多一分钟
+func minRenewStep(expiration time.Duration) time.Duration {
+	if step := expiration / 10; step < time.Minute {
+		return step
+	}
+	return time.Minute
 }
 
 // CheckLogin Checks login status (throws error if not logged in) | 检查登录（未登录抛出错误）
+// The error tells missing, invalid, expired, kicked-out, replaced and frozen tokens apart, and wraps ErrNotLogin |
+// 错误区分缺失、无效、过期、被踢下线、被顶下线和被冻结的Token，并包装ErrNotLogin
+// An expired opaque token leaves no key behind and reads as ErrTokenInvalid, only JWTs report ErrTokenExpired |
+// 过期的非JWT Token不会留下任何键，返回ErrTokenInvalid，只有JWT会返回ErrTokenExpired
 func (m *Manager) CheckLogin(tokenValue string) error {
-	if !m.IsLogin(tokenValue) {
-		return ErrNotLogin
-	}
-	return nil
+	_, err := m.checkToken(tokenValue)
+	return err
 }
 
 // GetLoginID Gets login ID from token | 根据Token获取登录ID
 func (m *Manager) GetLoginID(tokenValue string) (string, error) {
-	if !m.IsLogin(tokenValue) {
-		return "", ErrNotLogin
-	}
-
-	info, err := m.getTokenInfo(tokenValue)
-	if err != nil {
-		return "", err
-	}
-
-	return info.LoginID, nil
+	return m.checkToken(tokenValue)
 }
 
 // GetLoginIDNotCheck Gets login ID without checking token validity | 获取登录ID（不检查Token是否有效）
@@ -374,8 +558,26 @@ func (m *Manager) GetLoginIDNotCheck(tokenValue string) (string, error) {
 }
 
 // GetTokenValue Gets token by login ID | 根据登录ID获取Token
+// Returns token.ErrRawTokenUnavailable when TokenHashAtRest is enabled, use GetTokenDigest instead | 启用TokenHashAtRest时返回token.ErrRawTokenUnavailable，请改用GetTokenDigest
 func (m *Manager) GetTokenValue(loginID string, device ...string) (string, error) {
-	deviceType := getDevice(device)
+	if m.generator.HashAtRest() {
+		return "", token.ErrRawTokenUnavailable
+	}
+	return m.getStoredTokenValue(loginID, getDevice(device))
+}
+
+// GetTokenDigest Gets the at-rest form of a login ID's token | 获取登录ID对应Token的存储形式
+// Returns the digest when TokenHashAtRest is enabled, otherwise the raw token | 启用TokenHashAtRest时返回摘要，否则返回原始Token
+func (m *Manager) GetTokenDigest(loginID string, device ...string) (string, error) {
+	stored, err := m.getStoredTokenValue(loginID, getDevice(device))
+	if err != nil {
+		return "", err
+	}
+	return m.storedDigest(stored), nil
+}
+
+// getStoredTokenValue Reads the account index entry of a login ID and device | 读取登录ID和设备的账号索引项
+func (m *Manager) getStoredTokenValue(loginID, deviceType string) (string, error) {
 	accountKey := m.getAccountKey(loginID, deviceType)
 
 	tokenValue, err := m.storage.Get(accountKey)
@@ -396,48 +598,14 @@ func (m *Manager) GetTokenInfo(tokenValue string) (*TokenInfo, error) {
 	return m.getTokenInfo(tokenValue)
 }
 
-// ============ Account Disable | 账号封禁 ============
-
-// Disable Disables an account | 封禁账号
-func (m *Manager) Disable(loginID string, duration time.Duration) error {
-	key := m.getDisableKey(loginID)
-	return m.storage.Set(key, DisableValue, duration)
-}
-
-// Untie Re-enables a disabled account | 解封账号
-func (m *Manager) Untie(loginID string) error {
-	key := m.getDisableKey(loginID)
-	return m.storage.Delete(key)
-}
-
-// IsDisable Checks if account is disabled | 检查账号是否被封禁
-func (m *Manager) IsDisable(loginID string) bool {
-	key := m.getDisableKey(loginID)
-	return m.storage.Exists(key)
-}
-
-// GetDisableTime Gets remaining disable time in seconds | 获取账号剩余封禁时间（秒）
-func (m *Manager) GetDisableTime(loginID string) (int64, error) {
-	key := m.getDisableKey(loginID)
-	ttl, err := m.storage.TTL(key)
-	if err != nil {
-		return -2, err
-	}
-	return int64(ttl.Seconds()), nil
-}
-
-// getDisableKey Gets disable storage key | 获取禁用存储键
-func (m *Manager) getDisableKey(loginID string) string {
-	return m.prefix + DisableKeyPrefix + loginID
-}
-
 // ============ Session Management | Session管理 ============
 
-// GetSession Gets session by login ID | 获取Session
+// GetSession Gets session by login ID, creating it when missing | 获取Session，不存在时创建
 func (m *Manager) GetSession(loginID string) (*session.Session, error) {
 	sess, err := session.Load(loginID, m.storage, m.prefix)
 	if err != nil {
 		sess = session.NewSession(loginID, m.storage, m.prefix)
+		m.triggerSessionEvent(listener.EventCreateSession, loginID)
 	}
 	return sess, nil
 }
@@ -453,166 +621,180 @@ func (m *Manager) GetSessionByToken(tokenValue string) (*session.Session, error)
 
 // DeleteSession Deletes session | 删除Session
 func (m *Manager) DeleteSession(loginID string) error {
-	sess, err := m.GetSession(loginID)
+	sess, err := session.Load(loginID, m.storage, m.prefix)
 	if err != nil {
+		return nil
+	}
+	if err := sess.Destroy(); err != nil {
 		return err
 	}
-	return sess.Destroy()
+	m.triggerSessionEvent(listener.EventDestroySession, loginID)
+	return nil
+}
+
+// triggerSessionEvent Triggers a session lifecycle event | 触发Session生命周期事件
+func (m *Manager) triggerSessionEvent(event listener.Event, loginID string) {
+	m.TriggerEvent(&listener.EventData{
+		Event:   event,
+		LoginID: loginID,
+	})
 }
 
 // ============ Permission Validation | 权限验证 ============
 
+// SetPermissionProvider Sets where permissions and roles are loaded from, nil restores the session provider |
+// 设置权限和角色的加载来源，传nil恢复为Session提供者
+func (m *Manager) SetPermissionProvider(provider PermissionProvider) {
+	if provider == nil {
+		provider = NewSessionPermissionProvider(m.storage, m.prefix)
+	}
+	m.permProvider = provider
+	m.InvalidateAll()
+}
+
+// GetPermissionProvider Gets the permission provider | 获取权限提供者
+func (m *Manager) GetPermissionProvider() PermissionProvider {
+	return m.permProvider
+}
+
+// SetLoginType Sets the login type passed to the permission provider | 设置传给权限提供者的登录类型
+// Deprecated: set config LoginType before NewManager instead, this does not move the key namespace fixed at creation | 已废弃：请改为在NewManager之前设置配置项LoginType，此方法不会改变创建时确定的键命名空间
+func (m *Manager) SetLoginType(loginType string) {
+	m.loginType = loginType
+}
+
+// GetLoginType Gets the account type (config LoginType) | 获取账号类型（配置项LoginType）
+func (m *Manager) GetLoginType() string {
+	return m.loginType
+}
+
 // SetPermissions Sets permissions for user | 设置权限
+// Stored in the account session, read back only by the session provider | 保存在账号Session中，仅Session提供者会读取
 func (m *Manager) SetPermissions(loginID string, permissions []string) error {
 	sess, err := m.GetSession(loginID)
 	if err != nil {
 		return err
 	}
-	return sess.Set(SessionKeyPermissions, permissions)
+	if err := sess.Set(SessionKeyPermissions, permissions); err != nil {
+		return err
+	}
+	return m.InvalidatePermissions(loginID)
 }
 
-// GetPermissions Gets permission list | 获取权限列表
+// GetPermissions Gets permission list from the permission provider, plus those granted by roles | 从权限提供者获取权限列表，并加入角色授予的权限
 func (m *Manager) GetPermissions(loginID string) ([]string, error) {
-	sess, err := m.GetSession(loginID)
+	return m.cachedList(loginID, cacheKindPermissions, m.loadPermissions)
+}
+
+// GetPermissionMatcher Compiles an account's permissions into a matcher | 将账号的权限编译为匹配器
+// Patterns starting with "!" deny and override every grant | 以"!"开头的模式表示拒绝，优先于所有授权
+func (m *Manager) GetPermissionMatcher(loginID string) (*permission.Matcher, error) {
+	perms, err := m.GetPermissions(loginID)
 	if err != nil {
 		return nil, err
 	}
-
-	perms, exists := sess.Get(SessionKeyPermissions)
-	if !exists {
-		return []string{}, nil
-	}
-
-	return m.toStringSlice(perms), nil
+	return m.newMatcher(perms), nil
 }
 
 // HasPermission 检查是否有指定权限
 func (m *Manager) HasPermission(loginID string, permission string) bool {
-	perms, err := m.GetPermissions(loginID)
-	if err != nil {
-		return false
-	}
-
-	for _, p := range perms {
-		if m.matchPermission(p, permission) {
-			return true
-		}
-	}
-
-	return false
+	matcher, err := m.GetPermissionMatcher(loginID)
+	result := err == nil && matcher.Match(permission)
+	m.triggerCheckEvent(listener.EventPermissionCheck, loginID, map[string]any{"permission": permission}, result, err)
+	return result
 }
 
 // HasPermissionsAnd 检查是否拥有所有权限（AND）
 func (m *Manager) HasPermissionsAnd(loginID string, permissions []string) bool {
-	for _, perm := range permissions {
-		if !m.HasPermission(loginID, perm) {
-			return false
-		}
-	}
-	return true
+	matcher, err := m.GetPermissionMatcher(loginID)
+	result := err == nil && matcher.MatchAll(permissions)
+	m.triggerCheckEvent(listener.EventPermissionCheck, loginID, map[string]any{"permissions": permissions, "mode": "and"}, result, err)
+	return result
 }
 
 // HasPermissionsOr 检查是否拥有任一权限（OR）
 func (m *Manager) HasPermissionsOr(loginID string, permissions []string) bool {
-	for _, perm := range permissions {
-		if m.HasPermission(loginID, perm) {
-			return true
-		}
-	}
-	return false
+	matcher, err := m.GetPermissionMatcher(loginID)
+	result := err == nil && matcher.MatchAny(permissions)
+	m.triggerCheckEvent(listener.EventPermissionCheck, loginID, map[string]any{"permissions": permissions, "mode": "or"}, result, err)
+	return result
 }
 
-// matchPermission Matches permission with wildcards support | 权限匹配（支持通配符）
-func (m *Manager) matchPermission(pattern, permission string) bool {
-	// Exact match or wildcard | 精确匹配或通配符
-	if pattern == PermissionWildcard || pattern == permission {
-		return true
-	}
-
-	// Pattern like "user:*" matches "user:add", "user:delete", etc. | 支持通配符，例如 user:* 匹配 user:add, user:delete等
-	wildcardSuffix := PermissionSeparator + PermissionWildcard
-	if strings.HasSuffix(pattern, wildcardSuffix) {
-		prefix := strings.TrimSuffix(pattern, PermissionWildcard)
-		return strings.HasPrefix(permission, prefix)
-	}
-
-	// Pattern like "user:*:view" | 支持 user:*:view 这样的模式
-	if strings.Contains(pattern, PermissionWildcard) {
-		parts := strings.Split(pattern, PermissionSeparator)
-		permParts := strings.Split(permission, PermissionSeparator)
-		if len(parts) != len(permParts) {
-			return false
-		}
-		for i, part := range parts {
-			if part != PermissionWildcard && part != permParts[i] {
-				return false
-			}
-		}
-		return true
-	}
-
-	return false
+// newMatcher Compiles permission patterns with the configured options | 按配置的选项编译权限模式
+func (m *Manager) newMatcher(patterns []string) *permission.Matcher {
+	return permission.NewMatcher(patterns, permission.Options{
+		CaseInsensitive: m.config.PermissionIgnoreCase,
+	})
 }
 
 // ============ Role Validation | 角色验证 ============
 
 // SetRoles Sets roles for user | 设置角色
+// Stored in the account session, read back only by the session provider | 保存在账号Session中，仅Session提供者会读取
 func (m *Manager) SetRoles(loginID string, roles []string) error {
 	sess, err := m.GetSession(loginID)
 	if err != nil {
 		return err
 	}
-	return sess.Set(SessionKeyRoles, roles)
+	if err := sess.Set(SessionKeyRoles, roles); err != nil {
+		return err
+	}
+	return m.InvalidatePermissions(loginID)
 }
 
-// GetRoles Gets role list | 获取角色列表
+// GetRoles Gets role list from the permission provider, expanded with inherited roles | 从权限提供者获取角色列表，并展开继承的角色
 func (m *Manager) GetRoles(loginID string) ([]string, error) {
-	sess, err := m.GetSession(loginID)
-	if err != nil {
-		return nil, err
-	}
-
-	roles, exists := sess.Get(SessionKeyRoles)
-	if !exists {
-		return []string{}, nil
-	}
-
-	return m.toStringSlice(roles), nil
+	return m.cachedList(loginID, cacheKindRoles, m.loadRoles)
 }
 
 // HasRole 检查是否有指定角色
 func (m *Manager) HasRole(loginID string, role string) bool {
-	roles, err := m.GetRoles(loginID)
-	if err != nil {
-		return false
-	}
-
-	for _, r := range roles {
-		if r == role {
-			return true
-		}
-	}
-	return false
+	owned, err := m.GetRoles(loginID)
+	result := err == nil && utils.ContainsString(owned, role)
+	m.triggerCheckEvent(listener.EventRoleCheck, loginID, map[string]any{"role": role}, result, err)
+	return result
 }
 
 // HasRolesAnd 检查是否拥有所有角色（AND）
 func (m *Manager) HasRolesAnd(loginID string, roles []string) bool {
+	owned, err := m.GetRoles(loginID)
+	result := true
 	for _, role := range roles {
-		if !m.HasRole(loginID, role) {
-			return false
+		if err != nil || !utils.ContainsString(owned, role) {
+			result = false
+			break
 		}
 	}
-	return true
+	m.triggerCheckEvent(listener.EventRoleCheck, loginID, map[string]any{"roles": roles, "mode": "and"}, result, err)
+	return result
 }
 
 // HasRolesOr 检查是否拥有任一角色（OR）
 func (m *Manager) HasRolesOr(loginID string, roles []string) bool {
+	owned, err := m.GetRoles(loginID)
+	result := false
 	for _, role := range roles {
-		if m.HasRole(loginID, role) {
-			return true
+		if err == nil && utils.ContainsString(owned, role) {
+			result = true
+			break
 		}
 	}
-	return false
+	m.triggerCheckEvent(listener.EventRoleCheck, loginID, map[string]any{"roles": roles, "mode": "or"}, result, err)
+	return result
+}
+
+// triggerCheckEvent Triggers a permission or role check event carrying what was required and the result |
+// 触发权限或角色检查事件，携带所需的权限或角色及检查结果
+func (m *Manager) triggerCheckEvent(event listener.Event, loginID string, extra map[string]any, result bool, err error) {
+	extra["result"] = result
+	if err != nil {
+		extra["error"] = err.Error()
+	}
+	m.TriggerEvent(&listener.EventData{
+		Event:   event,
+		LoginID: loginID,
+		Extra:   extra,
+	})
 }
 
 // ============ Token Tags | Token标签 ============
@@ -633,7 +815,31 @@ func (m *Manager) GetTokenTag(tokenValue string) (string, error) {
 // ============ Session Query | 会话查询 ============
 
 // GetTokenValueListByLoginID Gets all tokens for specified account | 获取指定账号的所有Token
+// Returns token.ErrRawTokenUnavailable when TokenHashAtRest is enabled, use GetTokenDigestListByLoginID instead | 启用TokenHashAtRest时返回token.ErrRawTokenUnavailable，请改用GetTokenDigestListByLoginID
 func (m *Manager) GetTokenValueListByLoginID(loginID string) ([]string, error) {
+	if m.generator.HashAtRest() {
+		return nil, token.ErrRawTokenUnavailable
+	}
+	return m.getStoredTokenValueList(loginID)
+}
+
+// GetTokenDigestListByLoginID Gets the at-rest form of all tokens for specified account | 获取指定账号所有Token的存储形式
+// Returns digests when TokenHashAtRest is enabled, otherwise raw tokens | 启用TokenHashAtRest时返回摘要，否则返回原始Token
+func (m *Manager) GetTokenDigestListByLoginID(loginID string) ([]string, error) {
+	stored, err := m.getStoredTokenValueList(loginID)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]string, 0, len(stored))
+	for _, value := range stored {
+		digests = append(digests, m.storedDigest(value))
+	}
+	return digests, nil
+}
+
+// getStoredTokenValueList Reads all account index entries of a login ID | 读取登录ID的所有账号索引项
+func (m *Manager) getStoredTokenValueList(loginID string) ([]string, error) {
 	pattern := m.prefix + AccountKeyPrefix + loginID + ":*"
 	keys, err := m.storage.Keys(pattern)
 	if err != nil {
@@ -655,18 +861,119 @@ func (m *Manager) GetTokenValueListByLoginID(loginID string) ([]string, error) {
 
 // GetSessionCountByLoginID Gets session count for specified account | 获取指定账号的Session数量
 func (m *Manager) GetSessionCountByLoginID(loginID string) (int, error) {
-	tokens, err := m.GetTokenValueListByLoginID(loginID)
+	tokens, err := m.getStoredTokenValueList(loginID)
 	if err != nil {
 		return 0, err
 	}
 	return len(tokens), nil
 }
 
+// ============ Token Digest Migration | Token摘要迁移 ============
+
+// MigrateTokenDigests Rewrites tokens issued before TokenHashAtRest was enabled into digest form | 将启用TokenHashAtRest之前签发的Token迁移为摘要形式
+// Tokens missed here are still migrated lazily on their next validation | 未被迁移的Token仍会在下次校验时惰性迁移
+func (m *Manager) MigrateTokenDigests() (int, error) {
+	if !m.config.TokenHashAtRest {
+		return 0, nil
+	}
+
+	keys, err := m.storage.Keys(m.prefix + AccountKeyPrefix + "*")
+	if err != nil {
+		return 0, err
+	}
+
+	migrated := 0
+	for _, key := range keys {
+		value, err := m.storage.Get(key)
+		if err != nil || value == nil {
+			continue
+		}
+		tokenStr, ok := assertString(value)
+		if !ok || token.IsDigest(tokenStr) {
+			continue
+		}
+		if m.migrateLegacyToken(tokenStr) {
+			migrated++
+		}
+	}
+
+	return migrated, nil
+}
+
+// migrateLegacyToken Moves a token stored in raw form to its digest key | 将以原始形式存储的Token迁移到摘要键
+func (m *Manager) migrateLegacyToken(tokenValue string) bool {
+	// A digest presented as a token must never be treated as a raw token | 不能把作为Token提交的摘要当作原始Token
+	if !m.config.TokenHashAtRest || tokenValue == "" || token.IsDigest(tokenValue) {
+		return false
+	}
+
+	legacyKey := m.getStoredTokenKey(tokenValue)
+	data, err := m.storage.Get(legacyKey)
+	if err != nil || data == nil {
+		return false
+	}
+	loginID, ok := assertString(data)
+	if !ok {
+		return false
+	}
+
+	digest := m.generator.Digest(tokenValue)
+	if err := m.storage.Set(m.getStoredTokenKey(digest), loginID, m.getRemainingTTL(legacyKey)); err != nil {
+		return false
+	}
+	m.storage.Delete(legacyKey)
+
+	// Rewrite account index entries that still hold the raw token | 重写仍保存原始Token的账号索引
+	accountKeys, err := m.storage.Keys(m.prefix + AccountKeyPrefix + loginID + PermissionSeparator + "*")
+	if err != nil {
+		return true
+	}
+	for _, accountKey := range accountKeys {
+		value, err := m.storage.Get(accountKey)
+		if err != nil || value == nil {
+			continue
+		}
+		if tokenStr, ok := assertString(value); ok && tokenStr == tokenValue {
+			m.storage.Set(accountKey, digest, m.getRemainingTTL(accountKey))
+		}
+	}
+
+	return true
+}
+
 // ============ Internal Helper Methods | 内部辅助方法 ============
 
 // getTokenKey Gets token storage key | 获取Token存储键
 func (m *Manager) getTokenKey(tokenValue string) string {
-	return m.prefix + TokenKeyPrefix + tokenValue
+	return m.getStoredTokenKey(m.generator.Digest(tokenValue))
+}
+
+// getStoredTokenKey Gets token storage key from the value kept in the account index | 根据账号索引中保存的值获取Token存储键
+func (m *Manager) getStoredTokenKey(storedValue string) string {
+	return m.prefix + TokenKeyPrefix + storedValue
+}
+
+// storedDigest Returns the digest of an account index entry, hashing entries not yet migrated | 返回账号索引项的摘要，对尚未迁移的项进行哈希
+func (m *Manager) storedDigest(storedValue string) string {
+	if token.IsDigest(storedValue) {
+		return storedValue
+	}
+	return m.generator.Digest(storedValue)
+}
+
+// getActiveKey Gets last-active storage key from the stored token value | 根据Token存储值获取最后活跃时间的存储键
+func (m *Manager) getActiveKey(storedValue string) string {
+	return m.prefix + ActiveKeyPrefix + storedValue
+}
+
+// getSessionKey Gets session storage key | 获取Session存储键
+func (m *Manager) getSessionKey(loginID string) string {
+	return m.prefix + session.SessionKeyPrefix + loginID
+}
+
+// isTombstone Checks if a token key value is a kicked-out or replaced marker | 检查Token键的值是否为被踢或被顶的标记
+func isTombstone(value string) bool {
+	return value == TokenValueKickedOut || value == TokenValueReplaced
 }
 
 // getAccountKey Gets account storage key | 获取账号存储键
@@ -678,6 +985,9 @@ func (m *Manager) getAccountKey(loginID, device string) string {
 func (m *Manager) getLoginIDByToken(tokenValue string) (string, error) {
 	tokenKey := m.getTokenKey(tokenValue)
 	data, err := m.storage.Get(tokenKey)
+	if (err != nil || data == nil) && m.migrateLegacyToken(tokenValue) {
+		data, err = m.storage.Get(tokenKey)
+	}
 	if err != nil || data == nil {
 		return "", ErrTokenNotFound
 	}
@@ -696,6 +1006,9 @@ func (m *Manager) getTokenInfo(tokenValue string) (*TokenInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	if isTombstone(loginID) {
+		return nil, ErrTokenNotFound
+	}
 
 	// 构造简化的 TokenInfo，只包含必要信息
 	return &TokenInfo{
@@ -704,8 +1017,20 @@ func (m *Manager) getTokenInfo(tokenValue string) (*TokenInfo, error) {
 	}, nil
 }
 
+// getRemainingTTL Gets remaining TTL for re-saving a key (0 means never expire) | 获取重新保存键时使用的剩余有效期（0表示永不过期）
+func (m *Manager) getRemainingTTL(key string) time.Duration {
+	ttl, err := m.storage.TTL(key)
+	if err != nil || ttl < 0 {
+		return 0
+	}
+	if ttl < time.Second {
+		return time.Second
+	}
+	return ttl
+}
+
 // toStringSlice Converts any to []string | 将any转换为[]string
-func (m *Manager) toStringSlice(v any) []string {
+func toStringSlice(v any) []string {
 	switch val := v.(type) {
 	case []string:
 		return val
@@ -756,8 +1081,15 @@ func (m *Manager) Unregister(id string) bool {
 }
 
 // TriggerEvent manually triggers an event | 手动触发事件
+// Events carry the manager's login type and tenant ID | 事件携带管理器的登录类型和租户ID
 func (m *Manager) TriggerEvent(data *listener.EventData) {
 	if m.eventManager != nil {
+		if data.LoginType == "" {
+			data.LoginType = m.loginType
+		}
+		if data.TenantID == "" {
+			data.TenantID = m.tenantID
+		}
 		m.eventManager.Trigger(data)
 	}
 }
@@ -799,20 +1131,31 @@ func (m *Manager) VerifyNonce(nonce string) bool {
 }
 
 // LoginWithRefreshToken Logs in with refresh token | 使用刷新令牌登录
-func (m *Manager) LoginWithRefreshToken(loginID, device string) (*security.RefreshTokenInfo, error) {
+// fingerprint: client fingerprint, required when RefreshBindFingerprint is enabled | 客户端指纹，启用RefreshBindFingerprint时必须提供
+func (m *Manager) LoginWithRefreshToken(loginID, device string, fingerprint ...string) (*security.RefreshTokenInfo, error) {
 	deviceType := getDevice([]string{device})
 
+	var clientPrint string
+	if len(fingerprint) > 0 {
+		clientPrint = fingerprint[0]
+	}
+	// Fail before logging in so no unbound session is left behind | 在登录前失败，避免留下未绑定的会话
+	if m.config.RefreshBindFingerprint && clientPrint == "" {
+		return nil, security.ErrFingerprintRequired
+	}
+
 	accessToken, err := m.Login(loginID, deviceType)
 	if err != nil {
 		return nil, err
 	}
 
-	return m.refreshManager.GenerateTokenPair(loginID, deviceType, accessToken)
+	return m.refreshManager.GenerateBoundTokenPair(loginID, deviceType, clientPrint, accessToken)
 }
 
-// RefreshAccessToken Refreshes access token | 刷新访问令牌
-func (m *Manager) RefreshAccessToken(refreshToken string) (*security.RefreshTokenInfo, error) {
-	return m.refreshManager.RefreshAccessToken(refreshToken)
+// RefreshAccessToken Refreshes access token and rotates refresh token | 刷新访问令牌并轮换刷新令牌
+// The previous access token is revoked and the account index points at the new one | 旧的访问令牌被撤销，账号索引指向新的访问令牌
+func (m *Manager) RefreshAccessToken(refreshToken string, fingerprint ...string) (*security.RefreshTokenInfo, error) {
+	return m.refreshManager.RefreshAccessToken(refreshToken, fingerprint...)
 }
 
 // RevokeRefreshToken Revokes refresh token | 撤销刷新令牌
@@ -820,7 +1163,106 @@ func (m *Manager) RevokeRefreshToken(refreshToken string) error {
 	return m.refreshManager.RevokeRefreshToken(refreshToken)
 }
 
+// ListRefreshTokens Lists active refresh tokens of a user, one per token family | 列出用户有效的刷新令牌，每个令牌家族一个
+// Returns token.ErrRawTokenUnavailable when TokenHashAtRest is enabled, use ListRefreshTokenDigests instead | 启用TokenHashAtRest时返回token.ErrRawTokenUnavailable，请改用ListRefreshTokenDigests
+func (m *Manager) ListRefreshTokens(loginID string) ([]*security.RefreshTokenInfo, error) {
+	return m.refreshManager.ListRefreshTokens(loginID)
+}
+
+// ListRefreshTokenDigests Lists active refresh tokens of a user with tokens in their at-rest form | 列出用户有效的刷新令牌，令牌为存储形式
+func (m *Manager) ListRefreshTokenDigests(loginID string) ([]*security.RefreshTokenInfo, error) {
+	return m.refreshManager.ListRefreshTokenDigests(loginID)
+}
+
+// RevokeAllRefreshTokens Revokes all refresh tokens of a user, optionally limited to one device | 撤销用户的所有刷新令牌，可限定设备
+func (m *Manager) RevokeAllRefreshTokens(loginID string, device ...string) error {
+	return m.refreshManager.RevokeAllRefreshTokens(loginID, device...)
+}
+
 // GetOAuth2Server Gets OAuth2 server instance | 获取OAuth2服务器实例
 func (m *Manager) GetOAuth2Server() *oauth2.OAuth2Server {
 	return m.oauth2Server
 }
+
+// ============ OAuth2 Access Tokens | OAuth2访问令牌 ============
+
+// ScopePermissionMapper Maps the scopes of an OAuth2 access token to permissions | 将OAuth2访问令牌的权限范围映射为权限
+type ScopePermissionMapper func(scopes []string) []string
+
+// SetScopePermissionMapper Sets how OAuth2 scopes map to permissions, nil means scopes are permissions | 设置OAuth2权限范围到权限的映射，nil表示权限范围即权限
+func (m *Manager) SetScopePermissionMapper(mapper ScopePermissionMapper) {
+	m.scopeMapper = mapper
+}
+
+// GetOAuth2AccessToken Validates an OAuth2 access token issued on behalf of a user | 校验代表用户签发的OAuth2访问令牌
+// Client credentials tokens have no user and are rejected with ErrNotLogin | 客户端凭证令牌没有用户，返回ErrNotLogin
+func (m *Manager) GetOAuth2AccessToken(accessToken string) (*oauth2.AccessToken, error) {
+	token, err := m.oauth2Server.ValidateAccessToken(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if token.UserID == "" {
+		return nil, ErrNotLogin
+	}
+	return token, nil
+}
+
+// GetOAuth2Permissions Gets the permissions granted by an OAuth2 access token | 获取OAuth2访问令牌授予的权限
+func (m *Manager) GetOAuth2Permissions(token *oauth2.AccessToken) []string {
+	if m.scopeMapper == nil {
+		return token.Scopes
+	}
+	return m.scopeMapper(token.Scopes)
+}
+
+// HasOAuth2Permission Checks if an OAuth2 access token grants a permission | 检查OAuth2访问令牌是否授予指定权限
+func (m *Manager) HasOAuth2Permission(token *oauth2.AccessToken, permission string) bool {
+	return m.newMatcher(m.GetOAuth2Permissions(token)).Match(permission)
+}
+
+// refreshIssuer Issues refreshed access tokens through the login path | 通过登录流程签发刷新后的访问令牌
+type refreshIssuer struct {
+	m *Manager
+}
+
+// IssueAccessToken implements security.AccessTokenIssuer | 实现 security.AccessTokenIssuer
+func (r *refreshIssuer) IssueAccessToken(loginID, device string) (string, error) {
+	tokenValue, err := r.m.createToken(loginID, device)
+	if err != nil {
+		return "", err
+	}
+
+	// Trigger renew event | 触发续期事件
+	r.m.TriggerEvent(&listener.EventData{
+		Event:   listener.EventRenew,
+		LoginID: loginID,
+		Device:  device,
+		Token:   tokenValue,
+		Extra: map[string]any{
+			"source":   "refresh",
+			"duration": r.m.config.Timeout,
+		},
+	})
+
+	return tokenValue, nil
+}
+
+// RevokeAccessToken implements security.AccessTokenIssuer | 实现 security.AccessTokenIssuer
+func (r *refreshIssuer) RevokeAccessToken(loginID, device, storedToken string) {
+	// Keep kickout and replace tombstones so the client still learns why | 保留踢下线和顶下线的墓碑标记，客户端仍可得知下线原因
+	tokenKey := r.m.getStoredTokenKey(storedToken)
+	if value, err := r.m.storage.Get(tokenKey); err == nil {
+		if tokenStr, ok := assertString(value); !ok || !isTombstone(tokenStr) {
+			r.m.storage.Delete(tokenKey)
+		}
+	}
+	r.m.storage.Delete(r.m.getActiveKey(storedToken))
+
+	// Drop the account index entry only if it still points at this token | 仅当账号索引仍指向该Token时才删除
+	accountKey := r.m.getAccountKey(loginID, device)
+	if value, err := r.m.storage.Get(accountKey); err == nil {
+		if tokenStr, ok := assertString(value); ok && tokenStr == storedToken {
+			r.m.storage.Delete(accountKey)
+		}
+	}
+}
@@ -0,0 +1,1429 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/config"
+	"github.com/click33/sa-token-go/core/listener"
+	"github.com/click33/sa-token-go/core/security"
+	"github.com/click33/sa-token-go/core/token"
+)
+
+// testStorage minimal in-memory storage for manager tests | 用于Manager测试的最小内存存储
+type testStorage struct {
+	mu      sync.RWMutex
+	data    map[string]any
+	expires map[string]time.Time
+	delay   time.Duration // Widens read-then-write windows in race tests | 在竞态测试中放大先读后写的时间窗口
+}
+
+func newTestStorage() *testStorage {
+	return &testStorage{
+		data:    make(map[string]any),
+		expires: make(map[string]time.Time),
+	}
+}
+
+func (s *testStorage) expired(key string) bool {
+	exp, ok := s.expires[key]
+	return ok && time.Now().After(exp)
+}
+
+func (s *testStorage) Set(key string, value any, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	delete(s.expires, key)
+	if expiration > 0 {
+		s.expires[key] = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+func (s *testStorage) SetNX(key string, value any, expiration time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; ok && !s.expired(key) {
+		return false, nil
+	}
+	s.data[key] = value
+	delete(s.expires, key)
+	if expiration > 0 {
+		s.expires[key] = time.Now().Add(expiration)
+	}
+	return true, nil
+}
+
+func (s *testStorage) Get(key string) (any, error) {
+	time.Sleep(s.delay)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[key]
+	if !ok || s.expired(key) {
+		return nil, fmt.Errorf("key not found: %s", key)
+	}
+	return v, nil
+}
+
+func (s *testStorage) Delete(keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		delete(s.data, key)
+		delete(s.expires, key)
+	}
+	return nil
+}
+
+func (s *testStorage) Exists(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.data[key]
+	return ok && !s.expired(key)
+}
+
+func (s *testStorage) Keys(pattern string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// Redis-style glob: * and \-escapes | Redis风格通配：* 与 \ 转义
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		case c == '*':
+			expr.WriteString(".*")
+		default:
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	re := regexp.MustCompile("^" + expr.String() + "$")
+	keys := make([]string, 0)
+	for key := range s.data {
+		if re.MatchString(key) && !s.expired(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *testStorage) Expire(key string, expiration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data[key]; !ok {
+		return fmt.Errorf("key not found: %s", key)
+	}
+	delete(s.expires, key)
+	if expiration > 0 {
+		s.expires[key] = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+func (s *testStorage) TTL(key string) (time.Duration, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.data[key]; !ok || s.expired(key) {
+		return -2 * time.Second, fmt.Errorf("key not found: %s", key)
+	}
+	exp, ok := s.expires[key]
+	if !ok {
+		return -1 * time.Second, nil
+	}
+	return time.Until(exp), nil
+}
+
+func (s *testStorage) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = make(map[string]any)
+	s.expires = make(map[string]time.Time)
+	return nil
+}
+
+func (s *testStorage) Ping() error { return nil }
+
+func newTestManager(storage adapter.Storage, modify func(cfg *config.Config)) *Manager {
+	cfg := config.DefaultConfig()
+	cfg.AutoRenew = false
+	cfg.IsPrintBanner = false
+	if modify != nil {
+		modify(cfg)
+	}
+	return NewManager(storage, cfg)
+}
+
+func TestTokenHashAtRest(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.TokenHashAtRest = true
+	})
+
+	tokenValue, err := mgr.Login("1000", "web")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	for key, value := range storage.data {
+		if strings.Contains(key, tokenValue) || value == tokenValue {
+			t.Errorf("raw token found at rest: %s=%v", key, value)
+		}
+	}
+
+	if loginID, err := mgr.GetLoginID(tokenValue); err != nil || loginID != "1000" {
+		t.Fatalf("GetLoginID = %q, %v", loginID, err)
+	}
+
+	// Raw token APIs must not hand out digests | 原始Token接口不能返回摘要
+	if _, err := mgr.GetTokenValue("1000", "web"); !errors.Is(err, token.ErrRawTokenUnavailable) {
+		t.Errorf("GetTokenValue should fail with hashing enabled, got %v", err)
+	}
+	if _, err := mgr.GetTokenValueListByLoginID("1000"); !errors.Is(err, token.ErrRawTokenUnavailable) {
+		t.Errorf("GetTokenValueListByLoginID should fail with hashing enabled, got %v", err)
+	}
+	if count, err := mgr.GetSessionCountByLoginID("1000"); err != nil || count != 1 {
+		t.Errorf("GetSessionCountByLoginID = %d, %v", count, err)
+	}
+
+	// The stored digest itself must not authenticate | 存储的摘要本身不能用于认证
+	stored, err := mgr.GetTokenDigest("1000", "web")
+	if err != nil || stored != mgr.generator.Digest(tokenValue) {
+		t.Fatalf("GetTokenDigest = %s, %v", stored, err)
+	}
+	if digests, err := mgr.GetTokenDigestListByLoginID("1000"); err != nil || len(digests) != 1 || digests[0] != stored {
+		t.Errorf("GetTokenDigestListByLoginID = %v, %v", digests, err)
+	}
+	if mgr.IsLogin(stored) {
+		t.Error("digest must not be accepted as a token")
+	}
+
+	pair, err := mgr.LoginWithRefreshToken("2000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+	if _, err := mgr.ListRefreshTokens("2000"); !errors.Is(err, token.ErrRawTokenUnavailable) {
+		t.Errorf("ListRefreshTokens should fail with hashing enabled, got %v", err)
+	}
+	infos, err := mgr.ListRefreshTokenDigests("2000")
+	if err != nil || len(infos) != 1 || infos[0].RefreshToken != mgr.generator.Digest(pair.RefreshToken) {
+		t.Errorf("ListRefreshTokenDigests = %v, %v", infos, err)
+	}
+
+	// The retired record keeps the access token digest, not a digest of the digest | 已轮换的记录保留访问令牌摘要，而不是摘要的摘要
+	if _, err := mgr.RefreshAccessToken(pair.RefreshToken); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	retiredFound := false
+	for key, value := range storage.data {
+		if info, ok := value.(*security.RefreshTokenInfo); ok && strings.HasSuffix(key, mgr.generator.Digest(pair.RefreshToken)) {
+			retiredFound = true
+			if !info.Rotated || info.AccessToken != mgr.generator.Digest(pair.AccessToken) {
+				t.Errorf("retired record = %+v", info)
+			}
+		}
+	}
+	if !retiredFound {
+		t.Error("retired refresh token record not found")
+	}
+
+	if err := mgr.Kickout("1000", "web"); err != nil {
+		t.Fatalf("Kickout failed: %v", err)
+	}
+	if mgr.IsLogin(tokenValue) {
+		t.Error("token should be invalid after kickout")
+	}
+}
+
+func TestTokenHashMigration(t *testing.T) {
+	storage := newTestStorage()
+	legacy := newTestManager(storage, nil)
+
+	lazyToken, _ := legacy.Login("1000", "web")
+	bulkToken, _ := legacy.Login("2000", "app")
+
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.TokenHashAtRest = true
+		cfg.TokenHashSecret = "secret"
+	})
+
+	// Lazy migration on validation | 校验时惰性迁移
+	if !mgr.IsLogin(lazyToken) {
+		t.Fatal("legacy token should stay valid after enabling hashing")
+	}
+	if storage.Exists(legacy.getTokenKey(lazyToken)) {
+		t.Error("raw token key should be removed after migration")
+	}
+	if stored := storage.data[mgr.getAccountKey("1000", "web")]; stored != mgr.generator.Digest(lazyToken) {
+		t.Errorf("account index not migrated, got %s", stored)
+	}
+
+	// Bulk migration | 批量迁移
+	count, err := mgr.MigrateTokenDigests()
+	if err != nil || count != 1 {
+		t.Fatalf("MigrateTokenDigests = %d, %v", count, err)
+	}
+	if storage.Exists(legacy.getTokenKey(bulkToken)) {
+		t.Error("raw token key should be removed after bulk migration")
+	}
+	if loginID, err := mgr.GetLoginID(bulkToken); err != nil || loginID != "2000" {
+		t.Fatalf("GetLoginID after migration = %q, %v", loginID, err)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, nil)
+
+	reused := make(chan *listener.EventData, 1)
+	mgr.RegisterWithConfig(listener.EventRefreshTokenReuse, listener.ListenerFunc(func(data *listener.EventData) {
+		reused <- data
+	}), listener.ListenerConfig{Async: false})
+
+	first, err := mgr.LoginWithRefreshToken("1000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+
+	second, err := mgr.RefreshAccessToken(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("refresh token should rotate on every use")
+	}
+	if second.FamilyID != first.FamilyID {
+		t.Error("rotated refresh token should stay in the same family")
+	}
+	if mgr.IsLogin(first.AccessToken) {
+		t.Error("previous access token should be invalidated on refresh")
+	}
+	if !mgr.IsLogin(second.AccessToken) {
+		t.Error("new access token should be valid")
+	}
+
+	// Presenting the rotated token again revokes the family | 再次使用已轮换的令牌会撤销整个家族
+	if _, err := mgr.RefreshAccessToken(first.RefreshToken); !errors.Is(err, security.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	select {
+	case data := <-reused:
+		if data.LoginID != "1000" || data.Extra["familyId"] != first.FamilyID {
+			t.Errorf("unexpected reuse event: %+v", data)
+		}
+	default:
+		t.Error("reuse event not fired")
+	}
+	if mgr.IsLogin(second.AccessToken) {
+		t.Error("family access token should be revoked after reuse")
+	}
+	if _, err := mgr.RefreshAccessToken(second.RefreshToken); err == nil {
+		t.Error("family refresh token should be revoked after reuse")
+	}
+}
+
+func TestRefreshConcurrentRotation(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, nil)
+	info, err := mgr.LoginWithRefreshToken("1000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+	storage.delay = 5 * time.Millisecond
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := mgr.RefreshAccessToken(info.RefreshToken)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// Exactly one request consumes the token | 只有一个请求能消费该令牌
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, security.ErrRefreshTokenReused):
+			t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one rotation, got %d", succeeded)
+	}
+}
+
+// plainStorage Hides SetNX, like storages written before adapter.AtomicStorage | 隐藏SetNX，与adapter.AtomicStorage之前实现的存储相同
+type plainStorage struct {
+	adapter.Storage
+}
+
+func TestRefreshWithoutAtomicStorage(t *testing.T) {
+	storage := plainStorage{newTestStorage()}
+	if _, ok := adapter.Storage(storage).(adapter.AtomicStorage); ok {
+		t.Fatal("plainStorage should not implement AtomicStorage")
+	}
+	mgr := newTestManager(storage, nil)
+
+	info, err := mgr.LoginWithRefreshToken("1000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken); err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken); !errors.Is(err, security.ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused, got %v", err)
+	}
+}
+
+func TestRefreshAfterFailedIssue(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, nil)
+	reused := 0
+	mgr.RegisterWithConfig(listener.EventRefreshTokenReuse, listener.ListenerFunc(func(data *listener.EventData) {
+		reused++
+	}), listener.ListenerConfig{Async: false})
+
+	info, err := mgr.LoginWithRefreshToken("1000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+
+	// A banned account cannot refresh, lifting the ban makes the token usable again | 被封禁的账号无法刷新，解封后令牌可继续使用
+	mgr.Disable("1000", time.Minute)
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken); err == nil {
+		t.Fatal("banned account should not refresh")
+	}
+	mgr.Untie("1000")
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken); err != nil {
+		t.Fatalf("retry after a failed issue should succeed: %v", err)
+	}
+	if reused != 0 {
+		t.Errorf("a failed issue must not be reported as reuse, got %d events", reused)
+	}
+}
+
+func TestRefreshKeepsAccountIndex(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, nil)
+
+	renewed := make(chan *listener.EventData, 1)
+	mgr.RegisterWithConfig(listener.EventRenew, listener.ListenerFunc(func(data *listener.EventData) {
+		renewed <- data
+	}), listener.ListenerConfig{Async: false})
+
+	first, _ := mgr.LoginWithRefreshToken("1000", "web")
+	second, err := mgr.RefreshAccessToken(first.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+
+	if stored, _ := mgr.GetTokenValue("1000", "web"); stored != second.AccessToken {
+		t.Errorf("account index should point at refreshed token, got %s", stored)
+	}
+	select {
+	case data := <-renewed:
+		if data.Token != second.AccessToken || data.Device != "web" {
+			t.Errorf("unexpected renew event: %+v", data)
+		}
+	default:
+		t.Error("renew event not fired")
+	}
+
+	// Kickout must reach the refreshed token | 踢人下线必须作用于刷新后的Token
+	if err := mgr.Kickout("1000", "web"); err != nil {
+		t.Fatalf("Kickout failed: %v", err)
+	}
+	if mgr.IsLogin(second.AccessToken) {
+		t.Error("refreshed token should be invalid after kickout")
+	}
+
+	// Disabled accounts cannot refresh | 被封禁账号无法刷新
+	third, _ := mgr.LoginWithRefreshToken("2000", "web")
+	if err := mgr.Disable("2000", time.Hour); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if _, err := mgr.RefreshAccessToken(third.RefreshToken); err == nil {
+		t.Error("disabled account should not be able to refresh")
+	}
+}
+
+func TestListAndRevokeAllRefreshTokens(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, nil)
+
+	web, _ := mgr.LoginWithRefreshToken("1000", "web")
+	app, _ := mgr.LoginWithRefreshToken("1000", "app")
+	other, _ := mgr.LoginWithRefreshToken("2000", "web")
+	// A login ID extending "1000" must never match it | 以"1000"开头的登录ID不能被匹配
+	extended, _ := mgr.LoginWithRefreshToken("1000:web", "web")
+
+	// Rotation keeps one entry per family | 轮换后每个家族仍只有一项
+	web, _ = mgr.RefreshAccessToken(web.RefreshToken)
+
+	infos, err := mgr.ListRefreshTokens("1000")
+	if err != nil || len(infos) != 2 {
+		t.Fatalf("ListRefreshTokens = %d, %v", len(infos), err)
+	}
+	for _, info := range infos {
+		if info.FamilyID == web.FamilyID && info.RefreshToken != web.RefreshToken {
+			t.Errorf("listed refresh token should be the latest rotation")
+		}
+	}
+
+	if err := mgr.RevokeAllRefreshTokens("1000", "app"); err != nil {
+		t.Fatalf("RevokeAllRefreshTokens failed: %v", err)
+	}
+	if _, err := mgr.RefreshAccessToken(app.RefreshToken); err == nil {
+		t.Error("app refresh token should be revoked")
+	}
+	if mgr.IsLogin(app.AccessToken) {
+		t.Error("app access token should be revoked")
+	}
+	if !mgr.refreshManager.IsValid(web.RefreshToken) {
+		t.Error("web refresh token should survive device-scoped revoke")
+	}
+
+	if err := mgr.RevokeAllRefreshTokens("1000"); err != nil {
+		t.Fatalf("RevokeAllRefreshTokens failed: %v", err)
+	}
+	if infos, _ := mgr.ListRefreshTokens("1000"); len(infos) != 0 {
+		t.Errorf("expected no refresh tokens, got %d", len(infos))
+	}
+	if !mgr.refreshManager.IsValid(other.RefreshToken) || !mgr.refreshManager.IsValid(extended.RefreshToken) {
+		t.Error("other users must not be affected")
+	}
+}
+
+func TestCascadeRefreshToken(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.CascadeRefreshToken = true
+		cfg.ActiveTimeout = 600
+	})
+
+	web, _ := mgr.LoginWithRefreshToken("1000", "web")
+	app, _ := mgr.LoginWithRefreshToken("1000", "app")
+
+	kicked := make(chan *listener.EventData, 1)
+	mgr.RegisterWithConfig(listener.EventKickout, listener.ListenerFunc(func(data *listener.EventData) {
+		kicked <- data
+	}), listener.ListenerConfig{Async: false})
+
+	if err := mgr.Kickout("1000", "web"); err != nil {
+		t.Fatalf("Kickout failed: %v", err)
+	}
+	if len(kicked) != 1 {
+		t.Error("kickout event not fired")
+	}
+	if mgr.refreshManager.IsValid(web.RefreshToken) {
+		t.Error("kickout should revoke refresh tokens of the device")
+	}
+	if err := mgr.CheckLogin(web.AccessToken); !errors.Is(err, ErrTokenKickedOut) {
+		t.Errorf("revoking refresh tokens should keep the kickout tombstone, got %v", err)
+	}
+	if !mgr.refreshManager.IsValid(app.RefreshToken) {
+		t.Error("kickout should keep refresh tokens of other devices")
+	}
+
+	if err := mgr.Logout("1000", "app"); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if mgr.refreshManager.IsValid(app.RefreshToken) {
+		t.Error("logout should revoke refresh tokens of the device")
+	}
+
+	third, _ := mgr.LoginWithRefreshToken("1000", "web")
+	if err := mgr.Disable("1000", time.Hour); err != nil {
+		t.Fatalf("Disable failed: %v", err)
+	}
+	if mgr.refreshManager.IsValid(third.RefreshToken) || mgr.IsLogin(third.AccessToken) {
+		t.Error("disable should revoke refresh and access tokens")
+	}
+	if storage.Exists(mgr.getActiveKey(third.AccessToken)) {
+		t.Error("revoked access tokens should not leave an active key behind")
+	}
+}
+
+func TestRefreshExpiration(t *testing.T) {
+	// shorten Moves a stored refresh token closer to expiration | 让已存储的刷新令牌更接近过期
+	shorten := func(mgr *Manager, storage *testStorage, info *security.RefreshTokenInfo, remaining int64) {
+		stored := storage.data[mgr.prefix+security.RefreshKeySuffix+info.RefreshToken].(*security.RefreshTokenInfo)
+		stored.ExpireTime = time.Now().Unix() + remaining
+	}
+
+	t.Run("absolute", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, func(cfg *config.Config) {
+			cfg.RefreshTimeout = 100
+		})
+		first, _ := mgr.LoginWithRefreshToken("1000", "web")
+		if want := first.CreateTime + 100; first.ExpireTime != want {
+			t.Fatalf("ExpireTime = %d, want %d", first.ExpireTime, want)
+		}
+		shorten(mgr, storage, first, 10)
+		second, err := mgr.RefreshAccessToken(first.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshAccessToken failed: %v", err)
+		}
+		if second.ExpireTime > time.Now().Unix()+10 {
+			t.Errorf("absolute expiration should not move, got %d", second.ExpireTime)
+		}
+	})
+
+	t.Run("sliding", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, func(cfg *config.Config) {
+			cfg.RefreshTimeout = 100
+			cfg.RefreshSliding = true
+		})
+		first, _ := mgr.LoginWithRefreshToken("1000", "web")
+		shorten(mgr, storage, first, 10)
+		second, err := mgr.RefreshAccessToken(first.RefreshToken)
+		if err != nil {
+			t.Fatalf("RefreshAccessToken failed: %v", err)
+		}
+		if second.ExpireTime < time.Now().Unix()+99 {
+			t.Errorf("sliding expiration should extend, got %d", second.ExpireTime)
+		}
+	})
+
+	t.Run("max lifetime", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, func(cfg *config.Config) {
+			cfg.RefreshTimeout = 100
+			cfg.RefreshSliding = true
+			cfg.RefreshMaxLifetime = 50
+		})
+		first, _ := mgr.LoginWithRefreshToken("1000", "web")
+		if want := first.FamilyCreateTime + 50; first.ExpireTime != want {
+			t.Fatalf("ExpireTime = %d, want %d", first.ExpireTime, want)
+		}
+		second, _ := mgr.RefreshAccessToken(first.RefreshToken)
+		if second.ExpireTime > first.FamilyCreateTime+50 {
+			t.Errorf("sliding must not exceed max lifetime, got %d", second.ExpireTime)
+		}
+	})
+}
+
+func TestRefreshFingerprint(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.RefreshBindFingerprint = true
+	})
+
+	// Binding fails closed without a fingerprint | 未提供指纹时绑定拒绝签发
+	if _, err := mgr.LoginWithRefreshToken("1000", "web"); !errors.Is(err, security.ErrFingerprintRequired) {
+		t.Fatalf("expected ErrFingerprintRequired, got %v", err)
+	}
+	if storage.Exists(mgr.getAccountKey("1000", "web")) {
+		t.Error("rejected issuance must not log the user in")
+	}
+
+	fingerprint := security.ClientFingerprint("10.0.0.1", "Mozilla/5.0")
+	info, err := mgr.LoginWithRefreshToken("1000", "web", fingerprint)
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken); !errors.Is(err, security.ErrFingerprintMismatch) {
+		t.Fatalf("expected ErrFingerprintMismatch without fingerprint, got %v", err)
+	}
+	other := security.ClientFingerprint("10.0.0.2", "Mozilla/5.0")
+	if _, err := mgr.RefreshAccessToken(info.RefreshToken, other); !errors.Is(err, security.ErrFingerprintMismatch) {
+		t.Fatalf("expected ErrFingerprintMismatch for other client, got %v", err)
+	}
+	if !mgr.IsLogin(info.AccessToken) {
+		t.Error("mismatch must not revoke the current access token")
+	}
+
+	next, err := mgr.RefreshAccessToken(info.RefreshToken, fingerprint)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken with fingerprint failed: %v", err)
+	}
+	if next.Fingerprint != fingerprint {
+		t.Error("fingerprint should carry over to rotated token")
+	}
+
+	// Tokens issued before binding was enabled cannot be refreshed | 启用绑定前签发的令牌不能刷新
+	unbound, err := newTestManager(storage, nil).LoginWithRefreshToken("2000", "web")
+	if err != nil {
+		t.Fatalf("LoginWithRefreshToken failed: %v", err)
+	}
+	if _, err := mgr.RefreshAccessToken(unbound.RefreshToken, fingerprint); !errors.Is(err, security.ErrFingerprintMismatch) {
+		t.Fatalf("expected ErrFingerprintMismatch for unbound token, got %v", err)
+	}
+}
+
+func TestLoginStateErrors(t *testing.T) {
+	t.Run("missing and forged", func(t *testing.T) {
+		mgr := newTestManager(newTestStorage(), nil)
+		if err := mgr.CheckLogin(""); !errors.Is(err, ErrNotToken) {
+			t.Errorf("expected ErrNotToken, got %v", err)
+		}
+		if err := mgr.CheckLogin("forged"); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("expected ErrTokenInvalid, got %v", err)
+		}
+	})
+
+	t.Run("kicked out", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, nil)
+		var reason any
+		mgr.RegisterFunc(listener.EventKickout, func(data *listener.EventData) {
+			reason = data.Extra["reason"]
+		})
+
+		tokenValue, _ := mgr.Login("1000", "web")
+		if err := mgr.Kickout("1000", "web"); err != nil {
+			t.Fatalf("Kickout failed: %v", err)
+		}
+		mgr.WaitEvents()
+
+		err := mgr.CheckLogin(tokenValue)
+		if !errors.Is(err, ErrTokenKickedOut) || !errors.Is(err, ErrNotLogin) {
+			t.Fatalf("expected ErrTokenKickedOut wrapping ErrNotLogin, got %v", err)
+		}
+		if reason != "kickout" {
+			t.Errorf("kickout event reason = %v", reason)
+		}
+		ttl, _ := storage.TTL(mgr.getTokenKey(tokenValue))
+		if ttl <= 0 || ttl > time.Duration(mgr.config.Timeout)*time.Second {
+			t.Errorf("tombstone should keep the remaining TTL, got %v", ttl)
+		}
+		if _, err := mgr.GetLoginIDNotCheck(tokenValue); err == nil {
+			t.Error("tombstone must not resolve to a login ID")
+		}
+		if _, err := mgr.GetTokenValue("1000", "web"); err == nil {
+			t.Error("account index should be removed on kickout")
+		}
+
+		mgr.LogoutByToken(tokenValue)
+		if err := mgr.CheckLogin(tokenValue); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("expected ErrTokenInvalid after logout, got %v", err)
+		}
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		mgr := newTestManager(newTestStorage(), func(cfg *config.Config) {
+			cfg.IsConcurrent = false
+		})
+		var reason any
+		mgr.RegisterFunc(listener.EventKickout, func(data *listener.EventData) {
+			reason = data.Extra["reason"]
+		})
+
+		first, _ := mgr.Login("1000", "web")
+		second, _ := mgr.Login("1000", "web")
+		mgr.WaitEvents()
+
+		if err := mgr.CheckLogin(first); !errors.Is(err, ErrTokenReplaced) {
+			t.Fatalf("expected ErrTokenReplaced, got %v", err)
+		}
+		if reason != "replaced" {
+			t.Errorf("kickout event reason = %v", reason)
+		}
+		if loginID, err := mgr.GetLoginID(second); err != nil || loginID != "1000" {
+			t.Fatalf("GetLoginID = %q, %v", loginID, err)
+		}
+
+		// Kicking out the new login keeps the old tombstone | 踢出新登录不影响旧的墓碑标记
+		mgr.Kickout("1000", "web")
+		if err := mgr.CheckLogin(second); !errors.Is(err, ErrTokenKickedOut) {
+			t.Errorf("expected ErrTokenKickedOut, got %v", err)
+		}
+		if err := mgr.CheckLogin(first); !errors.Is(err, ErrTokenReplaced) {
+			t.Errorf("expected ErrTokenReplaced, got %v", err)
+		}
+	})
+
+	t.Run("expired jwt", func(t *testing.T) {
+		mgr := newTestManager(newTestStorage(), func(cfg *config.Config) {
+			cfg.TokenStyle = config.TokenStyleJWT
+			cfg.JwtSecretKey = "secret"
+		})
+		expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"loginId": "1000",
+			"exp":     time.Now().Add(-time.Minute).Unix(),
+		}).SignedString([]byte("secret"))
+
+		if err := mgr.CheckLogin(expired); !errors.Is(err, ErrTokenExpired) {
+			t.Errorf("expected ErrTokenExpired, got %v", err)
+		}
+	})
+
+	t.Run("expired opaque", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, nil)
+
+		tokenValue, _ := mgr.Login("1000", "web")
+		// Simulate the token key expiring before its account index | 模拟Token键先于账号索引过期
+		storage.Delete(mgr.getTokenKey(tokenValue))
+		if err := mgr.CheckLogin(tokenValue); !errors.Is(err, ErrTokenInvalid) {
+			t.Errorf("expected ErrTokenInvalid for an expired opaque token, got %v", err)
+		}
+
+		if err := mgr.Kickout("1000", "web"); err != nil {
+			t.Fatalf("Kickout failed: %v", err)
+		}
+		if storage.Exists(mgr.getAccountKey("1000", "web")) {
+			t.Error("account index should be removed even when the token already expired")
+		}
+	})
+
+	t.Run("frozen", func(t *testing.T) {
+		storage := newTestStorage()
+		mgr := newTestManager(storage, func(cfg *config.Config) {
+			cfg.ActiveTimeout = 60
+		})
+
+		tokenValue, _ := mgr.Login("1000", "web")
+		if err := mgr.CheckLogin(tokenValue); err != nil {
+			t.Fatalf("CheckLogin failed: %v", err)
+		}
+
+		// Simulate ActiveTimeout passing without requests | 模拟超过ActiveTimeout没有请求
+		storage.Delete(mgr.getActiveKey(mgr.generator.Digest(tokenValue)))
+		if err := mgr.CheckLogin(tokenValue); !errors.Is(err, ErrTokenFrozen) {
+			t.Errorf("expected ErrTokenFrozen, got %v", err)
+		}
+	})
+}
+
+// stubProvider PermissionProvider backed by fixed maps | 基于固定映射的权限提供者
+type stubProvider struct {
+	permissions map[string][]string
+	roles       map[string][]string
+	loginTypes  []string
+}
+
+func (p *stubProvider) GetPermissionList(loginID string, loginType string) ([]string, error) {
+	p.loginTypes = append(p.loginTypes, loginType)
+	return p.permissions[loginID], nil
+}
+
+func (p *stubProvider) GetRoleList(loginID string, loginType string) ([]string, error) {
+	p.loginTypes = append(p.loginTypes, loginType)
+	return p.roles[loginID], nil
+}
+
+func TestPermissionProvider(t *testing.T) {
+	mgr := newTestManager(newTestStorage(), nil)
+
+	// Session provider by default | 默认使用Session提供者
+	mgr.SetPermissions("1000", []string{"user:*"})
+	mgr.SetRoles("1000", []string{"admin"})
+	if !mgr.HasPermission("1000", "user:delete") || !mgr.HasRole("1000", "admin") {
+		t.Fatal("session provider should serve SetPermissions/SetRoles")
+	}
+
+	provider := &stubProvider{
+		permissions: map[string][]string{"1000": {"order:read"}},
+		roles:       map[string][]string{"1000": {"auditor"}},
+	}
+	mgr.SetPermissionProvider(provider)
+
+	if !mgr.HasPermission("1000", "order:read") || mgr.HasPermission("1000", "user:delete") {
+		t.Error("HasPermission should consult the registered provider")
+	}
+	if !mgr.HasRole("1000", "auditor") || mgr.HasRole("1000", "admin") {
+		t.Error("HasRole should consult the registered provider")
+	}
+	if !mgr.HasRolesOr("1000", []string{"admin", "auditor"}) {
+		t.Error("HasRolesOr should consult the registered provider")
+	}
+	for _, loginType := range provider.loginTypes {
+		if loginType != DefaultLoginType {
+			t.Errorf("loginType = %q, want %q", loginType, DefaultLoginType)
+		}
+	}
+
+	mgr.SetPermissionProvider(nil)
+	if !mgr.HasRole("1000", "admin") {
+		t.Error("nil should restore the session provider")
+	}
+}
+
+// countingProvider PermissionProvider that counts lookups | 统计查询次数的权限提供者
+type countingProvider struct {
+	mu          sync.Mutex
+	permissions map[string][]string
+	calls       int
+}
+
+func (p *countingProvider) GetPermissionList(loginID string, loginType string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	return p.permissions[loginID], nil
+}
+
+func (p *countingProvider) GetRoleList(loginID string, loginType string) ([]string, error) {
+	return nil, fmt.Errorf("role service unavailable")
+}
+
+func (p *countingProvider) grant(loginID string, permissions ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.permissions[loginID] = permissions
+}
+
+func TestPermissionCache(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.PermissionCacheTimeout = 60
+	})
+	provider := &countingProvider{permissions: map[string][]string{"1000": {"order:read"}}}
+	mgr.SetPermissionProvider(provider)
+
+	for i := 0; i < 3; i++ {
+		if !mgr.HasPermission("1000", "order:read") {
+			t.Fatal("expected cached permission")
+		}
+	}
+	if provider.calls != 1 {
+		t.Fatalf("provider calls = %d, want 1", provider.calls)
+	}
+	ttl, _ := storage.TTL(mgr.getPermissionCacheKey("1000", cacheKindPermissions))
+	if ttl <= 0 || ttl > 60*time.Second {
+		t.Errorf("cache TTL = %v", ttl)
+	}
+
+	// Stale until invalidated | 清除前保持旧值
+	provider.grant("1000", "order:write")
+	if mgr.HasPermission("1000", "order:write") {
+		t.Error("cached list should be served until invalidation")
+	}
+	mgr.InvalidatePermissions("1000")
+	if !mgr.HasPermission("1000", "order:write") {
+		t.Error("InvalidatePermissions should reload from the provider")
+	}
+
+	// Login, logout and kickout events invalidate | 登录、登出和踢人下线事件会清除缓存
+	provider.grant("1000", "order:export")
+	mgr.Login("1000", "web")
+	if !mgr.HasPermission("1000", "order:export") {
+		t.Error("login should invalidate the cache")
+	}
+
+	provider.grant("1000", "order:read")
+	provider.grant("2000", "order:read")
+	mgr.HasPermission("2000", "order:read")
+	mgr.InvalidateAll()
+	calls := provider.calls
+	mgr.HasPermission("1000", "order:read")
+	mgr.HasPermission("2000", "order:read")
+	if provider.calls != calls+2 {
+		t.Error("InvalidateAll should drop every account")
+	}
+
+	// Provider failures are not cached | 提供者失败不缓存
+	if mgr.HasRole("1000", "admin") {
+		t.Error("failed lookup must deny")
+	}
+	if storage.Exists(mgr.getPermissionCacheKey("1000", cacheKindRoles)) {
+		t.Error("failed lookup must not be cached")
+	}
+
+	// SetPermissions invalidates the session provider's cache | SetPermissions会清除Session提供者的缓存
+	mgr.SetPermissionProvider(nil)
+	mgr.SetPermissions("3000", []string{"user:read"})
+	mgr.HasPermission("3000", "user:read")
+	mgr.SetPermissions("3000", []string{"user:write"})
+	if !mgr.HasPermission("3000", "user:write") || mgr.HasPermission("3000", "user:read") {
+		t.Error("SetPermissions should invalidate the cache")
+	}
+}
+
+func TestRoleHierarchy(t *testing.T) {
+	mgr := newTestManager(newTestStorage(), func(cfg *config.Config) {
+		cfg.PermissionCacheTimeout = 60
+	})
+
+	if err := mgr.GrantRolePermissions("editor", "article:edit", "article:view"); err != nil {
+		t.Fatalf("GrantRolePermissions failed: %v", err)
+	}
+	if err := mgr.SaveRole(&Role{Name: "admin", Permissions: []string{"user:*"}, Inherits: []string{"editor"}}); err != nil {
+		t.Fatalf("SaveRole failed: %v", err)
+	}
+	if err := mgr.SetRoleInherits("super-admin", "admin"); err != nil {
+		t.Fatalf("SetRoleInherits failed: %v", err)
+	}
+
+	mgr.SetRoles("1000", []string{"super-admin"})
+	mgr.SetPermissions("1000", []string{"report:view"})
+
+	if !mgr.HasRolesAnd("1000", []string{"super-admin", "admin", "editor"}) {
+		t.Error("roles should be inherited")
+	}
+	if !mgr.HasPermissionsAnd("1000", []string{"article:edit", "user:delete", "report:view"}) {
+		t.Error("permissions should resolve through inherited roles")
+	}
+
+	// Cycles are rejected | 拒绝循环继承
+	if err := mgr.SetRoleInherits("editor", "super-admin"); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("expected ErrRoleCycle, got %v", err)
+	}
+	if err := mgr.SetRoleInherits("admin", "admin"); !errors.Is(err, ErrRoleCycle) {
+		t.Errorf("expected ErrRoleCycle for self inheritance, got %v", err)
+	}
+	if err := mgr.SaveRole(&Role{Name: "bad*"}); !errors.Is(err, ErrInvalidRoleName) {
+		t.Errorf("expected ErrInvalidRoleName, got %v", err)
+	}
+
+	// Definition changes apply to cached accounts | 定义变更对已缓存账号生效
+	if err := mgr.RevokeRolePermissions("editor", "article:edit"); err != nil {
+		t.Fatalf("RevokeRolePermissions failed: %v", err)
+	}
+	if mgr.HasPermission("1000", "article:edit") || !mgr.HasPermission("1000", "article:view") {
+		t.Error("revoked role permission should no longer apply")
+	}
+	if err := mgr.DeleteRole("admin"); err != nil {
+		t.Fatalf("DeleteRole failed: %v", err)
+	}
+	if mgr.HasRole("1000", "editor") || mgr.HasPermission("1000", "user:delete") {
+		t.Error("deleted role should no longer link super-admin to its grants")
+	}
+
+	roles, err := mgr.ListRoles()
+	if err != nil || len(roles) != 2 || roles[0].Name != "editor" || roles[1].Name != "super-admin" {
+		t.Errorf("ListRoles = %v, %v", roles, err)
+	}
+	if perms := mgr.GetRolePermissions("editor"); len(perms) != 1 || perms[0] != "article:view" {
+		t.Errorf("GetRolePermissions = %v", perms)
+	}
+}
+
+func TestPermissionDenyRules(t *testing.T) {
+	mgr := newTestManager(newTestStorage(), nil)
+	mgr.GrantRolePermissions("auditor", "!order:**:refund")
+	mgr.SetRoles("1000", []string{"auditor"})
+	mgr.SetPermissions("1000", []string{"order:**", "user:*", "!user:delete"})
+
+	if !mgr.HasPermissionsAnd("1000", []string{"order:view", "order:1:edit", "user:add"}) {
+		t.Error("grants should match")
+	}
+	if mgr.HasPermission("1000", "user:delete") {
+		t.Error("account deny rule should override user:*")
+	}
+	if mgr.HasPermissionsOr("1000", []string{"order:refund", "order:1:refund"}) {
+		t.Error("role deny rule should override order:**")
+	}
+	if mgr.HasPermission("1000", "USER:add") {
+		t.Error("matching should be case sensitive by default")
+	}
+
+	mgr.GetConfig().SetPermissionIgnoreCase(true)
+	if !mgr.HasPermission("1000", "USER:add") || mgr.HasPermission("1000", "User:Delete") {
+		t.Error("PermissionIgnoreCase should apply to grants and denies")
+	}
+}
+
+// TestPolicyCheck 测试基于属性的策略检查
+func TestPolicyCheck(t *testing.T) {
+	mgr := newTestManager(newTestStorage(), nil)
+
+	type document struct {
+		OwnerID int    `json:"ownerId"`
+		Status  string `json:"status"`
+	}
+
+	if err := mgr.RegisterPolicyExpr("doc:edit", `subject.id == resource.ownerId || hasRole("admin")`); err != nil {
+		t.Fatalf("RegisterPolicyExpr failed: %v", err)
+	}
+	if err := mgr.RegisterPolicyExpr("doc:*", `resource.status != "archived"`); err != nil {
+		t.Fatalf("RegisterPolicyExpr failed: %v", err)
+	}
+	if err := mgr.RegisterPolicyExpr("doc:broken", `resource.status ==`); err == nil {
+		t.Error("invalid expression should not compile")
+	}
+	mgr.RegisterPolicy("report:view", func(req *PolicyRequest) (bool, error) {
+		return req.Context["ip"] == "10.0.0.1", nil
+	})
+	mgr.SetRoles("2000", []string{"admin"})
+
+	draft := &document{OwnerID: 1000, Status: "draft"}
+	archived := map[string]any{"ownerId": "1000", "status": "archived"}
+
+	if err := mgr.Check("1000", "doc:edit", draft); err != nil {
+		t.Errorf("owner should edit: %v", err)
+	}
+	if err := mgr.Check("1001", "doc:edit", draft); !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("expected ErrPolicyDenied for non-owner, got %v", err)
+	}
+	if !mgr.Can("2000", "doc:edit", draft) {
+		t.Error("admin should edit")
+	}
+	if mgr.Can("1000", "doc:edit", archived) {
+		t.Error("every matching policy must allow")
+	}
+	if err := mgr.Check("1000", "user:delete", nil); !errors.Is(err, ErrNoPolicy) {
+		t.Errorf("expected ErrNoPolicy, got %v", err)
+	}
+	if err := mgr.CheckWithContext("1000", "report:view", nil, map[string]any{"ip": "10.0.0.1"}); err != nil {
+		t.Errorf("context policy should allow: %v", err)
+	}
+	if mgr.Can("1000", "report:view", nil) {
+		t.Error("context policy should deny without ip")
+	}
+}
+
+// TestTenantIsolation 测试多租户隔离
+func TestTenantIsolation(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.PermissionCacheTimeout = 60
+	})
+	mgr.SetTenantConfig("b", func(cfg *config.Config) {
+		cfg.Timeout = 60
+		cfg.TokenName = "b-token"
+	})
+
+	var mu sync.Mutex
+	tenants := map[string]bool{}
+	mgr.RegisterFunc(listener.EventLogin, func(data *listener.EventData) {
+		mu.Lock()
+		tenants[data.TenantID] = true
+		mu.Unlock()
+	})
+
+	a, b := mgr.Tenant("a"), mgr.Tenant("b")
+	if mgr.Tenant("a") != a || a.Tenant("b") != b || a.GetRootManager() != mgr || mgr.Tenant("") != mgr {
+		t.Fatal("tenant managers should be cached and share the root")
+	}
+	if b.GetConfig().Timeout != 60 || b.GetConfig().TokenName != "b-token" || a.GetConfig().Timeout == 60 {
+		t.Error("tenant config overrides should only apply to their tenant")
+	}
+
+	tokenA, _ := a.Login("42")
+	tokenB, _ := b.Login("42")
+	if !a.IsLogin(tokenA) || a.IsLogin(tokenB) || mgr.IsLogin(tokenA) {
+		t.Error("tokens should only be valid in their tenant")
+	}
+	for key := range storage.data {
+		if strings.Contains(key, tokenA) && !strings.HasPrefix(key, "satoken:tenant:a:") {
+			t.Errorf("tenant key outside its namespace: %s", key)
+		}
+	}
+
+	// Permissions, bans and kickouts stay in their tenant | 权限、封禁和踢人下线仅作用于所在租户
+	a.SetPermissions("42", []string{"order:*"})
+	if !a.HasPermission("42", "order:view") || b.HasPermission("42", "order:view") {
+		t.Error("permissions should be tenant scoped")
+	}
+	a.Disable("42", time.Minute)
+	if !a.IsDisable("42") || b.IsDisable("42") {
+		t.Error("bans should be tenant scoped")
+	}
+	a.Kickout("42")
+	if err := a.CheckLogin(tokenA); !errors.Is(err, ErrTokenKickedOut) {
+		t.Errorf("expected ErrTokenKickedOut, got %v", err)
+	}
+	if err := b.CheckLogin(tokenB); err != nil {
+		t.Errorf("other tenant should stay logged in: %v", err)
+	}
+
+	mgr.WaitEvents()
+	mu.Lock()
+	defer mu.Unlock()
+	if !tenants["a"] || !tenants["b"] {
+		t.Errorf("events should carry the tenant ID, got %v", tenants)
+	}
+}
+
+// TestTenantCache 测试租户管理器缓存上限和权限缓存监听器
+func TestTenantCache(t *testing.T) {
+	storage := newTestStorage()
+	mgr := newTestManager(storage, func(cfg *config.Config) {
+		cfg.PermissionCacheTimeout = 60
+	})
+	listeners := mgr.GetEventManager().CountForEvent(listener.EventLogin)
+
+	// Tenants and rebuilds share the root's listener | 租户和重建共享根管理器的监听器
+	a := mgr.Tenant("a")
+	mgr.Tenant("b")
+	mgr.SetTenantConfig("a", func(cfg *config.Config) { cfg.Timeout = 60 })
+	if mgr.Tenant("a") == a {
+		t.Fatal("tenant should be rebuilt after SetTenantConfig")
+	}
+	if got := mgr.GetEventManager().CountForEvent(listener.EventLogin); got != listeners {
+		t.Errorf("tenants should not add listeners, got %d want %d", got, listeners)
+	}
+
+	// Logging in drops the tenant's cached lists | 登录清除租户的缓存列表
+	a = mgr.Tenant("a")
+	a.SetPermissions("42", []string{"order:view"})
+	a.GetPermissions("42")
+	key := a.getPermissionCacheKey("42", cacheKindPermissions)
+	if _, ok := storage.data[key]; !ok {
+		t.Fatal("permissions should be cached")
+	}
+	a.Login("42")
+	if _, ok := storage.data[key]; ok {
+		t.Error("login should drop the tenant's cache")
+	}
+
+	mgr.SetMaxTenants(2)
+	for i := 0; i < 10; i++ {
+		mgr.Tenant(fmt.Sprintf("t%d", i))
+	}
+	if len(mgr.tenants) > 2 {
+		t.Errorf("tenant cache should be bounded, got %d", len(mgr.tenants))
+	}
+}
+
+// TestLoginTypes 测试多账号体系
+func TestLoginTypes(t *testing.T) {
+	storage := newTestStorage()
+	user := newTestManager(storage, nil)
+	admin := newTestManager(storage, func(cfg *config.Config) {
+		cfg.LoginType = "admin"
+		cfg.TokenName = "admin-token"
+		cfg.Timeout = 600
+	})
+	if user.GetLoginType() != DefaultLoginType || admin.GetLoginType() != "admin" {
+		t.Fatal("login type should come from the config")
+	}
+
+	// Names of key segments would share keys with the default namespace | 与键段同名会与默认命名空间共用键
+	for _, reserved := range []string{"token", "account", "session", "role", "tenant", "refresh"} {
+		if err := config.DefaultConfig().SetLoginType(reserved).Validate(); err == nil {
+			t.Errorf("login type %q should be reserved", reserved)
+		}
+	}
+	if err := config.DefaultConfig().SetLoginType("admin").Validate(); err != nil {
+		t.Errorf("login type admin should be valid: %v", err)
+	}
+
+	var mu sync.Mutex
+	types := map[string]bool{}
+	admin.RegisterFunc(listener.EventLogin, func(data *listener.EventData) {
+		mu.Lock()
+		types[data.LoginType] = true
+		mu.Unlock()
+	})
+
+	userToken, _ := user.Login("1")
+	adminToken, _ := admin.Login("1")
+	if !admin.IsLogin(adminToken) || admin.IsLogin(userToken) || user.IsLogin(adminToken) {
+		t.Error("tokens should only be valid for their login type")
+	}
+	for key := range storage.data {
+		if strings.Contains(key, adminToken) && !strings.HasPrefix(key, "satoken:admin:") {
+			t.Errorf("admin key outside its namespace: %s", key)
+		}
+		if strings.Contains(key, userToken) && strings.HasPrefix(key, "satoken:admin:") {
+			t.Errorf("user key inside the admin namespace: %s", key)
+		}
+	}
+
+	admin.Disable("1", time.Minute)
+	if user.IsDisable("1") {
+		t.Error("bans should be scoped to the login type")
+	}
+	if tenant := admin.Tenant("a"); tenant.GetLoginType() != "admin" || tenant.getTokenKey("x") != "satoken:tenant:a:admin:token:x" {
+		t.Errorf("tenant of a login type should keep it, got key %s", tenant.getTokenKey("x"))
+	}
+
+	admin.WaitEvents()
+	mu.Lock()
+	defer mu.Unlock()
+	if !types["admin"] || len(types) != 1 {
+		t.Errorf("events should carry the login type, got %v", types)
+	}
+}
+
+func TestDisableServices(t *testing.T) {
+	storage := newTestStorage()
+	m := newTestManager(storage, nil)
+
+	if err := m.DisableWithInfo(&DisableInfo{
+		LoginID:  "1",
+		Service:  "comment",
+		Level:    3,
+		Reason:   "spam",
+		Operator: "support-7",
+	}, time.Hour); err != nil {
+		t.Fatalf("DisableWithInfo failed: %v", err)
+	}
+	if err := m.DisableService("1", "payment", 0); err != nil {
+		t.Fatalf("DisableService failed: %v", err)
+	}
+
+	// A service ban leaves login and other services alone | 服务封禁不影响登录和其他服务
+	if m.IsDisable("1") || m.IsDisableService("1", "upload") {
+		t.Error("only the banned services should be disabled")
+	}
+	if _, err := m.Login("1"); err != nil {
+		t.Errorf("login should not be blocked by a service ban: %v", err)
+	}
+
+	if !m.IsDisableLevel("1", "comment", 3) || m.IsDisableLevel("1", "comment", 4) {
+		t.Error("level checks should compare against the ban level")
+	}
+	if m.GetDisableLevel("1", "comment") != 3 || m.GetDisableLevel("1", "upload") != NotDisableLevel {
+		t.Error("unexpected disable levels")
+	}
+
+	err := m.CheckDisable("1", "comment", MinDisableLevel)
+	var disableErr *DisableError
+	if !errors.As(err, &disableErr) || !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("expected a DisableError, got %v", err)
+	}
+	if info := disableErr.Info; info.Reason != "spam" || info.Operator != "support-7" || info.ExpireTime == 0 {
+		t.Errorf("ban record should keep reason, operator and expiry: %+v", info)
+	}
+
+	list, err := m.GetDisableList("1")
+	if err != nil || len(list) != 2 || list[0].Service != "comment" || list[1].Service != "payment" {
+		t.Errorf("unexpected disable list: %+v, %v", list, err)
+	}
+
+	// Login IDs sharing a suffix or holding glob characters list only their own bans | 后缀相同或含通配字符的账号只列出自己的封禁
+	_ = m.DisableService("x:1", "upload", time.Hour)
+	_ = m.DisableService("1*", "upload", time.Hour)
+	if list, _ := m.GetDisableList("1"); len(list) != 2 {
+		t.Errorf("other accounts leaked into the disable list: %+v", list)
+	}
+	if list, _ := m.GetDisableList("1*"); len(list) != 1 || list[0].LoginID != "1*" {
+		t.Errorf("glob characters should match literally: %+v", list)
+	}
+
+	if err := m.UntieService("1", "comment"); err != nil || m.IsDisableService("1", "comment") {
+		t.Error("UntieService should lift the ban")
+	}
+	if err := m.DisableService("1", "a:b", 0); !errors.Is(err, ErrInvalidDisableService) {
+		t.Errorf("expected ErrInvalidDisableService, got %v", err)
+	}
+	if err := m.DisableLevel("1", "comment", 0, 0); !errors.Is(err, ErrInvalidDisableLevel) {
+		t.Errorf("expected ErrInvalidDisableLevel, got %v", err)
+	}
+
+	// Legacy whole-account bans still block login | 旧版本的整个账号封禁仍会阻止登录
+	_ = storage.Set(m.getDisableKey("2"), DisableValue, 0)
+	if m.GetDisableLevel("2", "") != DefaultDisableLevel {
+		t.Error("legacy bans should read as the default level")
+	}
+	if _, err := m.Login("2"); !errors.Is(err, ErrAccountDisabled) {
+		t.Errorf("expected ErrAccountDisabled, got %v", err)
+	}
+}
+
+func TestEventLifecycle(t *testing.T) {
+	storage := newTestStorage()
+	m := newTestManager(storage, func(cfg *config.Config) {
+		cfg.AutoRenew = true
+		cfg.Timeout = 600
+	})
+
+	var (
+		mu     sync.Mutex
+		events []*listener.EventData
+	)
+	// Synchronous so the stream keeps its order | 同步执行以保证事件顺序
+	m.RegisterWithConfig(listener.EventAll, listener.ListenerFunc(func(data *listener.EventData) {
+		mu.Lock()
+		events = append(events, data)
+		mu.Unlock()
+	}), listener.ListenerConfig{})
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events)
+	}
+
+	tokenValue, err := m.Login("1", "web")
+	if err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// The session already exists on the second login | 第二次登录时Session已存在
+	if _, err := m.Login("1", "app"); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+
+	// A fresh token is not renewed, one whose TTL dropped is | 刚签发的Token不续期，TTL已下降的Token才续期
+	if !m.IsLogin(tokenValue) {
+		t.Fatal("expected logged in")
+	}
+	storage.Expire(m.getTokenKey(tokenValue), 300*time.Second)
+	if !m.IsLogin(tokenValue) {
+		t.Fatal("expected logged in")
+	}
+	// Renewal runs in the background | 续期在后台执行
+	for deadline := time.Now().Add(time.Second); count() < 4 && time.Now().Before(deadline); {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ttl, _ := storage.TTL(m.getTokenKey(tokenValue)); ttl < 500*time.Second {
+		t.Errorf("token should be renewed, ttl %v", ttl)
+	}
+
+	m.SetPermissions("1", []string{"doc:read"})
+	m.HasPermission("1", "doc:read")
+	m.HasRolesOr("1", []string{"admin", "editor"})
+	m.DisableWithInfo(&DisableInfo{LoginID: "1", Service: "comment", Reason: "spam"}, time.Hour)
+	m.UntieService("1", "comment", "payment")
+	m.Kickout("1", "web")
+	m.DeleteSession("1")
+	m.DeleteSession("1")
+
+	want := []listener.Event{
+		listener.EventCreateSession,
+		listener.EventLogin,
+		listener.EventLogin,
+		listener.EventRenew,
+		listener.EventPermissionCheck,
+		listener.EventRoleCheck,
+		listener.EventDisable,
+		listener.EventUntie,
+		listener.EventKickout,
+		listener.EventDestroySession,
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != len(want) {
+		got := make([]listener.Event, len(events))
+		for i, data := range events {
+			got[i] = data.Event
+		}
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i, data := range events {
+		if data.Event != want[i] || data.LoginID != "1" || data.LoginType != DefaultLoginType {
+			t.Errorf("event %d = %+v, want %s for 1", i, data, want[i])
+		}
+	}
+
+	if extra := events[3].Extra; extra["source"] != "autoRenew" || extra["duration"] != int64(600) {
+		t.Errorf("renew extra = %v", extra)
+	}
+	if extra := events[4].Extra; extra["permission"] != "doc:read" || extra["result"] != true {
+		t.Errorf("permission check extra = %v", extra)
+	}
+	if extra := events[5].Extra; extra["mode"] != "or" || extra["result"] != false {
+		t.Errorf("role check extra = %v", extra)
+	}
+	if extra := events[6].Extra; extra["service"] != "comment" || extra["duration"] != int64(3600) || extra["reason"] != "spam" {
+		t.Errorf("disable extra = %v", extra)
+	}
+	if extra := events[7].Extra; extra["service"] != "comment" {
+		t.Errorf("untie extra = %v", extra)
+	}
+}
@@ -0,0 +1,249 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/click33/sa-token-go/core/permission"
+	"github.com/click33/sa-token-go/core/policy"
+)
+
+// Attribute-based access control | 基于属性的访问控制
+//
+// Policies decide whether a subject (login ID) may perform an action on a resource, looking at
+// subject attributes (session data, roles, permissions), resource attributes and request context.
+// Register a Go function with RegisterPolicy() or an expression with RegisterPolicyExpr():
+//
+//	mgr.RegisterPolicyExpr("doc:edit", `subject.id == resource.ownerId || hasRole("admin")`)
+//	err := mgr.Check(loginID, "doc:edit", doc)
+//
+// Actions accept permission patterns ("doc:*") and every policy matching the action must allow it.
+// An action without any policy is denied. Policies are shared by every tenant of a manager.
+// 策略判断主体（登录ID）能否对资源执行操作，依据主体属性（Session数据、角色、权限）、资源属性和请求上下文。
+// 使用RegisterPolicy()注册Go函数，或使用RegisterPolicyExpr()注册表达式。操作名支持权限模式（"doc:*"），
+// 匹配该操作的所有策略都必须允许；没有任何策略的操作会被拒绝。策略由管理器的所有租户共享。
+
+// Expression roots | 表达式根属性
+const (
+	PolicyAttrSubject  = "subject"  // Subject attributes | 主体属性
+	PolicyAttrResource = "resource" // Resource attributes | 资源属性
+	PolicyAttrContext  = "context"  // Request context | 请求上下文
+	PolicyAttrAction   = "action"   // Requested action | 请求的操作
+)
+
+// Policy errors | 策略错误
+var (
+	ErrPolicyDenied = fmt.Errorf("access denied by policy")
+	ErrNoPolicy     = fmt.Errorf("no policy registered for action")
+)
+
+// PolicyRequest Input to a policy | 策略的输入
+type PolicyRequest struct {
+	LoginID  string         // Subject login ID | 主体登录ID
+	Action   string         // Requested action | 请求的操作
+	Resource any            // Target resource, may be nil | 目标资源，可为nil
+	Context  map[string]any // Request context (method, path, ip, params...) | 请求上下文（方法、路径、IP、参数等）
+
+	manager *Manager
+}
+
+// Policy Decides a request, errors deny it | 判定请求，返回错误即拒绝
+type Policy func(req *PolicyRequest) (bool, error)
+
+// SubjectAttribute Reads a subject attribute | 读取主体属性
+// "id"/"loginId", "roles" and "permissions" are built in, anything else comes from the session | 内置"id"/"loginId"、"roles"和"permissions"，其余来自Session
+func (r *PolicyRequest) SubjectAttribute(name string) (any, bool) {
+	switch name {
+	case "id", SessionKeyLoginID:
+		return r.LoginID, true
+	case "roles":
+		roles, err := r.manager.GetRoles(r.LoginID)
+		return roles, err == nil
+	case "permissions":
+		perms, err := r.manager.GetPermissions(r.LoginID)
+		return perms, err == nil
+	}
+	sess, err := r.manager.GetSession(r.LoginID)
+	if err != nil {
+		return nil, false
+	}
+	return sess.Get(name)
+}
+
+// ResourceAttribute Reads a resource attribute | 读取资源属性
+func (r *PolicyRequest) ResourceAttribute(name string) (any, bool) {
+	return policy.Attribute(r.Resource, name)
+}
+
+// HasRole Checks a subject role | 检查主体角色
+func (r *PolicyRequest) HasRole(role string) bool {
+	return r.manager.HasRole(r.LoginID, role)
+}
+
+// HasPermission Checks a subject permission | 检查主体权限
+func (r *PolicyRequest) HasPermission(perm string) bool {
+	return r.manager.HasPermission(r.LoginID, perm)
+}
+
+// ============ Policy Registration | 策略注册 ============
+
+// RegisterPolicy Registers a policy for an action or action pattern | 为操作或操作模式注册策略
+func (m *Manager) RegisterPolicy(action string, p Policy) {
+	if p == nil {
+		return
+	}
+	root := m.root()
+	root.policyMu.Lock()
+	defer root.policyMu.Unlock()
+	root.policies[action] = append(root.policies[action], p)
+}
+
+// RegisterPolicyExpr Compiles and registers an expression policy | 编译并注册表达式策略
+func (m *Manager) RegisterPolicyExpr(action, expr string) error {
+	compiled, err := policy.Compile(expr)
+	if err != nil {
+		return err
+	}
+	m.RegisterPolicy(action, ExprPolicy(compiled))
+	return nil
+}
+
+// RemovePolicies Removes every policy registered for an action | 移除操作上注册的所有策略
+func (m *Manager) RemovePolicies(action string) {
+	root := m.root()
+	root.policyMu.Lock()
+	defer root.policyMu.Unlock()
+	delete(root.policies, action)
+}
+
+// ExprPolicy Adapts a compiled expression to a Policy | 将编译后的表达式适配为Policy
+func ExprPolicy(expr *policy.Expression) Policy {
+	return func(req *PolicyRequest) (bool, error) {
+		return expr.Eval(&policyEnv{req: req})
+	}
+}
+
+// ============ Policy Evaluation | 策略求值 ============
+
+// Check Checks if loginID may perform action on resource | 检查loginID能否对资源执行操作
+func (m *Manager) Check(loginID, action string, resource any) error {
+	return m.CheckWithContext(loginID, action, resource, nil)
+}
+
+// CheckWithContext Checks an action with request context attributes | 带请求上下文属性检查操作
+// Returns ErrNoPolicy, ErrPolicyDenied or the policy's own error | 返回ErrNoPolicy、ErrPolicyDenied或策略自身的错误
+func (m *Manager) CheckWithContext(loginID, action string, resource any, ctx map[string]any) error {
+	policies := m.matchPolicies(action)
+	if len(policies) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoPolicy, action)
+	}
+	if ctx == nil {
+		ctx = map[string]any{}
+	}
+
+	req := &PolicyRequest{
+		LoginID:  loginID,
+		Action:   action,
+		Resource: resource,
+		Context:  ctx,
+		manager:  m,
+	}
+	for _, p := range policies {
+		allowed, err := p(req)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrPolicyDenied, action, err)
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s", ErrPolicyDenied, action)
+		}
+	}
+	return nil
+}
+
+// Can Reports whether loginID may perform action on resource | 判断loginID能否对资源执行操作
+func (m *Manager) Can(loginID, action string, resource any) bool {
+	return m.Check(loginID, action, resource) == nil
+}
+
+// IsPolicyError Checks if err came from policy evaluation | 检查错误是否来自策略求值
+func IsPolicyError(err error) bool {
+	return errors.Is(err, ErrPolicyDenied) || errors.Is(err, ErrNoPolicy)
+}
+
+// matchPolicies Collects policies whose action pattern matches, in a stable order | 按稳定顺序收集操作模式匹配的策略
+func (m *Manager) matchPolicies(action string) []Policy {
+	root := m.root()
+	root.policyMu.RLock()
+	defer root.policyMu.RUnlock()
+
+	patterns := make([]string, 0, len(root.policies))
+	for pattern := range root.policies {
+		if permission.Match(pattern, action) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+
+	var result []Policy
+	for _, pattern := range patterns {
+		result = append(result, root.policies[pattern]...)
+	}
+	return result
+}
+
+// policyEnv Exposes a PolicyRequest to expressions | 向表达式暴露PolicyRequest
+type policyEnv struct {
+	req *PolicyRequest
+}
+
+// Lookup Resolves subject.*, resource.*, context.* and action | 解析subject.*、resource.*、context.*和action
+func (e *policyEnv) Lookup(path []string) (any, bool) {
+	var value any
+	switch path[0] {
+	case PolicyAttrAction:
+		value = e.req.Action
+	case PolicyAttrResource:
+		value = e.req.Resource
+	case PolicyAttrContext:
+		value = e.req.Context
+	case PolicyAttrSubject:
+		if len(path) == 1 {
+			return e.req.LoginID, true
+		}
+		v, ok := e.req.SubjectAttribute(path[1])
+		if !ok {
+			return nil, false
+		}
+		value, path = v, path[1:]
+	default:
+		return nil, false
+	}
+
+	for _, name := range path[1:] {
+		v, ok := policy.Attribute(value, name)
+		if !ok {
+			return nil, false
+		}
+		value = v
+	}
+	return value, true
+}
+
+// Call Provides hasRole(role) and hasPermission(perm) | 提供hasRole(role)和hasPermission(perm)
+func (e *policyEnv) Call(name string, args []any) (any, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%w: %s expects 1 argument", policy.ErrEval, name)
+	}
+	arg, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s expects a string", policy.ErrEval, name)
+	}
+	switch name {
+	case "hasRole":
+		return e.req.HasRole(arg), nil
+	case "hasPermission":
+		return e.req.HasPermission(arg), nil
+	}
+	return nil, fmt.Errorf("%w: unknown function %s", policy.ErrEval, name)
+}
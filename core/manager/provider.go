@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/config"
+	"github.com/click33/sa-token-go/core/session"
+)
+
+// DefaultLoginType Login type of a manager unless configured otherwise | 未另行配置时Manager的登录类型
+const DefaultLoginType = config.DefaultLoginType
+
+// PermissionProvider Loads the permissions and roles of an account (StpInterface equivalent) |
+// 加载账号的权限和角色（对应Java sa-token的StpInterface）
+//
+// Register one with Manager.SetPermissionProvider() to read authorization data from your own RBAC
+// store instead of copying it into every session at login.
+// 通过Manager.SetPermissionProvider()注册后可直接从自己的RBAC存储读取授权数据，无需在登录时复制到每个Session。
+type PermissionProvider interface {
+	// GetPermissionList Returns the permissions of an account | 返回账号的权限列表
+	GetPermissionList(loginID string, loginType string) ([]string, error)
+
+	// GetRoleList Returns the roles of an account | 返回账号的角色列表
+	GetRoleList(loginID string, loginType string) ([]string, error)
+}
+
+// ============ Session Permission Provider | Session权限提供者 ============
+
+// SessionPermissionProvider Default provider reading values written by SetPermissions/SetRoles | 默认提供者，读取SetPermissions/SetRoles写入的值
+type SessionPermissionProvider struct {
+	storage adapter.Storage
+	prefix  string
+}
+
+// NewSessionPermissionProvider Creates a provider backed by account sessions | 创建基于账号Session的提供者
+func NewSessionPermissionProvider(storage adapter.Storage, prefix string) *SessionPermissionProvider {
+	return &SessionPermissionProvider{
+		storage: storage,
+		prefix:  prefix,
+	}
+}
+
+// GetPermissionList Reads permissions from the account session | 从账号Session读取权限列表
+func (p *SessionPermissionProvider) GetPermissionList(loginID string, loginType string) ([]string, error) {
+	return p.getList(loginID, SessionKeyPermissions), nil
+}
+
+// GetRoleList Reads roles from the account session | 从账号Session读取角色列表
+func (p *SessionPermissionProvider) GetRoleList(loginID string, loginType string) ([]string, error) {
+	return p.getList(loginID, SessionKeyRoles), nil
+}
+
+// getList Reads a string list from the account session | 从账号Session读取字符串列表
+func (p *SessionPermissionProvider) getList(loginID, key string) []string {
+	sess, err := session.Load(loginID, p.storage, p.prefix)
+	if err != nil {
+		return []string{}
+	}
+
+	value, exists := sess.Get(key)
+	if !exists {
+		return []string{}
+	}
+	return toStringSlice(value)
+}
@@ -0,0 +1,277 @@
+package manager
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/click33/sa-token-go/core/utils"
+)
+
+// Role hierarchy | 角色层级
+//
+// A Role grants permission patterns and may inherit other roles (super-admin ⊃ admin ⊃ editor).
+// Definitions are persisted in storage; GetRoles() expands an account's roles with everything they
+// inherit and GetPermissions() adds the permissions those roles grant, so HasRole() and
+// HasPermission() resolve through the hierarchy.
+// Role授予权限模式并可继承其他角色（super-admin ⊃ admin ⊃ editor）。角色定义持久化在存储中；GetRoles()会将账号的角色
+// 展开为包含所有继承的角色，GetPermissions()会加入这些角色授予的权限，因此HasRole()和HasPermission()会沿层级解析。
+
+// RoleKeyPrefix Role definition key prefix | 角色定义键前缀
+const RoleKeyPrefix = "role:"
+
+// Role errors | 角色错误
+var (
+	ErrRoleNotFound    = fmt.Errorf("role not found")
+	ErrRoleCycle       = fmt.Errorf("role inheritance cycle")
+	ErrInvalidRoleName = fmt.Errorf("role name cannot be empty or contain '*'")
+)
+
+// Role Role definition with granted permissions and inherited roles | 包含授予权限和继承角色的角色定义
+type Role struct {
+	Name        string   `json:"name"`                  // Role name | 角色名
+	Permissions []string `json:"permissions,omitempty"` // Granted permission patterns | 授予的权限模式
+	Inherits    []string `json:"inherits,omitempty"`    // Inherited role names | 继承的角色名
+	CreateTime  int64    `json:"createTime"`            // Creation time | 创建时间
+	UpdateTime  int64    `json:"updateTime"`            // Last update time | 最近更新时间
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (r *Role) MarshalBinary() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (r *Role) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, r)
+}
+
+// ============ Role Administration | 角色管理 ============
+
+// SaveRole Creates or replaces a role definition | 创建或替换角色定义
+// Returns ErrRoleCycle when the role would end up inheriting itself | 角色将继承自身时返回ErrRoleCycle
+func (m *Manager) SaveRole(role *Role) error {
+	if role == nil || !isValidRoleName(role.Name) {
+		return ErrInvalidRoleName
+	}
+
+	stored := &Role{
+		Name:        role.Name,
+		Permissions: utils.UniqueStrings(role.Permissions),
+		Inherits:    utils.UniqueStrings(role.Inherits),
+	}
+	for _, parent := range stored.Inherits {
+		if !isValidRoleName(parent) {
+			return ErrInvalidRoleName
+		}
+	}
+	if m.inheritsRole(stored.Inherits, stored.Name, map[string]bool{}) {
+		return fmt.Errorf("%w: %s", ErrRoleCycle, stored.Name)
+	}
+
+	now := time.Now().Unix()
+	stored.CreateTime = now
+	if existing, err := m.GetRole(role.Name); err == nil {
+		stored.CreateTime = existing.CreateTime
+	}
+	stored.UpdateTime = now
+
+	if err := m.storage.Set(m.getRoleKey(stored.Name), stored, 0); err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+	return m.InvalidateAll()
+}
+
+// GetRole Loads a role definition | 加载角色定义
+func (m *Manager) GetRole(name string) (*Role, error) {
+	data, err := m.storage.Get(m.getRoleKey(name))
+	if err != nil || data == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	role := &Role{}
+	if err := decodeRole(data, role); err != nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+// DeleteRole Deletes a role definition, roles inheriting it simply stop receiving its grants |
+// 删除角色定义，继承它的角色不再获得其授权
+func (m *Manager) DeleteRole(name string) error {
+	if err := m.storage.Delete(m.getRoleKey(name)); err != nil {
+		return err
+	}
+	return m.InvalidateAll()
+}
+
+// ListRoles Lists role definitions sorted by name | 按名称排序列出角色定义
+func (m *Manager) ListRoles() ([]*Role, error) {
+	keys, err := m.storage.Keys(m.getRoleKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]*Role, 0, len(keys))
+	for _, key := range keys {
+		role, err := m.GetRole(strings.TrimPrefix(key, m.getRoleKey("")))
+		if err != nil {
+			continue
+		}
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+// GrantRolePermissions Adds permissions to a role, creating it if missing | 为角色添加权限，角色不存在时创建
+func (m *Manager) GrantRolePermissions(name string, permissions ...string) error {
+	role, err := m.GetRole(name)
+	if err != nil {
+		role = &Role{Name: name}
+	}
+	role.Permissions = append(role.Permissions, permissions...)
+	return m.SaveRole(role)
+}
+
+// RevokeRolePermissions Removes permissions from a role | 从角色中移除权限
+func (m *Manager) RevokeRolePermissions(name string, permissions ...string) error {
+	role, err := m.GetRole(name)
+	if err != nil {
+		return err
+	}
+	role.Permissions = removeStrings(role.Permissions, permissions)
+	return m.SaveRole(role)
+}
+
+// SetRoleInherits Replaces the roles a role inherits, creating it if missing | 替换角色继承的角色，角色不存在时创建
+func (m *Manager) SetRoleInherits(name string, parents ...string) error {
+	role, err := m.GetRole(name)
+	if err != nil {
+		role = &Role{Name: name}
+	}
+	role.Inherits = parents
+	return m.SaveRole(role)
+}
+
+// GetRolePermissions Gets the permissions a role grants, including inherited ones | 获取角色授予的权限（包括继承的权限）
+func (m *Manager) GetRolePermissions(name string) []string {
+	return m.rolePermissions(m.expandRoles([]string{name}))
+}
+
+// ============ Role Resolution | 角色解析 ============
+
+// loadRoles Loads an account's roles expanded with inherited roles | 加载账号的角色并展开继承的角色
+func (m *Manager) loadRoles(loginID, loginType string) ([]string, error) {
+	roles, err := m.permProvider.GetRoleList(loginID, loginType)
+	if err != nil {
+		return nil, err
+	}
+	return m.expandRoles(roles), nil
+}
+
+// loadPermissions Loads an account's permissions plus those granted by its roles | 加载账号的权限及其角色授予的权限
+func (m *Manager) loadPermissions(loginID, loginType string) ([]string, error) {
+	permissions, err := m.permProvider.GetPermissionList(loginID, loginType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Role lookups may fail independently, direct permissions still apply | 角色查询可能单独失败，直接权限仍然有效
+	roles, err := m.loadRoles(loginID, loginType)
+	if err != nil || len(roles) == 0 {
+		return permissions, nil
+	}
+	return utils.UniqueStrings(append(append([]string{}, permissions...), m.rolePermissions(roles)...)), nil
+}
+
+// expandRoles Adds every inherited role, tolerating cycles and undefined roles | 加入所有继承的角色，容忍环和未定义的角色
+func (m *Manager) expandRoles(roles []string) []string {
+	seen := make(map[string]bool, len(roles))
+	result := make([]string, 0, len(roles))
+	queue := append([]string{}, roles...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+
+		if role, err := m.GetRole(name); err == nil {
+			queue = append(queue, role.Inherits...)
+		}
+	}
+	return result
+}
+
+// rolePermissions Collects permissions granted by already expanded roles | 收集已展开角色授予的权限
+func (m *Manager) rolePermissions(roles []string) []string {
+	var permissions []string
+	for _, name := range roles {
+		if role, err := m.GetRole(name); err == nil {
+			permissions = append(permissions, role.Permissions...)
+		}
+	}
+	return utils.UniqueStrings(permissions)
+}
+
+// inheritsRole Checks if any of the parents reaches target through stored definitions | 检查父角色是否通过已存储的定义到达目标角色
+func (m *Manager) inheritsRole(parents []string, target string, visited map[string]bool) bool {
+	for _, parent := range parents {
+		if parent == target {
+			return true
+		}
+		if visited[parent] {
+			continue
+		}
+		visited[parent] = true
+
+		if role, err := m.GetRole(parent); err == nil && m.inheritsRole(role.Inherits, target, visited) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRoleKey Gets storage key for a role definition | 获取角色定义的存储键
+func (m *Manager) getRoleKey(name string) string {
+	return m.prefix + RoleKeyPrefix + name
+}
+
+// isValidRoleName Checks a role name, '*' would break key listing | 检查角色名，'*'会破坏键列举
+func isValidRoleName(name string) bool {
+	return name != "" && !strings.Contains(name, "*")
+}
+
+// removeStrings Returns slice without the given values | 返回去除指定值后的切片
+func removeStrings(slice, values []string) []string {
+	result := make([]string, 0, len(slice))
+	for _, s := range slice {
+		if !utils.ContainsString(values, s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// decodeRole Decodes a role stored as a pointer or JSON | 解码以指针或JSON保存的角色
+func decodeRole(data any, role *Role) error {
+	var (
+		raw []byte
+		err error
+	)
+	if marshaler, ok := data.(encoding.BinaryMarshaler); ok {
+		raw, err = marshaler.MarshalBinary()
+	} else {
+		raw, err = utils.ToBytes(data)
+	}
+	if err != nil {
+		return err
+	}
+	return role.UnmarshalBinary(raw)
+}
@@ -0,0 +1,212 @@
+package manager
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/config"
+)
+
+// Multi-tenancy | 多租户
+//
+// Tenant(id) returns a Manager whose keys live under "<KeyPrefix>tenant:<id>:", so tokens, sessions,
+// permissions, roles and bans of loginID "42" in tenant A never meet those of "42" in tenant B.
+// Tenant managers are created on first use from the root configuration plus the overrides registered
+// with SetTenantConfig(), and share the root's storage, event manager, policies and renew pool.
+// Tenant(id)返回的Manager的键位于"<KeyPrefix>tenant:<id>:"之下，租户A与租户B中loginID为"42"的Token、Session、权限、
+// 角色和封禁互不相通。租户管理器在首次使用时根据根配置和SetTenantConfig()注册的覆盖项创建，并共享根管理器的存储、
+// 事件管理器、策略和续期池。
+
+// TenantKeyPrefix Tenant namespace key prefix | 租户命名空间键前缀
+const TenantKeyPrefix = "tenant:"
+
+// TenantResolver Resolves the tenant of a request, "" means no tenant | 解析请求所属租户，""表示无租户
+type TenantResolver func(ctx adapter.RequestContext) string
+
+// TenantConfigFunc Adjusts the configuration of a tenant | 调整租户配置
+type TenantConfigFunc func(cfg *config.Config)
+
+// TenantValidator Reports whether a tenant resolved from a request exists | 判断从请求解析出的租户是否存在
+type TenantValidator func(tenantID string) bool
+
+// DefaultMaxTenants Default number of cached tenant managers | 默认缓存的租户管理器数量
+const DefaultMaxTenants = 1024
+
+// HeaderTenantResolver Reads the tenant ID from a request header | 从请求头读取租户ID
+func HeaderTenantResolver(header string) TenantResolver {
+	return func(ctx adapter.RequestContext) string {
+		return strings.TrimSpace(ctx.GetHeader(header))
+	}
+}
+
+// SubdomainTenantResolver Uses the subdomain below baseDomain as tenant ID | 使用baseDomain下的子域名作为租户ID
+// acme.example.com with baseDomain "example.com" resolves to "acme" | baseDomain为"example.com"时acme.example.com解析为"acme"
+func SubdomainTenantResolver(baseDomain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(ctx adapter.RequestContext) string {
+		host := strings.ToLower(adapter.GetHost(ctx))
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		if sub == "" || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+}
+
+// ============ Tenant Managers | 租户管理器 ============
+
+// Tenant Gets the manager of a tenant, "" returns the root manager | 获取租户管理器，""返回根管理器
+func (m *Manager) Tenant(tenantID string) *Manager {
+	root := m.root()
+	if tenantID == "" {
+		return root
+	}
+
+	root.tenantMu.RLock()
+	tenant, ok := root.tenants[tenantID]
+	root.tenantMu.RUnlock()
+	if ok {
+		return tenant
+	}
+
+	root.tenantMu.Lock()
+	defer root.tenantMu.Unlock()
+	if tenant, ok = root.tenants[tenantID]; ok {
+		return tenant
+	}
+	// Evicted tenants are rebuilt from the root on next use, like after SetTenantConfig | 被淘汰的租户在下次使用时从根管理器重建，与SetTenantConfig之后相同
+	if root.maxTenants > 0 && len(root.tenants) >= root.maxTenants {
+		for id := range root.tenants {
+			delete(root.tenants, id)
+			break
+		}
+	}
+	tenant = root.newTenant(tenantID)
+	root.tenants[tenantID] = tenant
+	return tenant
+}
+
+// SetTenantConfig Registers configuration overrides for a tenant | 注册租户的配置覆盖项
+// A tenant manager created earlier is rebuilt on its next use | 已创建的租户管理器会在下次使用时重建
+func (m *Manager) SetTenantConfig(tenantID string, fn TenantConfigFunc) {
+	root := m.root()
+	root.tenantMu.Lock()
+	defer root.tenantMu.Unlock()
+	if fn == nil {
+		delete(root.tenantConfigs, tenantID)
+	} else {
+		root.tenantConfigs[tenantID] = fn
+	}
+	delete(root.tenants, tenantID)
+}
+
+// SetTenantResolver Sets how requests resolve their tenant | 设置请求解析租户的方式
+func (m *Manager) SetTenantResolver(resolver TenantResolver) {
+	m.root().tenantResolver = resolver
+}
+
+// GetTenantResolver Gets the tenant resolver | 获取租户解析器
+func (m *Manager) GetTenantResolver() TenantResolver {
+	return m.root().tenantResolver
+}
+
+// SetTenantValidator Sets which tenants requests may resolve to | 设置请求可以解析到的租户
+// Tenants with a SetTenantConfig entry are always accepted, nil accepts every tenant | 通过SetTenantConfig配置的租户始终接受，nil接受所有租户
+func (m *Manager) SetTenantValidator(validator TenantValidator) {
+	m.root().tenantAllowed = validator
+}
+
+// SetMaxTenants Sets how many tenant managers are cached, 0 means unlimited | 设置缓存的租户管理器数量，0表示不限制
+func (m *Manager) SetMaxTenants(max int) {
+	root := m.root()
+	root.tenantMu.Lock()
+	defer root.tenantMu.Unlock()
+	root.maxTenants = max
+}
+
+// ResolveTenant Gets the manager of the request's tenant | 获取请求所属租户的管理器
+// Returns the root manager when no resolver is set or no valid tenant is found, a tenant manager returns itself | 未设置解析器或未解析到有效租户时返回根管理器，租户管理器返回自身
+func (m *Manager) ResolveTenant(ctx adapter.RequestContext) *Manager {
+	if m.parent != nil {
+		return m
+	}
+	resolver := m.tenantResolver
+	if resolver == nil || ctx == nil {
+		return m.root()
+	}
+	tenantID := resolver(ctx)
+	if tenantID == "" || !m.acceptsTenant(tenantID) {
+		return m.root()
+	}
+	return m.Tenant(tenantID)
+}
+
+// acceptsTenant Checks a tenant ID taken from a request | 检查从请求中获取的租户ID
+func (m *Manager) acceptsTenant(tenantID string) bool {
+	m.tenantMu.RLock()
+	_, configured := m.tenantConfigs[tenantID]
+	m.tenantMu.RUnlock()
+	return configured || m.tenantAllowed == nil || m.tenantAllowed(tenantID)
+}
+
+// GetTenantID Gets the tenant ID, "" for the root manager | 获取租户ID，根管理器为""
+func (m *Manager) GetTenantID() string {
+	return m.tenantID
+}
+
+// IsTenant Checks if this is a tenant manager | 检查是否为租户管理器
+func (m *Manager) IsTenant() bool {
+	return m.parent != nil
+}
+
+// GetRootManager Gets the root manager | 获取根管理器
+func (m *Manager) GetRootManager() *Manager {
+	return m.root()
+}
+
+// root Gets the manager owning tenants and policies | 获取持有租户和策略的管理器
+func (m *Manager) root() *Manager {
+	if m.parent != nil {
+		return m.parent
+	}
+	return m
+}
+
+// newTenant Creates a tenant manager, caller holds tenantMu | 创建租户管理器，调用方需持有tenantMu
+func (m *Manager) newTenant(tenantID string) *Manager {
+	cfg := m.config.Clone()
+	if fn, ok := m.tenantConfigs[tenantID]; ok {
+		fn(cfg)
+	}
+	// The namespace and shared resources are not overridable | 命名空间和共享资源不可覆盖
+	cfg.KeyPrefix = basePrefix(m.config) + TenantKeyPrefix + url.QueryEscape(tenantID) + PermissionSeparator
+	cfg.LoginType = m.loginType
+	cfg.RenewPoolConfig = nil
+
+	tenant := NewManager(m.storage, cfg)
+	tenant.parent = m
+	tenant.tenantID = tenantID
+	tenant.scopeMapper = m.scopeMapper
+	// Clients and settings are shared, codes and tokens stay in the tenant | 共享客户端和设置，授权码和令牌留在租户内
+	tenant.oauth2Server = m.oauth2Server.Namespace(tenant.prefix)
+	tenant.renewPool = m.renewPool
+	if _, ok := m.permProvider.(*SessionPermissionProvider); !ok {
+		tenant.permProvider = m.permProvider
+	}
+
+	// Listeners registered on the root see every tenant | 注册在根管理器上的监听器可接收所有租户的事件
+	tenant.eventManager = m.eventManager
+	tenant.refreshManager.SetEventManager(m.eventManager)
+	if cfg.PermissionCacheTimeout > 0 {
+		m.registerPermissionCacheListener()
+	}
+	return tenant
+}
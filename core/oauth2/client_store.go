@@ -0,0 +1,167 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/click33/sa-token-go/core/adapter"
+)
+
+// ClientKeySuffix Client key suffix after prefix | 客户端键后缀
+const ClientKeySuffix = "oauth2:client:"
+
+// ClientStore Persists OAuth2 clients | OAuth2客户端存储
+type ClientStore interface {
+	// GetClient returns ErrClientNotFound for unknown clients | 客户端不存在时返回ErrClientNotFound
+	GetClient(clientID string) (*Client, error)
+	SaveClient(client *Client) error
+	DeleteClient(clientID string) error
+	ListClients() ([]*Client, error)
+}
+
+// ============ Memory Client Store | 内存客户端存储 ============
+
+// MemoryClientStore In-memory client store, clients are lost on restart | 内存客户端存储，重启后丢失
+type MemoryClientStore struct {
+	clients map[string]*Client
+	mu      sync.RWMutex
+}
+
+// NewMemoryClientStore Creates an in-memory client store | 创建内存客户端存储
+func NewMemoryClientStore() *MemoryClientStore {
+	return &MemoryClientStore{
+		clients: make(map[string]*Client),
+	}
+}
+
+// GetClient Gets a copy of the client | 获取客户端副本
+func (s *MemoryClientStore) GetClient(clientID string) (*Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	client, exists := s.clients[clientID]
+	if !exists {
+		return nil, ErrClientNotFound
+	}
+	return client.clone(), nil
+}
+
+// SaveClient Saves a copy of the client | 保存客户端副本
+func (s *MemoryClientStore) SaveClient(client *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients[client.ClientID] = client.clone()
+	return nil
+}
+
+// DeleteClient Deletes a client | 删除客户端
+func (s *MemoryClientStore) DeleteClient(clientID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.clients, clientID)
+	return nil
+}
+
+// ListClients Lists clients sorted by ID | 按ID排序列出客户端
+func (s *MemoryClientStore) ListClients() ([]*Client, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(s.clients))
+	for _, client := range s.clients {
+		clients = append(clients, client.clone())
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientID < clients[j].ClientID })
+	return clients, nil
+}
+
+// ============ Storage Client Store | 存储客户端存储 ============
+
+// StorageClientStore Client store backed by adapter.Storage, shared by every node | 基于adapter.Storage的客户端存储，所有节点共享
+type StorageClientStore struct {
+	storage   adapter.Storage
+	keyPrefix string
+}
+
+// NewStorageClientStore Creates a client store on top of storage | 基于存储创建客户端存储
+// prefix: key prefix, same as the OAuth2 server (e.g., "satoken:") | 键前缀，与OAuth2服务器相同（如："satoken:"）
+func NewStorageClientStore(storage adapter.Storage, prefix string) *StorageClientStore {
+	return &StorageClientStore{
+		storage:   storage,
+		keyPrefix: prefix,
+	}
+}
+
+// GetClient Loads a client | 加载客户端
+func (s *StorageClientStore) GetClient(clientID string) (*Client, error) {
+	data, err := s.storage.Get(s.getClientKey(clientID))
+	if err != nil || data == nil {
+		return nil, ErrClientNotFound
+	}
+
+	client := &Client{}
+	if err := decodeRecord(data, client); err != nil {
+		return nil, ErrInvalidClientData
+	}
+	return client, nil
+}
+
+// SaveClient Saves a client without expiration | 永久保存客户端
+func (s *StorageClientStore) SaveClient(client *Client) error {
+	return s.storage.Set(s.getClientKey(client.ClientID), client.clone(), 0)
+}
+
+// DeleteClient Deletes a client | 删除客户端
+func (s *StorageClientStore) DeleteClient(clientID string) error {
+	return s.storage.Delete(s.getClientKey(clientID))
+}
+
+// ListClients Lists clients sorted by ID | 按ID排序列出客户端
+func (s *StorageClientStore) ListClients() ([]*Client, error) {
+	keys, err := s.storage.Keys(s.getClientKey("*"))
+	if err != nil {
+		return nil, err
+	}
+
+	clients := make([]*Client, 0, len(keys))
+	for _, key := range keys {
+		client, err := s.GetClient(strings.TrimPrefix(key, s.getClientKey("")))
+		if err != nil {
+			continue
+		}
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ClientID < clients[j].ClientID })
+	return clients, nil
+}
+
+// getClientKey Gets storage key for client | 获取客户端的存储键
+func (s *StorageClientStore) getClientKey(clientID string) string {
+	return s.keyPrefix + ClientKeySuffix + clientID
+}
+
+// ============ Client Serialization | 客户端序列化 ============
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (c *Client) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (c *Client) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+// clone Deep copies the client | 深拷贝客户端
+func (c *Client) clone() *Client {
+	copied := *c
+	copied.RedirectURIs = append([]string(nil), c.RedirectURIs...)
+	copied.GrantTypes = append([]GrantType(nil), c.GrantTypes...)
+	copied.Scopes = append([]string(nil), c.Scopes...)
+	copied.Secrets = append([]ClientSecret(nil), c.Secrets...)
+	return &copied
+}
@@ -0,0 +1,248 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/utils"
+)
+
+// Consent management | 授权同意管理
+//
+// GrantConsent() remembers the scopes a user approved for a client, called by the consent page and
+// on device approval. The authorization endpoint skips the consent page when HasConsent() already
+// covers the request, and RevokeConsent() removes an application together with its outstanding tokens.
+// GrantConsent()记录用户为客户端批准的权限范围，由同意页及设备授权批准时调用。授权端点在HasConsent()已覆盖请求时跳过同意页，
+// RevokeConsent()移除应用授权并撤销其未过期的令牌。
+
+// Key suffixes after prefix | 前缀后的键后缀
+const (
+	ConsentKeySuffix = "oauth2:consent:" // Consent key suffix | 授权同意键后缀
+	GrantKeySuffix   = "oauth2:grant:"   // Per-token index of user/client grants | 用户/客户端授权的令牌索引键后缀
+)
+
+// Consent errors | 授权同意错误
+var (
+	ErrConsentNotFound = fmt.Errorf("consent not found")
+)
+
+// Consent Scopes a user approved for a client | 用户为客户端批准的权限范围
+type Consent struct {
+	UserID     string   `json:"userId"`     // User ID | 用户ID
+	ClientID   string   `json:"clientId"`   // Client ID | 客户端ID
+	Scopes     []string `json:"scopes"`     // Approved scopes | 已批准的权限范围
+	CreateTime int64    `json:"createTime"` // First approval time | 首次批准时间
+	UpdateTime int64    `json:"updateTime"` // Last approval time | 最近批准时间
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (c *Consent) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (c *Consent) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
+}
+
+// Covers Checks if the consent covers every scope | 检查授权同意是否覆盖所有权限范围
+func (c *Consent) Covers(scopes []string) bool {
+	for _, scope := range scopes {
+		if !scopeCovered(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// ConsentStore Persists consents | 授权同意存储
+type ConsentStore interface {
+	// GetConsent returns ErrConsentNotFound when the user never approved the client | 用户从未批准该客户端时返回ErrConsentNotFound
+	GetConsent(userID, clientID string) (*Consent, error)
+	SaveConsent(consent *Consent) error
+	DeleteConsent(userID, clientID string) error
+	ListConsents(userID string) ([]*Consent, error)
+}
+
+// ============ Storage Consent Store | 存储授权同意存储 ============
+
+// StorageConsentStore Consent store backed by adapter.Storage | 基于adapter.Storage的授权同意存储
+type StorageConsentStore struct {
+	storage   adapter.Storage
+	keyPrefix string
+}
+
+// NewStorageConsentStore Creates a consent store on top of storage | 基于存储创建授权同意存储
+func NewStorageConsentStore(storage adapter.Storage, prefix string) *StorageConsentStore {
+	return &StorageConsentStore{
+		storage:   storage,
+		keyPrefix: prefix,
+	}
+}
+
+// GetConsent Loads a consent | 加载授权同意
+func (s *StorageConsentStore) GetConsent(userID, clientID string) (*Consent, error) {
+	data, err := s.storage.Get(s.getConsentKey(userID, clientID))
+	if err != nil || data == nil {
+		return nil, ErrConsentNotFound
+	}
+
+	consent := &Consent{}
+	if err := decodeRecord(data, consent); err != nil {
+		return nil, ErrConsentNotFound
+	}
+	return consent, nil
+}
+
+// SaveConsent Saves a consent without expiration | 永久保存授权同意
+func (s *StorageConsentStore) SaveConsent(consent *Consent) error {
+	return s.storage.Set(s.getConsentKey(consent.UserID, consent.ClientID), consent, 0)
+}
+
+// DeleteConsent Deletes a consent | 删除授权同意
+func (s *StorageConsentStore) DeleteConsent(userID, clientID string) error {
+	return s.storage.Delete(s.getConsentKey(userID, clientID))
+}
+
+// ListConsents Lists a user's consents sorted by client ID | 按客户端ID排序列出用户的授权同意
+func (s *StorageConsentStore) ListConsents(userID string) ([]*Consent, error) {
+	keys, err := s.storage.Keys(s.getConsentKey(userID, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	consents := make([]*Consent, 0, len(keys))
+	for _, key := range keys {
+		consent, err := s.GetConsent(userID, strings.TrimPrefix(key, s.getConsentKey(userID, "")))
+		if err != nil || consent.UserID != userID {
+			continue
+		}
+		consents = append(consents, consent)
+	}
+	sort.Slice(consents, func(i, j int) bool { return consents[i].ClientID < consents[j].ClientID })
+	return consents, nil
+}
+
+// getConsentKey Gets storage key for a consent | 获取授权同意的存储键
+func (s *StorageConsentStore) getConsentKey(userID, clientID string) string {
+	return s.keyPrefix + ConsentKeySuffix + lengthPrefixed(userID) + clientID
+}
+
+// ============ Consent Operations | 授权同意操作 ============
+
+// SetConsentStore Sets where consents are persisted | 设置授权同意的持久化存储
+func (s *OAuth2Server) SetConsentStore(store ConsentStore) {
+	s.consents = store
+}
+
+// GetConsent Gets the scopes a user approved for a client | 获取用户为客户端批准的权限范围
+func (s *OAuth2Server) GetConsent(userID, clientID string) (*Consent, error) {
+	return s.consents.GetConsent(userID, clientID)
+}
+
+// HasConsent Checks if the user already approved the requested scopes | 检查用户是否已批准请求的权限范围
+// Empty scopes mean the client's default scopes | 权限范围为空时表示客户端的默认权限范围
+func (s *OAuth2Server) HasConsent(userID, clientID string, scopes []string) bool {
+	client, err := s.GetClient(clientID)
+	if err != nil {
+		return false
+	}
+	requested, err := validateScopes(client, scopes)
+	if err != nil {
+		return false
+	}
+
+	consent, err := s.consents.GetConsent(userID, clientID)
+	if err != nil {
+		return false
+	}
+	return consent.Covers(requested)
+}
+
+// GrantConsent Records that a user approved scopes for a client, merged with earlier approvals | 记录用户为客户端批准的权限范围，与之前的批准合并
+func (s *OAuth2Server) GrantConsent(userID, clientID string, scopes []string) (*Consent, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID cannot be empty")
+	}
+
+	client, err := s.GetClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := validateScopes(client, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	consent, err := s.consents.GetConsent(userID, clientID)
+	if err != nil {
+		consent = &Consent{UserID: userID, ClientID: clientID, CreateTime: now}
+	}
+	consent.Scopes = utils.UniqueStrings(append(consent.Scopes, granted...))
+	consent.UpdateTime = now
+
+	if err := s.consents.SaveConsent(consent); err != nil {
+		return nil, fmt.Errorf("failed to store consent: %w", err)
+	}
+	return consent, nil
+}
+
+// ListConsents Lists the applications a user authorized | 列出用户已授权的应用
+func (s *OAuth2Server) ListConsents(userID string) ([]*Consent, error) {
+	return s.consents.ListConsents(userID)
+}
+
+// RevokeConsent Removes a user's consent for a client and revokes its access and refresh tokens | 移除用户对客户端的授权同意，并撤销其访问令牌和刷新令牌
+func (s *OAuth2Server) RevokeConsent(userID, clientID string) error {
+	if err := s.consents.DeleteConsent(userID, clientID); err != nil {
+		return err
+	}
+	return s.RevokeGrantTokens(userID, clientID)
+}
+
+// RevokeGrantTokens Revokes every access and refresh token issued to a client for a user | 撤销为某用户签发给客户端的所有访问令牌和刷新令牌
+func (s *OAuth2Server) RevokeGrantTokens(userID, clientID string) error {
+	keys, err := s.storage.Keys(s.getGrantKey(userID, clientID, "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		accessToken := strings.TrimPrefix(key, s.getGrantKey(userID, clientID, ""))
+		toDelete := []string{key, s.getTokenKey(accessToken)}
+		if data, err := s.storage.Get(key); err == nil {
+			if refreshToken, ok := data.(string); ok && refreshToken != "" {
+				toDelete = append(toDelete, s.getRefreshKey(refreshToken))
+			}
+		}
+		if err := s.storage.Delete(toDelete...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexGrantToken Indexes a user token by user and client, the value is its refresh token | 按用户和客户端索引用户令牌，值为其刷新令牌
+func (s *OAuth2Server) indexGrantToken(token *AccessToken, refreshTTL time.Duration) error {
+	ttl := s.tokenExpiration
+	if token.RefreshToken != "" {
+		ttl = refreshTTL
+	}
+	return s.storage.Set(s.getGrantKey(token.UserID, token.ClientID, token.Token), token.RefreshToken, ttl)
+}
+
+// getGrantKey Gets the grant index key of an access token | 获取访问令牌的授权索引键
+func (s *OAuth2Server) getGrantKey(userID, clientID, accessToken string) string {
+	return s.keyPrefix + GrantKeySuffix + lengthPrefixed(userID) + lengthPrefixed(clientID) + accessToken
+}
+
+// lengthPrefixed Encodes an ID as "<len>:<id>:" so IDs containing ':' cannot collide | 将ID编码为"<长度>:<ID>:"，避免含':'的ID发生冲突
+func lengthPrefixed(id string) string {
+	return strconv.Itoa(len(id)) + ":" + id + ":"
+}
@@ -0,0 +1,357 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Device Authorization Grant (RFC 8628) | 设备授权模式
+//
+// Flow | 流程:
+// 1. CreateDeviceAuthorization() - Device gets device_code and user_code | 设备获取device_code和user_code
+// 2. ApproveDeviceAuthorization() - Logged-in user enters user_code on another screen | 已登录用户在其他设备输入user_code
+// 3. PollDeviceToken() - Device polls every Interval seconds until approved | 设备按Interval秒轮询直至批准
+//
+// Polling answers ErrAuthorizationPending, ErrSlowDown (interval grows by 5s),
+// ErrDeviceAccessDenied or ErrDeviceCodeExpired until tokens are issued.
+// 轮询在签发令牌前返回ErrAuthorizationPending、ErrSlowDown（间隔增加5秒）、ErrDeviceAccessDenied或ErrDeviceCodeExpired。
+
+// Device grant constants | 设备授权常量
+const (
+	DefaultDeviceCodeExpiration = 10 * time.Minute // Device code expiration | 设备码过期时间
+	DefaultDevicePollInterval   = 5 * time.Second  // Minimum polling interval | 最小轮询间隔
+
+	DeviceCodeLength = 32 // Device code byte length | 设备码字节长度
+	UserCodeLength   = 8  // User code characters, shown as XXXX-XXXX | 用户码字符数，显示为XXXX-XXXX
+
+	DeviceCodeKeySuffix = "oauth2:device:"   // Device code key suffix | 设备码键后缀
+	UserCodeKeySuffix   = "oauth2:usercode:" // User code index key suffix | 用户码索引键后缀
+
+	slowDownStep = 5 // Seconds added to the interval on slow_down (RFC 8628 3.5) | slow_down时增加的间隔秒数
+
+	// userCodeAlphabet Consonants only, avoids look-alike characters and words (RFC 8628 6.1) | 仅使用辅音字母，避免易混淆字符和单词
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+)
+
+// Device grant errors | 设备授权错误
+var (
+	ErrInvalidDeviceCode    = fmt.Errorf("invalid device code")
+	ErrInvalidUserCode      = fmt.Errorf("invalid user code")
+	ErrAuthorizationPending = fmt.Errorf("authorization pending")
+	ErrSlowDown             = fmt.Errorf("polling too fast, slow down")
+	ErrDeviceAccessDenied   = fmt.Errorf("device authorization denied")
+	ErrDeviceCodeExpired    = fmt.Errorf("device code expired")
+)
+
+// DeviceStatus Status of a device authorization | 设备授权状态
+type DeviceStatus string
+
+const (
+	DeviceStatusPending  DeviceStatus = "pending"  // Waiting for the user | 等待用户处理
+	DeviceStatusApproved DeviceStatus = "approved" // Approved, next poll gets tokens | 已批准，下次轮询获取令牌
+	DeviceStatusDenied   DeviceStatus = "denied"   // Denied by the user | 用户已拒绝
+)
+
+// DeviceAuthorization Device authorization information | 设备授权信息
+type DeviceAuthorization struct {
+	DeviceCode   string       `json:"deviceCode"`             // Device code, secret to the device | 设备码，仅设备持有
+	UserCode     string       `json:"userCode"`               // User code entered by the user | 用户输入的用户码
+	ClientID     string       `json:"clientId"`               // Client ID | 客户端ID
+	Scopes       []string     `json:"scopes"`                 // Requested scopes | 请求的权限范围
+	Status       DeviceStatus `json:"status"`                 // Authorization status | 授权状态
+	UserID       string       `json:"userId,omitempty"`       // User who approved | 批准的用户
+	AuthTime     int64        `json:"authTime,omitempty"`     // Time the user authenticated | 用户认证时间
+	CreateTime   int64        `json:"createTime"`             // Creation time | 创建时间
+	ExpiresIn    int64        `json:"expiresIn"`              // Expiration time in seconds | 过期时间（秒）
+	Interval     int64        `json:"interval"`               // Minimum polling interval in seconds | 最小轮询间隔（秒）
+	LastPollTime int64        `json:"lastPollTime,omitempty"` // Last poll in milliseconds | 最近轮询时间（毫秒）
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (d *DeviceAuthorization) MarshalBinary() ([]byte, error) {
+	return json.Marshal(d)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (d *DeviceAuthorization) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, d)
+}
+
+// expired Checks if the device code has expired | 检查设备码是否已过期
+func (d *DeviceAuthorization) expired(now time.Time) bool {
+	return now.Unix() > d.CreateTime+d.ExpiresIn
+}
+
+// SetDeviceCodeExpiration Sets how long device and user codes stay valid | 设置设备码和用户码的有效期
+func (s *OAuth2Server) SetDeviceCodeExpiration(expiration time.Duration) {
+	s.deviceExpiration = expiration
+}
+
+// SetDevicePollInterval Sets the minimum polling interval, rounded up to seconds | 设置最小轮询间隔，向上取整到秒
+func (s *OAuth2Server) SetDevicePollInterval(interval time.Duration) {
+	s.devicePollInterval = interval
+}
+
+// CreateDeviceAuthorization Issues a device_code/user_code pair (RFC 8628 3.2) | 签发device_code/user_code对
+// Public clients are identified by client ID only | 公开客户端仅通过客户端ID识别
+func (s *OAuth2Server) CreateDeviceAuthorization(clientID, clientSecret string, scopes []string) (*DeviceAuthorization, error) {
+	client, err := s.authenticateClient(clientID, clientSecret, GrantTypeDeviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	grantedScopes, err := validateScopes(client, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	codeBytes := make([]byte, DeviceCodeLength)
+	if _, err := rand.Read(codeBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate device code: %w", err)
+	}
+
+	// Retry on the unlikely user code collision | 用户码冲突时重试
+	var userCode string
+	for i := 0; i < 3 && userCode == ""; i++ {
+		candidate, err := generateUserCode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate user code: %w", err)
+		}
+		if !s.storage.Exists(s.getUserCodeKey(candidate)) {
+			userCode = candidate
+		}
+	}
+	if userCode == "" {
+		return nil, fmt.Errorf("failed to generate unique user code")
+	}
+
+	interval := int64((s.devicePollInterval + time.Second - 1) / time.Second)
+	device := &DeviceAuthorization{
+		DeviceCode: hex.EncodeToString(codeBytes),
+		UserCode:   userCode,
+		ClientID:   clientID,
+		Scopes:     grantedScopes,
+		Status:     DeviceStatusPending,
+		CreateTime: time.Now().Unix(),
+		ExpiresIn:  int64(s.deviceExpiration.Seconds()),
+		Interval:   interval,
+	}
+
+	if err := s.saveDeviceAuthorization(device); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Set(s.getUserCodeKey(userCode), device.DeviceCode, s.deviceExpiration); err != nil {
+		return nil, fmt.Errorf("failed to store user code: %w", err)
+	}
+	return device, nil
+}
+
+// GetDeviceAuthorization Gets the pending authorization of a user code, for the verification page | 获取用户码对应的待处理授权，供验证页展示
+// User codes are case-insensitive and may contain separators | 用户码不区分大小写，可包含分隔符
+func (s *OAuth2Server) GetDeviceAuthorization(userCode string) (*DeviceAuthorization, error) {
+	data, err := s.storage.Get(s.getUserCodeKey(normalizeUserCode(userCode)))
+	if err != nil || data == nil {
+		return nil, ErrInvalidUserCode
+	}
+	deviceCode, ok := data.(string)
+	if !ok {
+		return nil, ErrInvalidUserCode
+	}
+
+	device, err := s.loadDeviceAuthorization(deviceCode)
+	if err != nil || device.Status != DeviceStatusPending || device.expired(time.Now()) {
+		return nil, ErrInvalidUserCode
+	}
+	return device, nil
+}
+
+// ApproveDeviceAuthorization Binds a user code to the logged-in user | 将用户码绑定到已登录用户
+// authTime is when the user authenticated, now when 0 | authTime为用户认证时间，为0时取当前时间
+func (s *OAuth2Server) ApproveDeviceAuthorization(userCode, userID string, authTime int64) (*DeviceAuthorization, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID cannot be empty")
+	}
+
+	device, err := s.GetDeviceAuthorization(userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if authTime <= 0 {
+		authTime = time.Now().Unix()
+	}
+	device.Status = DeviceStatusApproved
+	device.UserID = userID
+	device.AuthTime = authTime
+
+	if err := s.finishDeviceVerification(device); err != nil {
+		return nil, err
+	}
+
+	// Remember what the user approved | 记录用户批准的内容
+	if _, err := s.GrantConsent(userID, device.ClientID, device.Scopes); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// DenyDeviceAuthorization Rejects a user code, the device gets access_denied | 拒绝用户码，设备将收到access_denied
+func (s *OAuth2Server) DenyDeviceAuthorization(userCode string) (*DeviceAuthorization, error) {
+	device, err := s.GetDeviceAuthorization(userCode)
+	if err != nil {
+		return nil, err
+	}
+
+	device.Status = DeviceStatusDenied
+	if err := s.finishDeviceVerification(device); err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// PollDeviceToken Exchanges an approved device code for tokens (RFC 8628 3.4) | 用已批准的设备码换取令牌
+func (s *OAuth2Server) PollDeviceToken(deviceCode, clientID, clientSecret string) (*AccessToken, error) {
+	client, err := s.authenticateClient(clientID, clientSecret, GrantTypeDeviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.loadDeviceAuthorization(deviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if device.ClientID != clientID {
+		return nil, ErrClientMismatch
+	}
+
+	now := time.Now()
+	if device.expired(now) {
+		return nil, ErrDeviceCodeExpired
+	}
+
+	// Polling faster than the interval widens it (RFC 8628 3.5) | 轮询快于间隔时扩大间隔
+	lastPoll := device.LastPollTime
+	device.LastPollTime = now.UnixMilli()
+	if lastPoll > 0 && device.LastPollTime-lastPoll < device.Interval*1000 {
+		device.Interval += slowDownStep
+		if err := s.saveDeviceAuthorization(device); err != nil {
+			return nil, err
+		}
+		return nil, ErrSlowDown
+	}
+
+	switch device.Status {
+	case DeviceStatusApproved:
+		// Device codes are single-use | 设备码只能使用一次
+		if err := s.storage.Delete(s.getDeviceCodeKey(deviceCode)); err != nil {
+			return nil, err
+		}
+		return s.generateAccessToken(&tokenGrant{
+			userID:      device.UserID,
+			clientID:    device.ClientID,
+			scopes:      device.Scopes,
+			withRefresh: client.AllowsGrantType(GrantTypeRefreshToken),
+			authTime:    device.AuthTime,
+		})
+	case DeviceStatusDenied:
+		s.storage.Delete(s.getDeviceCodeKey(deviceCode))
+		return nil, ErrDeviceAccessDenied
+	default:
+		if err := s.saveDeviceAuthorization(device); err != nil {
+			return nil, err
+		}
+		return nil, ErrAuthorizationPending
+	}
+}
+
+// finishDeviceVerification Saves the user's decision and retires the user code | 保存用户的决定并作废用户码
+func (s *OAuth2Server) finishDeviceVerification(device *DeviceAuthorization) error {
+	if err := s.saveDeviceAuthorization(device); err != nil {
+		return err
+	}
+	return s.storage.Delete(s.getUserCodeKey(device.UserCode))
+}
+
+// saveDeviceAuthorization Stores a device authorization
+// Kept for another expiration window so late polls get ErrDeviceCodeExpired instead of ErrInvalidDeviceCode
+// 存储设备授权，额外保留一个有效期，使过期后的轮询得到ErrDeviceCodeExpired而非ErrInvalidDeviceCode
+func (s *OAuth2Server) saveDeviceAuthorization(device *DeviceAuthorization) error {
+	ttl := time.Until(time.Unix(device.CreateTime+2*device.ExpiresIn, 0))
+	if ttl <= 0 {
+		return ErrDeviceCodeExpired
+	}
+	if err := s.storage.Set(s.getDeviceCodeKey(device.DeviceCode), device, ttl); err != nil {
+		return fmt.Errorf("failed to store device authorization: %w", err)
+	}
+	return nil
+}
+
+// loadDeviceAuthorization Loads a device authorization by device code | 根据设备码加载设备授权
+func (s *OAuth2Server) loadDeviceAuthorization(deviceCode string) (*DeviceAuthorization, error) {
+	if deviceCode == "" {
+		return nil, ErrInvalidDeviceCode
+	}
+
+	data, err := s.storage.Get(s.getDeviceCodeKey(deviceCode))
+	if err != nil || data == nil {
+		return nil, ErrInvalidDeviceCode
+	}
+
+	device := &DeviceAuthorization{}
+	if err := decodeRecord(data, device); err != nil {
+		return nil, ErrInvalidTokenData
+	}
+	return device, nil
+}
+
+// getDeviceCodeKey Gets storage key for a device code | 获取设备码的存储键
+func (s *OAuth2Server) getDeviceCodeKey(deviceCode string) string {
+	return s.keyPrefix + DeviceCodeKeySuffix + deviceCode
+}
+
+// getUserCodeKey Gets storage key for a normalized user code | 获取规范化用户码的存储键
+func (s *OAuth2Server) getUserCodeKey(userCode string) string {
+	return s.keyPrefix + UserCodeKeySuffix + userCode
+}
+
+// generateUserCode Generates a user code without modulo bias | 生成无取模偏差的用户码
+func generateUserCode() (string, error) {
+	limit := byte(256 - 256%len(userCodeAlphabet))
+	code := make([]byte, 0, UserCodeLength)
+	buf := make([]byte, UserCodeLength*2)
+	for len(code) < UserCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(code) < UserCodeLength {
+				code = append(code, userCodeAlphabet[int(b)%len(userCodeAlphabet)])
+			}
+		}
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode Uppercases a user code and drops separators | 将用户码转为大写并去除分隔符
+func normalizeUserCode(userCode string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(userCode))
+}
+
+// FormatUserCode Formats a user code for display, e.g. WDJB-MJHT | 格式化用户码用于展示，如WDJB-MJHT
+func FormatUserCode(userCode string) string {
+	userCode = normalizeUserCode(userCode)
+	if len(userCode) <= 4 {
+		return userCode
+	}
+	return userCode[:len(userCode)/2] + "-" + userCode[len(userCode)/2:]
+}
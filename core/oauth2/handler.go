@@ -0,0 +1,796 @@
+package oauth2
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/click33/sa-token-go/core/adapter"
+)
+
+// OAuth2 HTTP endpoints built on adapter.RequestContext
+// 基于adapter.RequestContext的OAuth2 HTTP端点
+//
+// Endpoints | 端点:
+//   GET  /oauth2/authorize  - Authorization endpoint (RFC 6749 4.1.1) | 授权端点
+//   POST /oauth2/token      - Token endpoint (RFC 6749 3.2) | 令牌端点
+//   POST /oauth2/revoke     - Token revocation (RFC 7009) | 令牌撤销
+//   POST /oauth2/introspect - Token introspection (RFC 7662) | 令牌内省
+//   POST /oauth2/register   - Dynamic client registration (RFC 7591), mount explicitly | 动态客户端注册，需显式挂载
+//   POST /oauth2/device_authorization - Device authorization (RFC 8628 3.1) | 设备授权
+//   GET  /oauth2/device     - Show a user code to the logged-in user, POST from a trusted origin approves or denies it | 向已登录用户展示用户码，来自可信来源的POST批准或拒绝
+//
+// Handlers only compute a Response, integrations write it with their framework.
+// 处理器只计算Response，由各框架集成负责写出。
+
+// Endpoint paths | 端点路径
+const (
+	AuthorizePath  = "/oauth2/authorize"
+	TokenPath      = "/oauth2/token"
+	RevokePath     = "/oauth2/revoke"
+	IntrospectPath = "/oauth2/introspect"
+	RegisterPath   = "/oauth2/register"
+
+	DeviceAuthorizationPath = "/oauth2/device_authorization"
+	DeviceVerificationPath  = "/oauth2/device"
+)
+
+// Error codes (RFC 6749 5.2, 4.1.2.1) | 错误码
+const (
+	ErrorInvalidRequest          = "invalid_request"
+	ErrorInvalidClient           = "invalid_client"
+	ErrorInvalidGrant            = "invalid_grant"
+	ErrorUnauthorizedClient      = "unauthorized_client"
+	ErrorUnsupportedGrantType    = "unsupported_grant_type"
+	ErrorUnsupportedResponseType = "unsupported_response_type"
+	ErrorInvalidScope            = "invalid_scope"
+	ErrorAccessDenied            = "access_denied"
+	ErrorServerError             = "server_error"
+	ErrorInvalidToken            = "invalid_token"
+	ErrorInvalidRedirectURI      = "invalid_redirect_uri"
+	ErrorInvalidClientMetadata   = "invalid_client_metadata"
+	ErrorConsentRequired         = "consent_required" // OpenID Connect Core 3.1.2.6
+
+	ErrorAuthorizationPending = "authorization_pending" // RFC 8628 3.5
+	ErrorSlowDown             = "slow_down"             // RFC 8628 3.5
+	ErrorExpiredToken         = "expired_token"         // RFC 8628 3.5
+)
+
+// Token type hints (RFC 7009 2.1) | 令牌类型提示
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// UserResolver Resolves the logged-in resource owner of a request | 解析请求中已登录的资源所有者
+type UserResolver func(ctx adapter.RequestContext) (userID string, err error)
+
+// AuthTimeResolver Resolves when the resource owner authenticated, 0 when unknown | 解析资源所有者的认证时间，未知时返回0
+type AuthTimeResolver func(ctx adapter.RequestContext, userID string) int64
+
+// ServerResolver Resolves the OAuth2 server of a request, nil for the default one | 解析请求使用的OAuth2服务器，nil表示默认服务器
+type ServerResolver func(ctx adapter.RequestContext) *OAuth2Server
+
+// Response HTTP response computed by a Handler | Handler计算出的HTTP响应
+type Response struct {
+	Status int               // HTTP status | HTTP状态码
+	Header map[string]string // Response headers | 响应头
+	Body   any               // JSON body, empty when nil | JSON响应体，为nil时无响应体
+}
+
+// ErrorResponse OAuth2 error body (RFC 6749 5.2) | OAuth2错误响应体
+type ErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenResponse Successful token response (RFC 6749 5.1) | 令牌成功响应
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	IDToken      string `json:"id_token,omitempty"` // OIDC ID token for the openid scope | openid权限范围的OIDC ID令牌
+}
+
+// IntrospectionResponse Token introspection response (RFC 7662 2.2) | 令牌内省响应
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+}
+
+// DeviceAuthorizationResponse Device authorization response (RFC 8628 3.2) | 设备授权响应
+type DeviceAuthorizationResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceVerificationResponse Device authorization shown to or decided by the user | 向用户展示或由用户决定的设备授权
+type DeviceVerificationResponse struct {
+	UserCode   string       `json:"user_code"`
+	ClientID   string       `json:"client_id"`
+	ClientName string       `json:"client_name,omitempty"`
+	Scope      string       `json:"scope,omitempty"`
+	Status     DeviceStatus `json:"status"`
+}
+
+// Handler OAuth2 endpoint handlers | OAuth2端点处理器
+type Handler struct {
+	server        *OAuth2Server
+	resolveServer ServerResolver
+	resolveUser   UserResolver
+	resolveAuth   AuthTimeResolver
+	loginURL      string // Login page for anonymous authorize requests | 未登录授权请求跳转的登录页
+	consentURL    string // Consent page for scopes not yet approved | 未批准权限范围时跳转的同意页
+	autoApprove   bool   // Issue codes without consent | 无需授权同意直接签发授权码
+
+	verificationURI string   // Where users enter device user codes | 用户输入设备用户码的页面
+	trustedOrigins  []string // Origins allowed to post device verification decisions | 允许提交设备验证决定的来源
+
+	registrationToken string // Initial access token for the registration endpoint | 注册端点的初始访问令牌
+	openRegistration  bool   // Registration without initial access token | 无需初始访问令牌即可注册
+}
+
+// NewHandler Creates OAuth2 endpoint handlers | 创建OAuth2端点处理器
+func NewHandler(server *OAuth2Server, resolveUser UserResolver) *Handler {
+	return &Handler{
+		server:      server,
+		resolveUser: resolveUser,
+	}
+}
+
+// SetServerResolver Serves each request from the server it resolves to, e.g. the server of the request's tenant | 每个请求使用解析出的服务器处理，如请求所属租户的服务器
+func (h *Handler) SetServerResolver(resolver ServerResolver) {
+	h.resolveServer = resolver
+}
+
+// SetLoginURL Sets login page for anonymous authorize requests, "redirect" carries the original URL | 设置未登录授权请求跳转的登录页，"redirect"参数携带原始URL
+func (h *Handler) SetLoginURL(loginURL string) {
+	h.loginURL = loginURL
+}
+
+// SetConsentURL Sends users to a consent page unless they already approved the requested scopes | 用户尚未批准请求的权限范围时跳转到同意页
+// The page receives client_id, scope and "redirect", calls GrantConsent on approval and redirects back | 同意页接收client_id、scope和"redirect"参数，批准后调用GrantConsent并跳转回原地址
+// Requests without stored consent get consent_required when unset | 未设置时，没有已存储授权同意的请求返回consent_required
+func (h *Handler) SetConsentURL(consentURL string) {
+	h.consentURL = consentURL
+}
+
+// SetAutoApprove Issues codes without asking for consent, only for trusted first-party clients | 无需授权同意直接签发授权码，仅用于可信的第一方客户端
+func (h *Handler) SetAutoApprove(autoApprove bool) {
+	h.autoApprove = autoApprove
+}
+
+// SetAuthTimeResolver Sets how auth_time of ID tokens is resolved, authorization time when unset | 设置ID令牌auth_time的解析方式，未设置时取授权时间
+func (h *Handler) SetAuthTimeResolver(resolver AuthTimeResolver) {
+	h.resolveAuth = resolver
+}
+
+// SetVerificationURI Sets the page where users enter device user codes, DeviceVerificationPath when unset | 设置用户输入设备用户码的页面，未设置时为DeviceVerificationPath
+// Use an absolute URL, devices show it to the user | 应使用绝对URL，设备会将其展示给用户
+func (h *Handler) SetVerificationURI(uri string) {
+	h.verificationURI = uri
+}
+
+// SetTrustedOrigins Sets the origins allowed to approve or deny device user codes, e.g. "https://auth.example.com" | 设置允许批准或拒绝设备用户码的来源，如"https://auth.example.com"
+// Decisions are rejected unless the Origin (or Referer) header matches, integrations mount the POST route only when set | Origin（或Referer）头不匹配时拒绝决定，仅在设置后集成才会挂载POST路由
+func (h *Handler) SetTrustedOrigins(origins ...string) {
+	h.trustedOrigins = h.trustedOrigins[:0]
+	for _, origin := range origins {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			h.trustedOrigins = append(h.trustedOrigins, origin)
+		}
+	}
+}
+
+// AcceptsDeviceDecisions Checks if device verification decisions (POST) are enabled by SetTrustedOrigins | 检查是否已通过SetTrustedOrigins启用设备验证决定（POST）
+func (h *Handler) AcceptsDeviceDecisions() bool {
+	return len(h.trustedOrigins) > 0
+}
+
+// SetRegistrationToken Requires "Authorization: Bearer <token>" on the registration endpoint | 注册端点要求携带"Authorization: Bearer <token>"
+// Registration is closed unless a token is set or SetOpenRegistration is enabled | 未设置令牌且未启用SetOpenRegistration时关闭注册
+func (h *Handler) SetRegistrationToken(token string) {
+	h.registrationToken = token
+}
+
+// SetOpenRegistration Lets anyone register clients without an initial access token | 允许任何人无需初始访问令牌注册客户端
+func (h *Handler) SetOpenRegistration(open bool) {
+	h.openRegistration = open
+}
+
+// ============ Authorization Endpoint | 授权端点 ============
+
+// Authorize Handles the authorization request and redirects back with a code | 处理授权请求并携带授权码重定向回客户端
+func (h *Handler) Authorize(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	clientID := formValue(ctx, "client_id")
+	client, err := server.GetClient(clientID)
+	if err != nil {
+		// Never redirect to an unverified URI (RFC 6749 4.1.2.1) | 不能重定向到未验证的URI
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "unknown client_id")
+	}
+
+	redirectURI := formValue(ctx, "redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !server.isValidRedirectURI(client, redirectURI) {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "invalid redirect_uri")
+	}
+
+	state := formValue(ctx, "state")
+	if formValue(ctx, "response_type") != "code" {
+		return redirectError(redirectURI, state, ErrorUnsupportedResponseType, "response_type must be code")
+	}
+
+	userID, err := h.resolveUser(ctx)
+	if err != nil || userID == "" {
+		if h.loginURL != "" {
+			return redirect(h.loginURL, map[string]string{"redirect": ctx.GetURL()})
+		}
+		return errorResponse(http.StatusUnauthorized, ErrorAccessDenied, "user not logged in")
+	}
+
+	scopes := strings.Fields(formValue(ctx, "scope"))
+	if !h.autoApprove && !server.HasConsent(userID, clientID, scopes) {
+		if _, err := validateScopes(client, scopes); err != nil {
+			return redirectError(redirectURI, state, ErrorInvalidScope, err.Error())
+		}
+		if h.consentURL == "" {
+			return redirectError(redirectURI, state, ErrorConsentRequired, "user consent required")
+		}
+		return redirect(h.consentURL, map[string]string{
+			"client_id": clientID,
+			"scope":     strings.Join(scopes, " "),
+			"redirect":  ctx.GetURL(),
+		})
+	}
+
+	req := &AuthorizationRequest{
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		UserID:              userID,
+		Scopes:              scopes,
+		CodeChallenge:       formValue(ctx, "code_challenge"),
+		CodeChallengeMethod: formValue(ctx, "code_challenge_method"),
+		Nonce:               formValue(ctx, "nonce"),
+	}
+	if h.resolveAuth != nil {
+		req.AuthTime = h.resolveAuth(ctx, userID)
+	}
+
+	authCode, err := server.CreateAuthorizationCode(req)
+	if err != nil {
+		code, _ := errorCode(err)
+		return redirectError(redirectURI, state, code, err.Error())
+	}
+
+	return redirect(redirectURI, map[string]string{"code": authCode.Code, "state": state})
+}
+
+// ============ Token Endpoint | 令牌端点 ============
+
+// Token Handles the token request for every supported grant type | 处理所有支持授权类型的令牌请求
+func (h *Handler) Token(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	if ctx.GetMethod() != http.MethodPost {
+		return errorResponse(http.StatusMethodNotAllowed, ErrorInvalidRequest, "POST required")
+	}
+
+	clientID, clientSecret, basic := clientCredentials(ctx)
+	if clientID == "" {
+		return clientError(basic, "client authentication required")
+	}
+
+	var (
+		token *AccessToken
+		err   error
+	)
+	scopes := strings.Fields(ctx.GetPostForm("scope"))
+
+	switch GrantType(ctx.GetPostForm("grant_type")) {
+	case GrantTypeAuthorizationCode:
+		token, err = server.ExchangeCodeForToken(
+			ctx.GetPostForm("code"),
+			clientID,
+			clientSecret,
+			ctx.GetPostForm("redirect_uri"),
+			ctx.GetPostForm("code_verifier"),
+		)
+	case GrantTypeRefreshToken:
+		token, err = server.RefreshAccessToken(ctx.GetPostForm("refresh_token"), clientID, clientSecret, scopes...)
+	case GrantTypeClientCredentials:
+		token, err = server.ClientCredentialsToken(clientID, clientSecret, scopes)
+	case GrantTypePassword:
+		token, err = server.PasswordToken(clientID, clientSecret, ctx.GetPostForm("username"), ctx.GetPostForm("password"), scopes)
+	case GrantTypeDeviceCode:
+		token, err = server.PollDeviceToken(ctx.GetPostForm("device_code"), clientID, clientSecret)
+	case "":
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "grant_type required")
+	default:
+		return errorResponse(http.StatusBadRequest, ErrorUnsupportedGrantType, "")
+	}
+
+	if err != nil {
+		code, status := errorCode(err)
+		if code == ErrorInvalidClient {
+			return clientError(basic, err.Error())
+		}
+		return errorResponse(status, code, err.Error())
+	}
+
+	return noStore(&Response{
+		Status: http.StatusOK,
+		Body: &TokenResponse{
+			AccessToken:  token.Token,
+			TokenType:    token.TokenType,
+			ExpiresIn:    token.ExpiresIn,
+			RefreshToken: token.RefreshToken,
+			Scope:        strings.Join(token.Scopes, " "),
+			IDToken:      token.IDToken,
+		},
+	})
+}
+
+// ============ Revocation Endpoint | 撤销端点 ============
+
+// Revoke Handles token revocation (RFC 7009), unknown tokens still answer 200 | 处理令牌撤销（RFC 7009），未知令牌同样返回200
+func (h *Handler) Revoke(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	client, resp := h.authenticate(ctx)
+	if resp != nil {
+		return resp
+	}
+
+	tokenString := ctx.GetPostForm("token")
+	if tokenString == "" {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "token required")
+	}
+
+	// Tokens issued to other clients are left untouched | 不撤销签发给其他客户端的令牌
+	revokeAccess := func() bool {
+		token, err := server.ValidateAccessToken(tokenString)
+		if err != nil {
+			return false
+		}
+		if token.ClientID == client.ClientID {
+			server.RevokeToken(tokenString)
+		}
+		return true
+	}
+	revokeRefresh := func() bool {
+		record, err := server.loadRefreshRecord(tokenString)
+		if err != nil {
+			return false
+		}
+		if record.ClientID == client.ClientID {
+			server.RevokeRefreshToken(tokenString)
+		}
+		return true
+	}
+
+	if ctx.GetPostForm("token_type_hint") == TokenTypeHintRefreshToken {
+		_ = revokeRefresh() || revokeAccess()
+	} else {
+		_ = revokeAccess() || revokeRefresh()
+	}
+
+	return &Response{Status: http.StatusOK}
+}
+
+// ============ Introspection Endpoint | 内省端点 ============
+
+// Introspect Handles token introspection (RFC 7662) | 处理令牌内省（RFC 7662）
+// Only confidential clients may call it, tokens of other clients are inactive unless the caller is an Introspector | 仅机密客户端可调用，除非调用方为Introspector，否则其他客户端的令牌视为无效
+func (h *Handler) Introspect(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	client, resp := h.authenticate(ctx)
+	if resp != nil {
+		return resp
+	}
+	if client.Public {
+		_, _, basic := clientCredentials(ctx)
+		return clientError(basic, "public clients cannot introspect tokens")
+	}
+
+	tokenString := ctx.GetPostForm("token")
+	if tokenString == "" {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "token required")
+	}
+
+	introspectAccess := func() *IntrospectionResponse {
+		token, err := server.ValidateAccessToken(tokenString)
+		if err != nil {
+			return nil
+		}
+		resp := introspection(token)
+		resp.TokenType = token.TokenType
+		if token.IssuedAt > 0 {
+			resp.Exp = token.IssuedAt + token.ExpiresIn
+		}
+		return resp
+	}
+	introspectRefresh := func() *IntrospectionResponse {
+		record, err := server.loadRefreshRecord(tokenString)
+		if err != nil {
+			return nil
+		}
+		resp := introspection(record)
+		// The storage TTL is the real expiry | 存储的剩余有效期才是实际过期时间
+		if ttl, err := server.storage.TTL(server.getRefreshKey(tokenString)); err == nil && ttl > 0 {
+			resp.Exp = time.Now().Add(ttl).Unix()
+		}
+		return resp
+	}
+
+	var result *IntrospectionResponse
+	if ctx.GetPostForm("token_type_hint") == TokenTypeHintRefreshToken {
+		if result = introspectRefresh(); result == nil {
+			result = introspectAccess()
+		}
+	} else {
+		if result = introspectAccess(); result == nil {
+			result = introspectRefresh()
+		}
+	}
+	if result == nil || (result.ClientID != client.ClientID && !client.Introspector) {
+		result = &IntrospectionResponse{Active: false}
+	}
+
+	return noStore(&Response{Status: http.StatusOK, Body: result})
+}
+
+// ============ Registration Endpoint | 注册端点 ============
+
+// Register Handles dynamic client registration with a JSON body (RFC 7591) | 处理JSON格式的动态客户端注册（RFC 7591）
+func (h *Handler) Register(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	if ctx.GetMethod() != http.MethodPost {
+		return errorResponse(http.StatusMethodNotAllowed, ErrorInvalidRequest, "POST required")
+	}
+
+	if h.registrationToken == "" && !h.openRegistration {
+		return errorResponse(http.StatusForbidden, ErrorAccessDenied, "client registration is closed")
+	}
+	if h.registrationToken != "" {
+		auth := ctx.GetHeader("Authorization")
+		if len(auth) <= 7 || !strings.EqualFold(auth[:7], "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(auth[7:])), []byte(h.registrationToken)) != 1 {
+			resp := errorResponse(http.StatusUnauthorized, ErrorInvalidToken, "initial access token required")
+			resp.Header["WWW-Authenticate"] = `Bearer error="invalid_token"`
+			return resp
+		}
+	}
+
+	body, err := ctx.GetBody()
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidClientMetadata, "unreadable body")
+	}
+	req := &ClientRegistrationRequest{}
+	if err := json.Unmarshal(body, req); err != nil {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidClientMetadata, "malformed JSON")
+	}
+
+	registered, err := server.RegisterDynamicClient(req)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidRedirectURI):
+			return errorResponse(http.StatusBadRequest, ErrorInvalidRedirectURI, err.Error())
+		case errors.Is(err, ErrInvalidClientMetadata):
+			return errorResponse(http.StatusBadRequest, ErrorInvalidClientMetadata, err.Error())
+		default:
+			return errorResponse(http.StatusInternalServerError, ErrorServerError, err.Error())
+		}
+	}
+
+	return noStore(&Response{Status: http.StatusCreated, Body: registered})
+}
+
+// ============ Device Authorization Endpoints | 设备授权端点 ============
+
+// DeviceAuthorization Issues a device_code/user_code pair (RFC 8628 3.1) | 签发device_code/user_code对
+func (h *Handler) DeviceAuthorization(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	if ctx.GetMethod() != http.MethodPost {
+		return errorResponse(http.StatusMethodNotAllowed, ErrorInvalidRequest, "POST required")
+	}
+
+	clientID, clientSecret, basic := clientCredentials(ctx)
+	if clientID == "" {
+		return clientError(basic, "client authentication required")
+	}
+
+	device, err := server.CreateDeviceAuthorization(clientID, clientSecret, strings.Fields(ctx.GetPostForm("scope")))
+	if err != nil {
+		code, status := errorCode(err)
+		if code == ErrorInvalidClient {
+			return clientError(basic, err.Error())
+		}
+		return errorResponse(status, code, err.Error())
+	}
+
+	verificationURI := h.verificationURI
+	if verificationURI == "" {
+		verificationURI = DeviceVerificationPath
+	}
+	userCode := FormatUserCode(device.UserCode)
+	complete := redirect(verificationURI, map[string]string{"user_code": userCode}).Header["Location"]
+
+	return noStore(&Response{
+		Status: http.StatusOK,
+		Body: &DeviceAuthorizationResponse{
+			DeviceCode:              device.DeviceCode,
+			UserCode:                userCode,
+			VerificationURI:         verificationURI,
+			VerificationURIComplete: complete,
+			ExpiresIn:               device.ExpiresIn,
+			Interval:                device.Interval,
+		},
+	})
+}
+
+// DeviceVerification Lets the logged-in user review (GET) and approve or deny (POST, action=approve|deny) a user code | 已登录用户查看（GET）并批准或拒绝（POST，action=approve|deny）用户码
+// Decisions must come from a trusted origin, see SetTrustedOrigins | 决定必须来自可信来源，见SetTrustedOrigins
+func (h *Handler) DeviceVerification(ctx adapter.RequestContext) *Response {
+	server := h.serverFor(ctx)
+	isDecision := ctx.GetMethod() == http.MethodPost
+	if isDecision {
+		if resp := h.checkOrigin(ctx); resp != nil {
+			return resp
+		}
+	}
+
+	userID, err := h.resolveUser(ctx)
+	if err != nil || userID == "" {
+		if h.loginURL != "" {
+			return redirect(h.loginURL, map[string]string{"redirect": ctx.GetURL()})
+		}
+		return errorResponse(http.StatusUnauthorized, ErrorAccessDenied, "user not logged in")
+	}
+
+	userCode := formValue(ctx, "user_code")
+	if userCode == "" {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "user_code required")
+	}
+
+	var device *DeviceAuthorization
+	switch {
+	case !isDecision:
+		device, err = server.GetDeviceAuthorization(userCode)
+	case ctx.GetPostForm("action") == "deny":
+		device, err = server.DenyDeviceAuthorization(userCode)
+	case ctx.GetPostForm("action") != "approve":
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "action must be approve or deny")
+	default:
+		var authTime int64
+		if h.resolveAuth != nil {
+			authTime = h.resolveAuth(ctx, userID)
+		}
+		device, err = server.ApproveDeviceAuthorization(userCode, userID, authTime)
+	}
+	if err != nil {
+		code, status := errorCode(err)
+		return errorResponse(status, code, err.Error())
+	}
+
+	resp := &DeviceVerificationResponse{
+		UserCode: FormatUserCode(device.UserCode),
+		ClientID: device.ClientID,
+		Scope:    strings.Join(device.Scopes, " "),
+		Status:   device.Status,
+	}
+	if client, err := server.GetClient(device.ClientID); err == nil {
+		resp.ClientName = client.Name
+	}
+	return noStore(&Response{Status: http.StatusOK, Body: resp})
+}
+
+// ============ Helper Methods | 辅助方法 ============
+
+// serverFor Gets the server handling a request | 获取处理请求的服务器
+func (h *Handler) serverFor(ctx adapter.RequestContext) *OAuth2Server {
+	if h.resolveServer != nil {
+		if server := h.resolveServer(ctx); server != nil {
+			return server
+		}
+	}
+	return h.server
+}
+
+// checkOrigin Rejects state-changing browser requests not sent from a trusted origin | 拒绝不是来自可信来源的状态变更浏览器请求
+func (h *Handler) checkOrigin(ctx adapter.RequestContext) *Response {
+	if len(h.trustedOrigins) == 0 {
+		return errorResponse(http.StatusForbidden, ErrorAccessDenied, "no trusted origins configured")
+	}
+
+	origin := ctx.GetHeader("Origin")
+	if origin == "" || origin == "null" {
+		// Fall back to the origin of the Referer | 回退为Referer的来源
+		if referer, err := url.Parse(ctx.GetHeader("Referer")); err == nil && referer.Scheme != "" && referer.Host != "" {
+			origin = referer.Scheme + "://" + referer.Host
+		}
+	}
+	for _, trusted := range h.trustedOrigins {
+		if origin != "" && strings.EqualFold(origin, trusted) {
+			return nil
+		}
+	}
+	return errorResponse(http.StatusForbidden, ErrorAccessDenied, "request origin not trusted")
+}
+
+// authenticate Authenticates the calling client of revoke / introspect | 认证撤销 / 内省请求的客户端
+func (h *Handler) authenticate(ctx adapter.RequestContext) (*Client, *Response) {
+	server := h.serverFor(ctx)
+	if ctx.GetMethod() != http.MethodPost {
+		return nil, errorResponse(http.StatusMethodNotAllowed, ErrorInvalidRequest, "POST required")
+	}
+
+	clientID, clientSecret, basic := clientCredentials(ctx)
+	if clientID == "" {
+		return nil, clientError(basic, "client authentication required")
+	}
+
+	client, err := server.verifyClient(clientID, clientSecret)
+	if err != nil {
+		return nil, clientError(basic, err.Error())
+	}
+	return client, nil
+}
+
+// introspection Builds the common introspection fields | 构建通用的内省字段
+func introspection(token *AccessToken) *IntrospectionResponse {
+	return &IntrospectionResponse{
+		Active:   true,
+		Scope:    strings.Join(token.Scopes, " "),
+		ClientID: token.ClientID,
+		Iat:      token.IssuedAt,
+		Sub:      token.UserID,
+	}
+}
+
+// clientCredentials Reads client credentials from HTTP Basic or the form body (RFC 6749 2.3.1) | 从HTTP Basic或表单中读取客户端凭证
+func clientCredentials(ctx adapter.RequestContext) (clientID, clientSecret string, basic bool) {
+	auth := ctx.GetHeader("Authorization")
+	if len(auth) > 6 && strings.EqualFold(auth[:6], "Basic ") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[6:]))
+		if err != nil {
+			return "", "", true
+		}
+		id, secret, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return "", "", true
+		}
+		// Credentials are form-urlencoded before Basic encoding | Basic编码前凭证经过form-urlencoded编码
+		if id, err = url.QueryUnescape(id); err != nil {
+			return "", "", true
+		}
+		if secret, err = url.QueryUnescape(secret); err != nil {
+			return "", "", true
+		}
+		return id, secret, true
+	}
+	return ctx.GetPostForm("client_id"), ctx.GetPostForm("client_secret"), false
+}
+
+// errorCode Maps server errors to OAuth2 error codes and HTTP status | 将服务器错误映射为OAuth2错误码和HTTP状态码
+func errorCode(err error) (string, int) {
+	switch {
+	case errors.Is(err, ErrClientNotFound), errors.Is(err, ErrInvalidClientCredentials):
+		return ErrorInvalidClient, http.StatusUnauthorized
+	case errors.Is(err, ErrUnauthorizedClient):
+		return ErrorUnauthorizedClient, http.StatusBadRequest
+	case errors.Is(err, ErrInvalidScope):
+		return ErrorInvalidScope, http.StatusBadRequest
+	case errors.Is(err, ErrPasswordVerifierNotSet):
+		return ErrorUnsupportedGrantType, http.StatusBadRequest
+	case errors.Is(err, ErrAuthorizationPending):
+		return ErrorAuthorizationPending, http.StatusBadRequest
+	case errors.Is(err, ErrSlowDown):
+		return ErrorSlowDown, http.StatusBadRequest
+	case errors.Is(err, ErrDeviceCodeExpired):
+		return ErrorExpiredToken, http.StatusBadRequest
+	case errors.Is(err, ErrDeviceAccessDenied):
+		return ErrorAccessDenied, http.StatusBadRequest
+	case errors.Is(err, ErrInvalidAuthCode),
+		errors.Is(err, ErrAuthCodeUsed),
+		errors.Is(err, ErrAuthCodeExpired),
+		errors.Is(err, ErrClientMismatch),
+		errors.Is(err, ErrRedirectURIMismatch),
+		errors.Is(err, ErrInvalidCodeVerifier),
+		errors.Is(err, ErrInvalidUserCredentials),
+		errors.Is(err, ErrInvalidRefreshToken),
+		errors.Is(err, ErrInvalidDeviceCode),
+		errors.Is(err, ErrInvalidTokenData):
+		return ErrorInvalidGrant, http.StatusBadRequest
+	case errors.Is(err, ErrPKCERequired),
+		errors.Is(err, ErrInvalidCodeChallenge),
+		errors.Is(err, ErrUnsupportedPKCEMethod),
+		errors.Is(err, ErrInvalidNonce),
+		errors.Is(err, ErrInvalidUserCode),
+		errors.Is(err, ErrInvalidRedirectURI):
+		return ErrorInvalidRequest, http.StatusBadRequest
+	default:
+		return ErrorServerError, http.StatusInternalServerError
+	}
+}
+
+// formValue Reads a parameter from the query string, then the form body | 先从查询字符串读取参数，再从表单读取
+func formValue(ctx adapter.RequestContext, key string) string {
+	if value := ctx.GetQuery(key); value != "" {
+		return value
+	}
+	return ctx.GetPostForm(key)
+}
+
+// errorResponse Builds an OAuth2 JSON error response | 构建OAuth2 JSON错误响应
+func errorResponse(status int, code, description string) *Response {
+	return noStore(&Response{
+		Status: status,
+		Body:   &ErrorResponse{Error: code, ErrorDescription: description},
+	})
+}
+
+// clientError Builds an invalid_client response, challenging Basic auth when used (RFC 6749 5.2) | 构建invalid_client响应，使用Basic认证时返回认证质询
+func clientError(basic bool, description string) *Response {
+	resp := errorResponse(http.StatusUnauthorized, ErrorInvalidClient, description)
+	if basic {
+		resp.Header["WWW-Authenticate"] = `Basic realm="oauth2"`
+	}
+	return resp
+}
+
+// redirectError Redirects an error back to the client (RFC 6749 4.1.2.1) | 将错误重定向回客户端
+func redirectError(redirectURI, state, code, description string) *Response {
+	return redirect(redirectURI, map[string]string{
+		"error":             code,
+		"error_description": description,
+		"state":             state,
+	})
+}
+
+// redirect Builds a 302 response, empty parameters are skipped | 构建302响应，跳过空参数
+func redirect(target string, params map[string]string) *Response {
+	u, err := url.Parse(target)
+	if err != nil {
+		return errorResponse(http.StatusBadRequest, ErrorInvalidRequest, "invalid redirect target")
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return &Response{
+		Status: http.StatusFound,
+		Header: map[string]string{"Location": u.String()},
+	}
+}
+
+// noStore Adds the cache headers required for token responses | 添加令牌响应要求的缓存头
+func noStore(resp *Response) *Response {
+	if resp.Header == nil {
+		resp.Header = make(map[string]string)
+	}
+	resp.Header["Cache-Control"] = "no-store"
+	resp.Header["Pragma"] = "no-cache"
+	return resp
+}
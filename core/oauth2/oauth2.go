@@ -2,12 +2,17 @@ package oauth2
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/utils"
 )
 
 // OAuth2 Authorization Code Flow Implementation
@@ -20,6 +25,31 @@ import (
 // 4. ValidateAccessToken() - Validate access token | 验证访问令牌
 // 5. RefreshAccessToken() - Use refresh token to get new token | 用刷新令牌获取新令牌
 //
+// Other grants | 其他授权模式:
+// ClientCredentialsToken() - Client acts on its own behalf, no refresh token | 客户端以自身身份获取令牌，不签发刷新令牌
+// PasswordToken() - Resource owner credentials checked by a PasswordVerifier | 通过PasswordVerifier校验资源所有者凭证
+// CreateDeviceAuthorization() / PollDeviceToken() - Input-constrained devices, see device.go | 输入受限设备，见device.go
+//
+// Every flow is checked against Client.GrantTypes | 每个流程都会校验Client.GrantTypes
+//
+// Clients | 客户端:
+// Clients live in a ClientStore (memory by default, NewStorageClientStore to share them across nodes). | 客户端保存在ClientStore中（默认内存，使用NewStorageClientStore在多节点间共享）。
+// Secrets are hashed on registration and can be rotated with an overlap window. | 密钥在注册时哈希存储，并可在重叠窗口内轮换。
+//
+// Consent | 授权同意:
+// Approved scopes are remembered per user and client, RevokeConsent() also revokes the client's tokens. | 按用户和客户端记录已批准的权限范围，RevokeConsent()同时撤销客户端的令牌。
+//
+// Scopes | 权限范围:
+// Requested scopes must be covered by Client.Scopes, wildcards follow permission matching ("user:*"). | 请求的权限范围必须被Client.Scopes覆盖，通配符规则与权限匹配一致（"user:*"）。
+// RefreshAccessToken() may narrow scopes, ValidateAccessTokenWithScope() checks required scopes. | RefreshAccessToken()可缩小权限范围，ValidateAccessTokenWithScope()校验所需权限范围。
+//
+// PKCE (RFC 7636) | PKCE:
+// GenerateAuthorizationCodeWithPKCE() stores code_challenge, ExchangeCodeForToken() checks code_verifier. | 签发授权码时保存code_challenge，换取令牌时校验code_verifier。
+// Public clients have no secret and must use PKCE. | 公开客户端没有密钥，必须使用PKCE。
+//
+// OpenID Connect | OpenID Connect:
+// With an IDTokenIssuer set (see core/oidc), the "openid" scope adds an ID token carrying the code's nonce and auth_time. | 设置IDTokenIssuer后（见core/oidc），"openid"权限范围会额外签发携带授权码nonce和auth_time的ID令牌。
+//
 // Usage | 用法:
 //   server := oauth2.NewOAuth2Server(storage)
 //   server.RegisterClient(&oauth2.Client{...})
@@ -36,11 +66,22 @@ const (
 	AccessTokenLength  = 32 // Access token byte length | 访问令牌字节长度
 	RefreshTokenLength = 32 // Refresh token byte length | 刷新令牌字节长度
 
-	CodeKeySuffix    = "oauth2:code:"    // Code key suffix after prefix | 授权码键后缀
-	TokenKeySuffix   = "oauth2:token:"   // Token key suffix after prefix | 令牌键后缀
-	RefreshKeySuffix = "oauth2:refresh:" // Refresh key suffix after prefix | 刷新令牌键后缀
+	CodeKeySuffix        = "oauth2:code:"         // Code key suffix after prefix | 授权码键后缀
+	TokenKeySuffix       = "oauth2:token:"        // Token key suffix after prefix | 令牌键后缀
+	RefreshKeySuffix     = "oauth2:refresh:"      // Refresh key suffix after prefix | 刷新令牌键后缀
+	RefreshUsedKeySuffix = "oauth2:refresh-used:" // Marker of a consumed refresh token | 已消费刷新令牌的标记键后缀
 
 	TokenTypeBearer = "Bearer" // Token type | 令牌类型
+
+	ScopeOpenID = "openid" // OpenID Connect scope, requests an ID token | OpenID Connect权限范围，请求签发ID令牌
+
+	CodeChallengeMethodPlain = "plain" // PKCE plain method | PKCE plain方式
+	CodeChallengeMethodS256  = "S256"  // PKCE SHA-256 method | PKCE SHA-256方式
+
+	minPKCELength = 43  // Minimum code_verifier / code_challenge length | code_verifier / code_challenge最小长度
+	maxPKCELength = 128 // Maximum code_verifier / code_challenge length | code_verifier / code_challenge最大长度
+
+	maxNonceLength = 255 // Maximum OIDC nonce length | OIDC nonce最大长度
 )
 
 // Error variables | 错误变量
@@ -55,121 +96,390 @@ var (
 	ErrRedirectURIMismatch      = fmt.Errorf("redirect_uri mismatch")
 	ErrInvalidAccessToken       = fmt.Errorf("invalid access token")
 	ErrInvalidTokenData         = fmt.Errorf("invalid token data")
+	ErrInvalidRefreshToken      = fmt.Errorf("invalid refresh token")
+	ErrInvalidClientData        = fmt.Errorf("invalid client data")
+	ErrPublicClient             = fmt.Errorf("public client has no secret")
+	ErrUnauthorizedClient       = fmt.Errorf("client is not authorized to use this grant type")
+	ErrInvalidUserCredentials   = fmt.Errorf("invalid resource owner credentials")
+	ErrPasswordVerifierNotSet   = fmt.Errorf("password verifier not configured")
+	ErrPKCERequired             = fmt.Errorf("code_challenge required")
+	ErrInvalidCodeChallenge     = fmt.Errorf("invalid code_challenge")
+	ErrUnsupportedPKCEMethod    = fmt.Errorf("unsupported code_challenge_method")
+	ErrInvalidCodeVerifier      = fmt.Errorf("invalid code_verifier")
+	ErrInvalidScope             = fmt.Errorf("invalid scope")
+	ErrInsufficientScope        = fmt.Errorf("insufficient scope")
+	ErrInvalidNonce             = fmt.Errorf("invalid nonce")
 )
 
 // GrantType OAuth2 grant type | OAuth2授权类型
 type GrantType string
 
 const (
-	GrantTypeAuthorizationCode GrantType = "authorization_code" // Authorization code flow | 授权码模式
-	GrantTypeRefreshToken      GrantType = "refresh_token"      // Refresh token flow | 刷新令牌模式
-	GrantTypeClientCredentials GrantType = "client_credentials" // Client credentials flow | 客户端凭证模式
-	GrantTypePassword          GrantType = "password"           // Password flow | 密码模式
+	GrantTypeAuthorizationCode GrantType = "authorization_code"                           // Authorization code flow | 授权码模式
+	GrantTypeRefreshToken      GrantType = "refresh_token"                                // Refresh token flow | 刷新令牌模式
+	GrantTypeClientCredentials GrantType = "client_credentials"                           // Client credentials flow | 客户端凭证模式
+	GrantTypePassword          GrantType = "password"                                     // Password flow | 密码模式
+	GrantTypeDeviceCode        GrantType = "urn:ietf:params:oauth:grant-type:device_code" // Device authorization flow (RFC 8628) | 设备授权模式
 )
 
+// DefaultGrantTypes Grant types allowed when Client.GrantTypes is empty | Client.GrantTypes为空时允许的授权类型
+var DefaultGrantTypes = []GrantType{GrantTypeAuthorizationCode, GrantTypeRefreshToken}
+
 // Client OAuth2 client configuration | OAuth2客户端配置
 type Client struct {
-	ClientID     string      // Client ID | 客户端ID
-	ClientSecret string      // Client secret | 客户端密钥
-	RedirectURIs []string    // Allowed redirect URIs | 允许的回调URI
-	GrantTypes   []GrantType // Allowed grant types | 允许的授权类型
-	Scopes       []string    // Allowed scopes | 允许的权限范围
+	ClientID     string         `json:"clientId"`               // Client ID | 客户端ID
+	ClientSecret string         `json:"clientSecret,omitempty"` // Plaintext secret, hashed into Secrets by RegisterClient | 明文密钥，RegisterClient会将其哈希到Secrets
+	Secrets      []ClientSecret `json:"secrets,omitempty"`      // Hashed secrets, more than one during rotation | 哈希后的密钥，轮换期间可有多个
+	RedirectURIs []string       `json:"redirectUris"`           // Allowed redirect URIs | 允许的回调URI
+	GrantTypes   []GrantType    `json:"grantTypes"`             // Allowed grant types, DefaultGrantTypes when empty | 允许的授权类型，为空时使用DefaultGrantTypes
+	Scopes       []string       `json:"scopes"`                 // Allowed scopes, wildcards supported, unrestricted when empty unless Dynamic | 允许的权限范围，支持通配符，为空时不限制（动态注册客户端除外）
+	Public       bool           `json:"public"`                 // Public client (SPA, mobile) without secret, must use PKCE | 无密钥的公开客户端（SPA、移动端），必须使用PKCE
+	Dynamic      bool           `json:"dynamic,omitempty"`      // Registered through RFC 7591, never unrestricted and never password | 通过RFC 7591动态注册，权限范围不会不受限且不能使用密码模式
+	Introspector bool           `json:"introspector,omitempty"` // May introspect tokens of every client, e.g. a resource server | 可内省所有客户端的令牌，例如资源服务器
+	Name         string         `json:"name,omitempty"`         // Display name | 显示名称
+	CreateTime   int64          `json:"createTime,omitempty"`   // Registration time | 注册时间
+}
+
+// ClientSecret Hashed client secret | 哈希后的客户端密钥
+type ClientSecret struct {
+	Hash       string `json:"hash"`                 // SecretHasher output | SecretHasher的输出
+	CreateTime int64  `json:"createTime"`           // Creation time | 创建时间
+	ExpireTime int64  `json:"expireTime,omitempty"` // Expiration time, 0 means never | 过期时间，0表示永不过期
+}
+
+// active Checks if the secret is still usable | 检查密钥是否仍可使用
+func (cs *ClientSecret) active(now int64) bool {
+	return cs.ExpireTime == 0 || now < cs.ExpireTime
 }
 
+// AllowsGrantType Checks if the client may use a grant type | 检查客户端是否允许使用某授权类型
+func (c *Client) AllowsGrantType(grantType GrantType) bool {
+	if c.Dynamic && grantType == GrantTypePassword {
+		return false
+	}
+
+	grantTypes := c.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = DefaultGrantTypes
+	}
+	for _, gt := range grantTypes {
+		if gt == grantType {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordVerifier Verifies resource owner credentials and returns the user ID | 校验资源所有者凭证并返回用户ID
+type PasswordVerifier func(username, password string) (userID string, err error)
+
 // AuthorizationCode authorization code information | 授权码信息
 type AuthorizationCode struct {
-	Code        string   // Authorization code | 授权码
-	ClientID    string   // Client ID | 客户端ID
-	RedirectURI string   // Redirect URI | 回调URI
-	UserID      string   // User ID | 用户ID
-	Scopes      []string // Requested scopes | 请求的权限范围
-	CreateTime  int64    // Creation time | 创建时间
-	ExpiresIn   int64    // Expiration time in seconds | 过期时间（秒）
-	Used        bool     // Whether used | 是否已使用
+	Code        string   `json:"code"`        // Authorization code | 授权码
+	ClientID    string   `json:"clientId"`    // Client ID | 客户端ID
+	RedirectURI string   `json:"redirectUri"` // Redirect URI | 回调URI
+	UserID      string   `json:"userId"`      // User ID | 用户ID
+	Scopes      []string `json:"scopes"`      // Requested scopes | 请求的权限范围
+	CreateTime  int64    `json:"createTime"`  // Creation time | 创建时间
+	ExpiresIn   int64    `json:"expiresIn"`   // Expiration time in seconds | 过期时间（秒）
+	Used        bool     `json:"used"`        // Whether used | 是否已使用
+
+	CodeChallenge       string `json:"codeChallenge,omitempty"`       // PKCE code challenge | PKCE code_challenge
+	CodeChallengeMethod string `json:"codeChallengeMethod,omitempty"` // PKCE method (plain/S256) | PKCE方式（plain/S256）
+
+	Nonce    string `json:"nonce,omitempty"`    // OIDC nonce echoed in the ID token | 回显到ID令牌中的OIDC nonce
+	AuthTime int64  `json:"authTime,omitempty"` // Time the user authenticated | 用户认证时间
+}
+
+// AuthorizationRequest Parameters of an approved authorization request | 已批准的授权请求参数
+type AuthorizationRequest struct {
+	ClientID            string   // Client ID | 客户端ID
+	RedirectURI         string   // Redirect URI | 回调URI
+	UserID              string   // Resource owner | 资源所有者
+	Scopes              []string // Requested scopes | 请求的权限范围
+	CodeChallenge       string   // PKCE code challenge | PKCE code_challenge
+	CodeChallengeMethod string   // PKCE method, plain when empty | PKCE方式，为空时为plain
+	Nonce               string   // OIDC nonce | OIDC nonce
+	AuthTime            int64    // Time the user authenticated, now when 0 | 用户认证时间，为0时取当前时间
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (c *AuthorizationCode) MarshalBinary() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (c *AuthorizationCode) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, c)
 }
 
 // AccessToken access token information | 访问令牌信息
 type AccessToken struct {
-	Token        string   // Access token | 访问令牌
-	TokenType    string   // Token type (Bearer) | 令牌类型（Bearer）
-	ExpiresIn    int64    // Expiration time in seconds | 过期时间（秒）
-	RefreshToken string   // Refresh token | 刷新令牌
-	Scopes       []string // Granted scopes | 授予的权限范围
-	UserID       string   // User ID | 用户ID
-	ClientID     string   // Client ID | 客户端ID
+	Token        string   `json:"accessToken"`        // Access token | 访问令牌
+	TokenType    string   `json:"tokenType"`          // Token type (Bearer) | 令牌类型（Bearer）
+	ExpiresIn    int64    `json:"expiresIn"`          // Expiration time in seconds | 过期时间（秒）
+	RefreshToken string   `json:"refreshToken"`       // Refresh token | 刷新令牌
+	Scopes       []string `json:"scopes"`             // Granted scopes | 授予的权限范围
+	UserID       string   `json:"userId"`             // User ID | 用户ID
+	ClientID     string   `json:"clientId"`           // Client ID | 客户端ID
+	IssuedAt     int64    `json:"issuedAt"`           // Issue time | 签发时间
+	AuthTime     int64    `json:"authTime,omitempty"` // Time the user authenticated | 用户认证时间
+	IDToken      string   `json:"idToken,omitempty"`  // OIDC ID token | OIDC ID令牌
+}
+
+// IDTokenIssuer Signs OIDC ID tokens for tokens granted the openid scope | 为授予openid权限范围的令牌签发OIDC ID令牌
+type IDTokenIssuer interface {
+	// IssueIDToken returns the signed ID token, nonce is empty on refresh | 返回签名后的ID令牌，刷新时nonce为空
+	IssueIDToken(token *AccessToken, nonce string) (string, error)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
+func (t *AccessToken) MarshalBinary() ([]byte, error) {
+	return json.Marshal(t)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for Redis storage | 实现encoding.BinaryUnmarshaler接口用于Redis存储
+func (t *AccessToken) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, t)
 }
 
 // OAuth2Server OAuth2 authorization server | OAuth2授权服务器
 type OAuth2Server struct {
-	storage         adapter.Storage
-	keyPrefix       string // Configurable prefix | 可配置的前缀
-	clients         map[string]*Client
-	clientsMu       sync.RWMutex  // Clients map lock | 客户端映射锁
-	codeExpiration  time.Duration // Authorization code expiration (10min) | 授权码过期时间（10分钟）
-	tokenExpiration time.Duration // Access token expiration (2h) | 访问令牌过期时间（2小时）
+	storage   adapter.Storage
+	keyPrefix string // Configurable prefix | 可配置的前缀
+	consents  ConsentStore
+
+	*serverSettings // Shared with the server's namespaces | 与服务器的命名空间共享
+}
+
+// serverSettings Clients and settings shared by a server and its namespaces | 服务器及其命名空间共享的客户端和设置
+type serverSettings struct {
+	clients            ClientStore
+	secretHasher       SecretHasher
+	codeExpiration     time.Duration // Authorization code expiration (10min) | 授权码过期时间（10分钟）
+	tokenExpiration    time.Duration // Access token expiration (2h) | 访问令牌过期时间（2小时）
+	verifyPassword     PasswordVerifier
+	requirePKCE        bool     // Require PKCE for confidential clients too | 机密客户端也必须使用PKCE
+	registrationScopes []string // Scopes dynamic clients may request | 动态注册客户端可请求的权限范围
+	idTokenIssuer      IDTokenIssuer
+	deviceExpiration   time.Duration // Device code expiration (10min) | 设备码过期时间（10分钟）
+	devicePollInterval time.Duration // Minimum device polling interval (5s) | 设备最小轮询间隔（5秒）
 }
 
 // NewOAuth2Server Creates a new OAuth2 server | 创建新的OAuth2服务器
 // prefix: key prefix (e.g., "satoken:" or "" for Java compatibility) | 键前缀（如："satoken:" 或 "" 兼容Java）
 func NewOAuth2Server(storage adapter.Storage, prefix string) *OAuth2Server {
 	return &OAuth2Server{
-		storage:         storage,
-		keyPrefix:       prefix,
-		clients:         make(map[string]*Client),
-		codeExpiration:  DefaultCodeExpiration,
-		tokenExpiration: DefaultTokenExpiration,
+		storage:   storage,
+		keyPrefix: prefix,
+		consents:  NewStorageConsentStore(storage, prefix),
+		serverSettings: &serverSettings{
+			clients:         NewMemoryClientStore(),
+			secretHasher:    NewPBKDF2Hasher(DefaultSecretHashIterations),
+			codeExpiration:  DefaultCodeExpiration,
+			tokenExpiration: DefaultTokenExpiration,
+
+			deviceExpiration:   DefaultDeviceCodeExpiration,
+			devicePollInterval: DefaultDevicePollInterval,
+		},
+	}
+}
+
+// Namespace Returns a server sharing clients and settings whose codes, tokens and consents live under prefix | 返回共享客户端和设置的服务器，其授权码、令牌和授权同意位于prefix之下
+// Used by tenant managers, consents always use the storage consent store | 供租户管理器使用，授权同意始终使用存储授权同意存储
+func (s *OAuth2Server) Namespace(prefix string) *OAuth2Server {
+	return &OAuth2Server{
+		storage:        s.storage,
+		keyPrefix:      prefix,
+		consents:       NewStorageConsentStore(s.storage, prefix),
+		serverSettings: s.serverSettings,
 	}
 }
 
-// RegisterClient Registers an OAuth2 client | 注册OAuth2客户端
+// SetClientStore Sets where clients are persisted | 设置客户端的持久化存储
+func (s *OAuth2Server) SetClientStore(store ClientStore) {
+	s.clients = store
+}
+
+// SetSecretHasher Sets the client secret hasher, existing hashes need the same scheme | 设置客户端密钥哈希器，已有哈希需使用相同方案
+func (s *OAuth2Server) SetSecretHasher(hasher SecretHasher) {
+	s.secretHasher = hasher
+}
+
+// RegisterClient Registers an OAuth2 client, ClientSecret is stored hashed | 注册OAuth2客户端，ClientSecret以哈希形式存储
+// The passed client is not modified | 不会修改传入的client
 func (s *OAuth2Server) RegisterClient(client *Client) error {
 	if client == nil || client.ClientID == "" {
 		return fmt.Errorf("invalid client: clientID is required")
 	}
 
-	s.clientsMu.Lock()
-	defer s.clientsMu.Unlock()
+	stored := client.clone()
+	now := time.Now().Unix()
+	if stored.CreateTime == 0 {
+		stored.CreateTime = now
+	}
+	if stored.ClientSecret != "" {
+		hash, err := s.secretHasher.Hash(stored.ClientSecret)
+		if err != nil {
+			return err
+		}
+		stored.Secrets = append(stored.Secrets, ClientSecret{Hash: hash, CreateTime: now})
+		stored.ClientSecret = ""
+	}
 
-	s.clients[client.ClientID] = client
-	return nil
+	return s.clients.SaveClient(stored)
 }
 
 // UnregisterClient Unregisters an OAuth2 client | 注销OAuth2客户端
 func (s *OAuth2Server) UnregisterClient(clientID string) {
-	s.clientsMu.Lock()
-	defer s.clientsMu.Unlock()
+	s.clients.DeleteClient(clientID)
+}
+
+// ListClients Lists registered clients | 列出已注册的客户端
+func (s *OAuth2Server) ListClients() ([]*Client, error) {
+	return s.clients.ListClients()
+}
+
+// RotateClientSecret Issues a new secret, older secrets stay valid for overlap | 签发新密钥，旧密钥在overlap时间内仍然有效
+// The new plaintext secret is only returned here | 新的明文密钥仅在此返回
+func (s *OAuth2Server) RotateClientSecret(clientID string, overlap time.Duration) (string, error) {
+	client, err := s.GetClient(clientID)
+	if err != nil {
+		return "", err
+	}
+	if client.Public {
+		return "", ErrPublicClient
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+	hash, err := s.secretHasher.Hash(secret)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().Unix()
+	deadline := now + int64(overlap.Seconds())
+
+	// Legacy plaintext secret joins the rotation | 旧的明文密钥也参与轮换
+	if client.ClientSecret != "" {
+		legacyHash, err := s.secretHasher.Hash(client.ClientSecret)
+		if err != nil {
+			return "", err
+		}
+		client.Secrets = append(client.Secrets, ClientSecret{Hash: legacyHash, CreateTime: client.CreateTime})
+		client.ClientSecret = ""
+	}
+
+	// Cap old secrets at the overlap deadline and drop expired ones | 旧密钥最晚在重叠窗口结束时过期，并移除已过期的密钥
+	secrets := make([]ClientSecret, 0, len(client.Secrets)+1)
+	for _, old := range client.Secrets {
+		if old.ExpireTime == 0 || old.ExpireTime > deadline {
+			old.ExpireTime = deadline
+		}
+		if old.active(now) {
+			secrets = append(secrets, old)
+		}
+	}
+	client.Secrets = append(secrets, ClientSecret{Hash: hash, CreateTime: now})
+
+	if err := s.clients.SaveClient(client); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// SetPasswordVerifier Sets resource owner credential verifier for the password grant | 设置密码模式使用的资源所有者凭证校验器
+func (s *OAuth2Server) SetPasswordVerifier(verifier PasswordVerifier) {
+	s.verifyPassword = verifier
+}
+
+// SetRequirePKCE Sets whether every client must use PKCE, public clients always must | 设置是否所有客户端都必须使用PKCE，公开客户端始终必须使用
+func (s *OAuth2Server) SetRequirePKCE(require bool) {
+	s.requirePKCE = require
+}
 
-	delete(s.clients, clientID)
+// SetIDTokenIssuer Enables ID tokens for the openid scope | 为openid权限范围启用ID令牌
+func (s *OAuth2Server) SetIDTokenIssuer(issuer IDTokenIssuer) {
+	s.idTokenIssuer = issuer
 }
 
 // GetClient Gets client by ID | 根据ID获取客户端
 func (s *OAuth2Server) GetClient(clientID string) (*Client, error) {
-	s.clientsMu.RLock()
-	defer s.clientsMu.RUnlock()
-
-	client, exists := s.clients[clientID]
-	if !exists {
+	if clientID == "" {
 		return nil, ErrClientNotFound
 	}
-	return client, nil
+	return s.clients.GetClient(clientID)
 }
 
 // GenerateAuthorizationCode Generates authorization code | 生成授权码
 func (s *OAuth2Server) GenerateAuthorizationCode(clientID, redirectURI, userID string, scopes []string) (*AuthorizationCode, error) {
-	if userID == "" {
+	return s.GenerateAuthorizationCodeWithPKCE(clientID, redirectURI, userID, scopes, "", "")
+}
+
+// GenerateAuthorizationCodeWithPKCE Generates authorization code bound to a PKCE challenge | 生成绑定PKCE challenge的授权码
+// codeChallengeMethod defaults to plain when empty | codeChallengeMethod为空时默认为plain
+func (s *OAuth2Server) GenerateAuthorizationCodeWithPKCE(clientID, redirectURI, userID string, scopes []string, codeChallenge, codeChallengeMethod string) (*AuthorizationCode, error) {
+	return s.CreateAuthorizationCode(&AuthorizationRequest{
+		ClientID:            clientID,
+		RedirectURI:         redirectURI,
+		UserID:              userID,
+		Scopes:              scopes,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+	})
+}
+
+// CreateAuthorizationCode Generates authorization code for an approved request | 为已批准的授权请求生成授权码
+func (s *OAuth2Server) CreateAuthorizationCode(req *AuthorizationRequest) (*AuthorizationCode, error) {
+	if req.UserID == "" {
 		return nil, fmt.Errorf("userID cannot be empty")
 	}
 
-	client, err := s.GetClient(clientID)
+	client, err := s.GetClient(req.ClientID)
 	if err != nil {
 		return nil, err
 	}
 
+	if !client.AllowsGrantType(GrantTypeAuthorizationCode) {
+		return nil, ErrUnauthorizedClient
+	}
+
 	// Validate redirect URI | 验证回调URI
-	if !s.isValidRedirectURI(client, redirectURI) {
+	if !s.isValidRedirectURI(client, req.RedirectURI) {
 		return nil, ErrInvalidRedirectURI
 	}
 
+	grantedScopes, err := validateScopes(client, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate PKCE challenge | 验证PKCE challenge
+	codeChallenge, codeChallengeMethod := req.CodeChallenge, req.CodeChallengeMethod
+	if codeChallenge == "" {
+		if client.Public || s.requirePKCE {
+			return nil, ErrPKCERequired
+		}
+		codeChallengeMethod = ""
+	} else {
+		if codeChallengeMethod == "" {
+			codeChallengeMethod = CodeChallengeMethodPlain
+		}
+		if codeChallengeMethod != CodeChallengeMethodPlain && codeChallengeMethod != CodeChallengeMethodS256 {
+			return nil, ErrUnsupportedPKCEMethod
+		}
+		if !isValidPKCEValue(codeChallenge) {
+			return nil, ErrInvalidCodeChallenge
+		}
+	}
+
+	if len(req.Nonce) > maxNonceLength {
+		return nil, ErrInvalidNonce
+	}
+
 	// Generate code | 生成授权码
 	codeBytes := make([]byte, CodeLength)
 	if _, err := rand.Read(codeBytes); err != nil {
@@ -177,15 +487,27 @@ func (s *OAuth2Server) GenerateAuthorizationCode(clientID, redirectURI, userID s
 	}
 	code := hex.EncodeToString(codeBytes)
 
+	now := time.Now().Unix()
+	authTime := req.AuthTime
+	if authTime <= 0 {
+		authTime = now
+	}
+
 	authCode := &AuthorizationCode{
 		Code:        code,
-		ClientID:    clientID,
-		RedirectURI: redirectURI,
-		UserID:      userID,
-		Scopes:      scopes,
-		CreateTime:  time.Now().Unix(),
+		ClientID:    req.ClientID,
+		RedirectURI: req.RedirectURI,
+		UserID:      req.UserID,
+		Scopes:      grantedScopes,
+		CreateTime:  now,
 		ExpiresIn:   int64(s.codeExpiration.Seconds()),
 		Used:        false,
+
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+
+		Nonce:    req.Nonce,
+		AuthTime: authTime,
 	}
 
 	key := s.getCodeKey(code)
@@ -207,17 +529,14 @@ func (s *OAuth2Server) isValidRedirectURI(client *Client, redirectURI string) bo
 }
 
 // ExchangeCodeForToken Exchanges authorization code for access token | 用授权码换取访问令牌
-func (s *OAuth2Server) ExchangeCodeForToken(code, clientID, clientSecret, redirectURI string) (*AccessToken, error) {
+// codeVerifier: PKCE code_verifier, required when the code carries a challenge | PKCE code_verifier，授权码带有challenge时必须提供
+func (s *OAuth2Server) ExchangeCodeForToken(code, clientID, clientSecret, redirectURI string, codeVerifier ...string) (*AccessToken, error) {
 	// Verify client credentials | 验证客户端凭证
-	client, err := s.GetClient(clientID)
+	client, err := s.authenticateClient(clientID, clientSecret, GrantTypeAuthorizationCode)
 	if err != nil {
 		return nil, err
 	}
 
-	if client.ClientSecret != clientSecret {
-		return nil, ErrInvalidClientCredentials
-	}
-
 	// Get authorization code | 获取授权码
 	key := s.getCodeKey(code)
 	data, err := s.storage.Get(key)
@@ -225,8 +544,8 @@ func (s *OAuth2Server) ExchangeCodeForToken(code, clientID, clientSecret, redire
 		return nil, ErrInvalidAuthCode
 	}
 
-	authCode, ok := data.(*AuthorizationCode)
-	if !ok {
+	authCode := &AuthorizationCode{}
+	if err := decodeRecord(data, authCode); err != nil {
 		return nil, fmt.Errorf("invalid code data")
 	}
 
@@ -248,15 +567,170 @@ func (s *OAuth2Server) ExchangeCodeForToken(code, clientID, clientSecret, redire
 		return nil, ErrAuthCodeExpired
 	}
 
+	// Verify PKCE | 校验PKCE
+	var verifier string
+	if len(codeVerifier) > 0 {
+		verifier = codeVerifier[0]
+	}
+	if !verifyCodeChallenge(authCode, verifier) {
+		return nil, ErrInvalidCodeVerifier
+	}
+
 	// Mark code as used | 标记为已使用
 	authCode.Used = true
 	s.storage.Set(key, authCode, time.Minute)
 
-	return s.generateAccessToken(authCode.UserID, authCode.ClientID, authCode.Scopes)
+	return s.generateAccessToken(&tokenGrant{
+		userID:      authCode.UserID,
+		clientID:    authCode.ClientID,
+		scopes:      authCode.Scopes,
+		withRefresh: client.AllowsGrantType(GrantTypeRefreshToken),
+		nonce:       authCode.Nonce,
+		authTime:    authCode.AuthTime,
+	})
+}
+
+// ClientCredentialsToken Issues access token for the client itself (RFC 6749 4.4) | 为客户端自身签发访问令牌（RFC 6749 4.4）
+// No refresh token is issued and UserID is empty | 不签发刷新令牌，UserID为空
+func (s *OAuth2Server) ClientCredentialsToken(clientID, clientSecret string, scopes []string) (*AccessToken, error) {
+	client, err := s.authenticateClient(clientID, clientSecret, GrantTypeClientCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only confidential clients can act on their own behalf | 只有机密客户端可以以自身身份获取令牌
+	if client.Public {
+		return nil, ErrUnauthorizedClient
+	}
+
+	grantedScopes, err := validateScopes(client, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.generateAccessToken(&tokenGrant{clientID: clientID, scopes: grantedScopes})
+}
+
+// PasswordToken Issues access token with resource owner credentials (RFC 6749 4.3) | 使用资源所有者凭证签发访问令牌（RFC 6749 4.3）
+func (s *OAuth2Server) PasswordToken(clientID, clientSecret, username, password string, scopes []string) (*AccessToken, error) {
+	client, err := s.authenticateClient(clientID, clientSecret, GrantTypePassword)
+	if err != nil {
+		return nil, err
+	}
+
+	grantedScopes, err := validateScopes(client, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.verifyPassword == nil {
+		return nil, ErrPasswordVerifierNotSet
+	}
+
+	userID, err := s.verifyPassword(username, password)
+	if err != nil || userID == "" {
+		return nil, ErrInvalidUserCredentials
+	}
+
+	return s.generateAccessToken(&tokenGrant{
+		userID:      userID,
+		clientID:    clientID,
+		scopes:      grantedScopes,
+		withRefresh: client.AllowsGrantType(GrantTypeRefreshToken),
+		authTime:    time.Now().Unix(),
+	})
+}
+
+// authenticateClient Verifies client credentials and grant type | 验证客户端凭证和授权类型
+// Public clients are identified by client ID only | 公开客户端仅通过客户端ID识别
+func (s *OAuth2Server) authenticateClient(clientID, clientSecret string, grantType GrantType) (*Client, error) {
+	client, err := s.verifyClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.AllowsGrantType(grantType) {
+		return nil, ErrUnauthorizedClient
+	}
+
+	return client, nil
+}
+
+// validateScopes Checks requested scopes against the client's allowed scopes | 按客户端允许的权限范围校验请求的权限范围
+// Empty request falls back to all allowed scopes | 未请求时默认授予全部允许的权限范围
+func validateScopes(client *Client, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+
+	granted := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if scope == "" {
+			return nil, ErrInvalidScope
+		}
+		if (len(client.Scopes) > 0 || client.Dynamic) && !scopeCovered(client.Scopes, scope) {
+			return nil, ErrInvalidScope
+		}
+		granted = append(granted, scope)
+	}
+	return utils.UniqueStrings(granted), nil
+}
+
+// scopeCovered Checks if any granted scope matches the required one | 检查是否有已授予的权限范围匹配所需权限范围
+func scopeCovered(granted []string, scope string) bool {
+	for _, g := range granted {
+		if utils.MatchPermission(g, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyClient Verifies client credentials | 验证客户端凭证
+func (s *OAuth2Server) verifyClient(clientID, clientSecret string) (*Client, error) {
+	client, err := s.GetClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Public && !s.verifySecret(client, clientSecret) {
+		return nil, ErrInvalidClientCredentials
+	}
+
+	return client, nil
+}
+
+// verifySecret Checks secret against the client's active secrets in constant time | 以常量时间校验密钥是否匹配客户端的有效密钥
+func (s *OAuth2Server) verifySecret(client *Client, secret string) bool {
+	if secret == "" {
+		return false
+	}
+
+	now := time.Now().Unix()
+	for i := range client.Secrets {
+		if client.Secrets[i].active(now) && s.secretHasher.Verify(client.Secrets[i].Hash, secret) {
+			return true
+		}
+	}
+
+	// Plaintext secret of a client saved to the store directly | 直接写入存储的客户端的明文密钥
+	return client.ClientSecret != "" && subtle.ConstantTimeCompare([]byte(client.ClientSecret), []byte(secret)) == 1
+}
+
+// tokenGrant What a grant authorizes, input of generateAccessToken | 授权结果，generateAccessToken的输入
+type tokenGrant struct {
+	userID        string
+	clientID      string
+	scopes        []string
+	refreshScopes []string // Scopes kept by the refresh token, same as scopes when nil | 刷新令牌保留的权限范围，为nil时与scopes相同
+	withRefresh   bool
+	refreshTTL    time.Duration // Lifetime of the refresh token, DefaultRefreshTTL when zero | 刷新令牌的有效期，为0时使用DefaultRefreshTTL
+	nonce         string
+	authTime      int64
 }
 
-// generateAccessToken Generates access token and refresh token | 生成访问令牌和刷新令牌
-func (s *OAuth2Server) generateAccessToken(userID, clientID string, scopes []string) (*AccessToken, error) {
+// generateAccessToken Generates access token and optionally refresh token and ID token | 生成访问令牌，并可选生成刷新令牌和ID令牌
+func (s *OAuth2Server) generateAccessToken(grant *tokenGrant) (*AccessToken, error) {
 	// Generate access token | 生成访问令牌
 	tokenBytes := make([]byte, AccessTokenLength)
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -264,34 +738,63 @@ func (s *OAuth2Server) generateAccessToken(userID, clientID string, scopes []str
 	}
 	accessToken := hex.EncodeToString(tokenBytes)
 
-	// Generate refresh token | 生成刷新令牌
-	refreshBytes := make([]byte, RefreshTokenLength)
-	if _, err := rand.Read(refreshBytes); err != nil {
-		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	token := &AccessToken{
+		Token:     accessToken,
+		TokenType: TokenTypeBearer,
+		ExpiresIn: int64(s.tokenExpiration.Seconds()),
+		Scopes:    grant.scopes,
+		UserID:    grant.userID,
+		ClientID:  grant.clientID,
+		IssuedAt:  time.Now().Unix(),
+		AuthTime:  grant.authTime,
 	}
-	refreshToken := hex.EncodeToString(refreshBytes)
 
-	token := &AccessToken{
-		Token:        accessToken,
-		TokenType:    TokenTypeBearer,
-		ExpiresIn:    int64(s.tokenExpiration.Seconds()),
-		RefreshToken: refreshToken,
-		Scopes:       scopes,
-		UserID:       userID,
-		ClientID:     clientID,
+	// Issue ID token for the openid scope | 为openid权限范围签发ID令牌
+	if s.idTokenIssuer != nil && token.UserID != "" && utils.ContainsString(token.Scopes, ScopeOpenID) {
+		idToken, err := s.idTokenIssuer.IssueIDToken(token, grant.nonce)
+		if err != nil {
+			return nil, fmt.Errorf("failed to issue id token: %w", err)
+		}
+		token.IDToken = idToken
 	}
 
-	tokenKey := s.getTokenKey(accessToken)
-	refreshKey := s.getRefreshKey(refreshToken)
+	// Generate refresh token | 生成刷新令牌
+	if grant.withRefresh {
+		refreshBytes := make([]byte, RefreshTokenLength)
+		if _, err := rand.Read(refreshBytes); err != nil {
+			return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+		}
+		token.RefreshToken = hex.EncodeToString(refreshBytes)
+	}
 
 	// Store access token | 存储访问令牌
-	if err := s.storage.Set(tokenKey, token, s.tokenExpiration); err != nil {
+	if err := s.storage.Set(s.getTokenKey(accessToken), token, s.tokenExpiration); err != nil {
 		return nil, fmt.Errorf("failed to store access token: %w", err)
 	}
 
-	// Store refresh token | 存储刷新令牌
-	if err := s.storage.Set(refreshKey, token, DefaultRefreshTTL); err != nil {
-		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	refreshTTL := grant.refreshTTL
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+
+	// Store refresh token, keeping the originally granted scopes | 存储刷新令牌，保留最初授予的权限范围
+	if token.RefreshToken != "" {
+		refreshRecord := token
+		if grant.refreshScopes != nil {
+			copied := *token
+			copied.Scopes = grant.refreshScopes
+			refreshRecord = &copied
+		}
+		if err := s.storage.Set(s.getRefreshKey(token.RefreshToken), refreshRecord, refreshTTL); err != nil {
+			return nil, fmt.Errorf("failed to store refresh token: %w", err)
+		}
+	}
+
+	// Index user tokens so revoking consent can find them | 索引用户令牌，以便撤销授权同意时查找
+	if token.UserID != "" {
+		if err := s.indexGrantToken(token, refreshTTL); err != nil {
+			return nil, fmt.Errorf("failed to index access token: %w", err)
+		}
 	}
 
 	return token, nil
@@ -309,47 +812,92 @@ func (s *OAuth2Server) ValidateAccessToken(tokenString string) (*AccessToken, er
 		return nil, ErrInvalidAccessToken
 	}
 
-	token, ok := data.(*AccessToken)
-	if !ok {
+	token := &AccessToken{}
+	if err := decodeRecord(data, token); err != nil {
 		return nil, ErrInvalidTokenData
 	}
 
 	return token, nil
 }
 
-// RefreshAccessToken Refreshes access token using refresh token | 使用刷新令牌刷新访问令牌
-func (s *OAuth2Server) RefreshAccessToken(refreshToken, clientID, clientSecret string) (*AccessToken, error) {
-	// Verify client credentials | 验证客户端凭证
-	client, err := s.GetClient(clientID)
+// ValidateAccessTokenWithScope Validates access token and checks it covers all required scopes | 验证访问令牌并检查是否覆盖所有所需权限范围
+func (s *OAuth2Server) ValidateAccessTokenWithScope(tokenString string, requiredScopes ...string) (*AccessToken, error) {
+	token, err := s.ValidateAccessToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if client.ClientSecret != clientSecret {
-		return nil, ErrInvalidClientCredentials
+	for _, scope := range requiredScopes {
+		if !scopeCovered(token.Scopes, scope) {
+			return nil, ErrInsufficientScope
+		}
 	}
 
-	// Get refresh token | 获取刷新令牌
-	key := s.getRefreshKey(refreshToken)
-	data, err := s.storage.Get(key)
-	if err != nil || data == nil {
-		return nil, fmt.Errorf("invalid refresh token")
+	return token, nil
+}
+
+// RefreshAccessToken Refreshes access token using refresh token | 使用刷新令牌刷新访问令牌
+// scopes: optional subset of the original scopes, the refresh token keeps the original ones | 可选，原权限范围的子集，刷新令牌保留原权限范围
+func (s *OAuth2Server) RefreshAccessToken(refreshToken, clientID, clientSecret string, scopes ...string) (*AccessToken, error) {
+	// Verify client credentials | 验证客户端凭证
+	if _, err := s.authenticateClient(clientID, clientSecret, GrantTypeRefreshToken); err != nil {
+		return nil, err
 	}
 
-	oldToken, ok := data.(*AccessToken)
-	if !ok {
-		return nil, fmt.Errorf("invalid refresh token data")
+	// Get refresh token | 获取刷新令牌
+	oldToken, err := s.loadRefreshRecord(refreshToken)
+	if err != nil {
+		return nil, err
 	}
 
 	if oldToken.ClientID != clientID {
 		return nil, ErrClientMismatch
 	}
 
-	// Delete old access token | 删除旧的访问令牌
-	oldTokenKey := s.getTokenKey(oldToken.Token)
-	s.storage.Delete(oldTokenKey)
+	// Narrow scopes, never widen them (RFC 6749 6) | 只能缩小权限范围，不能扩大（RFC 6749 6）
+	grantedScopes := oldToken.Scopes
+	if len(scopes) > 0 {
+		for _, scope := range scopes {
+			if !scopeCovered(oldToken.Scopes, scope) {
+				return nil, ErrInvalidScope
+			}
+		}
+		grantedScopes = utils.UniqueStrings(scopes)
+	}
+
+	// The new refresh token expires with the old one, rotation never extends the chain | 新的刷新令牌与旧令牌同时过期，轮换不会延长令牌链
+	refreshTTL, err := s.storage.TTL(s.getRefreshKey(refreshToken))
+	if err != nil || refreshTTL <= 0 || refreshTTL > DefaultRefreshTTL {
+		refreshTTL = DefaultRefreshTTL
+	}
+
+	// Consume atomically, only one concurrent request wins the rotation | 原子地消费刷新令牌，并发请求中只有一个能完成轮换
+	claimed, err := adapter.SetNX(s.storage, s.getRefreshUsedKey(refreshToken), oldToken.ClientID, refreshTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+	if !claimed {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Drop the refresh token and the old access token, a new pair is issued (RFC 6749 6) | 删除刷新令牌和旧的访问令牌，签发新的令牌对（RFC 6749 6）
+	if err := s.storage.Delete(
+		s.getRefreshKey(refreshToken),
+		s.getTokenKey(oldToken.Token),
+		s.getGrantKey(oldToken.UserID, oldToken.ClientID, oldToken.Token),
+	); err != nil {
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
 
-	return s.generateAccessToken(oldToken.UserID, oldToken.ClientID, oldToken.Scopes)
+	return s.generateAccessToken(&tokenGrant{
+		userID:        oldToken.UserID,
+		clientID:      oldToken.ClientID,
+		scopes:        grantedScopes,
+		refreshScopes: oldToken.Scopes,
+		withRefresh:   true,
+		refreshTTL:    refreshTTL,
+		authTime:      oldToken.AuthTime,
+	})
 }
 
 // RevokeToken Revokes access token and its refresh token | 撤销访问令牌及其刷新令牌
@@ -365,7 +913,8 @@ func (s *OAuth2Server) RevokeToken(tokenString string) error {
 	}
 
 	// Revoke refresh token if exists | 如果存在则撤销刷新令牌
-	if token, ok := data.(*AccessToken); ok && token.RefreshToken != "" {
+	token := &AccessToken{}
+	if decodeRecord(data, token) == nil && token.RefreshToken != "" {
 		refreshKey := s.getRefreshKey(token.RefreshToken)
 		s.storage.Delete(refreshKey)
 	}
@@ -373,8 +922,89 @@ func (s *OAuth2Server) RevokeToken(tokenString string) error {
 	return s.storage.Delete(key)
 }
 
+// RevokeRefreshToken Revokes refresh token and the access token issued with it | 撤销刷新令牌及与其一同签发的访问令牌
+func (s *OAuth2Server) RevokeRefreshToken(refreshToken string) error {
+	record, err := s.loadRefreshRecord(refreshToken)
+	if err != nil {
+		return err
+	}
+
+	s.storage.Delete(s.getTokenKey(record.Token))
+	return s.storage.Delete(s.getRefreshKey(refreshToken))
+}
+
 // ============ Helper Methods | 辅助方法 ============
 
+// loadRefreshRecord Loads the token record stored under a refresh token | 加载刷新令牌对应的令牌记录
+func (s *OAuth2Server) loadRefreshRecord(refreshToken string) (*AccessToken, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	data, err := s.storage.Get(s.getRefreshKey(refreshToken))
+	if err != nil || data == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	record := &AccessToken{}
+	if err := decodeRecord(data, record); err != nil {
+		return nil, ErrInvalidTokenData
+	}
+	return record, nil
+}
+
+// verifyCodeChallenge Checks code_verifier against the stored challenge (RFC 7636 4.6) | 按存储的challenge校验code_verifier（RFC 7636 4.6）
+func verifyCodeChallenge(authCode *AuthorizationCode, verifier string) bool {
+	if authCode.CodeChallenge == "" {
+		// A verifier without a challenge indicates a tampered request | 没有challenge却带有verifier说明请求被篡改
+		return verifier == ""
+	}
+	if !isValidPKCEValue(verifier) {
+		return false
+	}
+
+	expected := verifier
+	if authCode.CodeChallengeMethod == CodeChallengeMethodS256 {
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(authCode.CodeChallenge)) == 1
+}
+
+// isValidPKCEValue Checks length and charset of code_verifier / code_challenge | 检查code_verifier / code_challenge的长度和字符集
+func isValidPKCEValue(value string) bool {
+	if len(value) < minPKCELength || len(value) > maxPKCELength {
+		return false
+	}
+	for _, c := range value {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case c == '-', c == '.', c == '_', c == '~':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// decodeRecord Decodes a stored record into v | 将存储的记录解码到v
+// Memory storage returns the stored pointer, Redis returns its JSON string | 内存存储返回原指针，Redis返回其JSON字符串
+func decodeRecord(data any, v encoding.BinaryUnmarshaler) error {
+	var (
+		raw []byte
+		err error
+	)
+	if marshaler, ok := data.(encoding.BinaryMarshaler); ok {
+		raw, err = marshaler.MarshalBinary()
+	} else {
+		raw, err = utils.ToBytes(data)
+	}
+	if err != nil {
+		return err
+	}
+	return v.UnmarshalBinary(raw)
+}
+
 // getCodeKey Gets storage key for authorization code | 获取授权码的存储键
 func (s *OAuth2Server) getCodeKey(code string) string {
 	return s.keyPrefix + CodeKeySuffix + code
@@ -389,3 +1019,8 @@ func (s *OAuth2Server) getTokenKey(token string) string {
 func (s *OAuth2Server) getRefreshKey(refreshToken string) string {
 	return s.keyPrefix + RefreshKeySuffix + refreshToken
 }
+
+// getRefreshUsedKey Gets the consumed marker key of a refresh token | 获取刷新令牌的已消费标记键
+func (s *OAuth2Server) getRefreshUsedKey(refreshToken string) string {
+	return s.keyPrefix + RefreshUsedKeySuffix + refreshToken
+}
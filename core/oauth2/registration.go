@@ -0,0 +1,169 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Dynamic Client Registration (RFC 7591) | 动态客户端注册（RFC 7591）
+
+// Token endpoint authentication methods (RFC 7591 2) | 令牌端点认证方式
+const (
+	AuthMethodNone              = "none"                // Public client | 公开客户端
+	AuthMethodClientSecretBasic = "client_secret_basic" // HTTP Basic | HTTP Basic认证
+	AuthMethodClientSecretPost  = "client_secret_post"  // Form body | 表单参数
+
+	ClientIDLength = 16 // Generated client ID byte length | 生成的客户端ID字节长度
+)
+
+// Registration errors | 注册错误
+var (
+	ErrInvalidClientMetadata = fmt.Errorf("invalid client metadata")
+)
+
+// ClientRegistrationRequest Client metadata sent to the registration endpoint | 发送到注册端点的客户端元数据
+type ClientRegistrationRequest struct {
+	RedirectURIs            []string    `json:"redirect_uris,omitempty"`
+	GrantTypes              []GrantType `json:"grant_types,omitempty"`
+	Scope                   string      `json:"scope,omitempty"`
+	TokenEndpointAuthMethod string      `json:"token_endpoint_auth_method,omitempty"`
+	ClientName              string      `json:"client_name,omitempty"`
+}
+
+// ClientRegistrationResponse Registered client information (RFC 7591 3.2.1) | 已注册客户端信息
+type ClientRegistrationResponse struct {
+	ClientID                string      `json:"client_id"`
+	ClientSecret            string      `json:"client_secret,omitempty"`
+	ClientIDIssuedAt        int64       `json:"client_id_issued_at"`
+	ClientSecretExpiresAt   int64       `json:"client_secret_expires_at"` // 0 means never | 0表示永不过期
+	RedirectURIs            []string    `json:"redirect_uris,omitempty"`
+	GrantTypes              []GrantType `json:"grant_types"`
+	Scope                   string      `json:"scope,omitempty"`
+	TokenEndpointAuthMethod string      `json:"token_endpoint_auth_method"`
+	ClientName              string      `json:"client_name,omitempty"`
+}
+
+// SetRegistrationScopes Sets scopes dynamically registered clients may request, wildcards supported | 设置动态注册客户端可请求的权限范围，支持通配符
+// Registered clients default to all of them; when unset they get no scopes | 默认授予全部；未设置时不授予任何权限范围
+func (s *OAuth2Server) SetRegistrationScopes(scopes []string) {
+	s.registrationScopes = scopes
+}
+
+// RegisterDynamicClient Registers a client from RFC 7591 metadata | 根据RFC 7591元数据注册客户端
+// The plaintext secret is only returned in the response | 明文密钥仅在响应中返回
+func (s *OAuth2Server) RegisterDynamicClient(req *ClientRegistrationRequest) (*ClientRegistrationResponse, error) {
+	if req == nil {
+		return nil, ErrInvalidClientMetadata
+	}
+
+	authMethod := req.TokenEndpointAuthMethod
+	if authMethod == "" {
+		authMethod = AuthMethodClientSecretBasic
+	}
+	if authMethod != AuthMethodNone && authMethod != AuthMethodClientSecretBasic && authMethod != AuthMethodClientSecretPost {
+		return nil, fmt.Errorf("%w: unsupported token_endpoint_auth_method", ErrInvalidClientMetadata)
+	}
+	public := authMethod == AuthMethodNone
+
+	grantTypes := req.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = DefaultGrantTypes
+	}
+	for _, gt := range grantTypes {
+		switch gt {
+		case GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeDeviceCode:
+		case GrantTypePassword:
+			return nil, fmt.Errorf("%w: dynamically registered clients cannot use password", ErrInvalidClientMetadata)
+		case GrantTypeClientCredentials:
+			if public {
+				return nil, fmt.Errorf("%w: public clients cannot use client_credentials", ErrInvalidClientMetadata)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported grant type %s", ErrInvalidClientMetadata, gt)
+		}
+	}
+
+	client := &Client{
+		GrantTypes:   append([]GrantType(nil), grantTypes...),
+		RedirectURIs: req.RedirectURIs,
+		Public:       public,
+		Dynamic:      true,
+		Name:         req.ClientName,
+	}
+	if client.AllowsGrantType(GrantTypeAuthorizationCode) && len(client.RedirectURIs) == 0 {
+		return nil, fmt.Errorf("%w: redirect_uris required", ErrInvalidRedirectURI)
+	}
+	for _, uri := range client.RedirectURIs {
+		if !isValidRegisteredURI(uri) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRedirectURI, uri)
+		}
+	}
+
+	scopes, err := s.registrationScopesFor(strings.Fields(req.Scope))
+	if err != nil {
+		return nil, err
+	}
+	client.Scopes = scopes
+
+	idBytes := make([]byte, ClientIDLength)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate client id: %w", err)
+	}
+	client.ClientID = hex.EncodeToString(idBytes)
+	client.CreateTime = time.Now().Unix()
+
+	secret := ""
+	if !public {
+		if secret, err = generateSecret(); err != nil {
+			return nil, err
+		}
+		client.ClientSecret = secret
+	}
+
+	if err := s.RegisterClient(client); err != nil {
+		return nil, err
+	}
+
+	return &ClientRegistrationResponse{
+		ClientID:                client.ClientID,
+		ClientSecret:            secret,
+		ClientIDIssuedAt:        client.CreateTime,
+		RedirectURIs:            client.RedirectURIs,
+		GrantTypes:              client.GrantTypes,
+		Scope:                   strings.Join(client.Scopes, " "),
+		TokenEndpointAuthMethod: authMethod,
+		ClientName:              client.Name,
+	}, nil
+}
+
+// registrationScopesFor Checks requested scopes against the registration allow-list | 按注册允许列表校验请求的权限范围
+func (s *OAuth2Server) registrationScopesFor(requested []string) ([]string, error) {
+	if len(s.registrationScopes) == 0 {
+		if len(requested) > 0 {
+			return nil, fmt.Errorf("%w: no scopes open to registration", ErrInvalidClientMetadata)
+		}
+		return nil, nil
+	}
+	if len(requested) == 0 {
+		return append([]string(nil), s.registrationScopes...), nil
+	}
+	for _, scope := range requested {
+		if !scopeCovered(s.registrationScopes, scope) {
+			return nil, fmt.Errorf("%w: scope %s not allowed", ErrInvalidClientMetadata, scope)
+		}
+	}
+	return requested, nil
+}
+
+// isValidRegisteredURI Checks a redirect URI is absolute without fragment (RFC 6749 3.1.2) | 检查回调URI为不带片段的绝对URI
+func isValidRegisteredURI(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return false
+	}
+	return u.Scheme != "" && u.Fragment == "" && (u.Host != "" || u.Opaque != "" || u.Path != "")
+}
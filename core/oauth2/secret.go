@@ -0,0 +1,129 @@
+package oauth2
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Client secret hashing | 客户端密钥哈希
+//
+// Secrets are stored as "$pbkdf2-sha256$<iterations>$<salt>$<hash>" so the cost can be raised later
+// without invalidating existing secrets. Plug in bcrypt / argon2 via SetSecretHasher.
+// 密钥以 "$pbkdf2-sha256$<迭代次数>$<盐>$<哈希>" 格式存储，之后提高迭代次数不会使已有密钥失效。
+// 可通过SetSecretHasher接入bcrypt / argon2。
+
+// Constants for secret hashing | 密钥哈希常量
+const (
+	DefaultSecretHashIterations = 10000 // PBKDF2 iterations, client secrets are random so a moderate cost suffices | PBKDF2迭代次数，客户端密钥是随机生成的，中等开销即可
+
+	pbkdf2Scheme    = "pbkdf2-sha256"
+	secretSaltBytes = 16
+	secretHashBytes = 32
+)
+
+// SecretHasher Hashes and verifies client secrets | 哈希并校验客户端密钥
+type SecretHasher interface {
+	// Hash returns a self-describing hash of secret | 返回密钥的自描述哈希
+	Hash(secret string) (string, error)
+	// Verify checks secret against hashed in constant time | 以常量时间校验密钥
+	Verify(hashed, secret string) bool
+}
+
+// PBKDF2Hasher PBKDF2-HMAC-SHA256 secret hasher | PBKDF2-HMAC-SHA256密钥哈希器
+type PBKDF2Hasher struct {
+	Iterations int // Iterations for new hashes | 新哈希的迭代次数
+}
+
+// NewPBKDF2Hasher Creates a PBKDF2 hasher, DefaultSecretHashIterations when iterations <= 0 | 创建PBKDF2哈希器，iterations <= 0时使用DefaultSecretHashIterations
+func NewPBKDF2Hasher(iterations int) *PBKDF2Hasher {
+	if iterations <= 0 {
+		iterations = DefaultSecretHashIterations
+	}
+	return &PBKDF2Hasher{Iterations: iterations}
+}
+
+// Hash Hashes secret with a random salt | 使用随机盐哈希密钥
+func (h *PBKDF2Hasher) Hash(secret string) (string, error) {
+	salt := make([]byte, secretSaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	sum := pbkdf2SHA256([]byte(secret), salt, h.Iterations, secretHashBytes)
+	return fmt.Sprintf("$%s$%d$%s$%s",
+		pbkdf2Scheme,
+		h.Iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(sum),
+	), nil
+}
+
+// Verify Checks secret against a hash produced by Hash | 校验由Hash生成的哈希
+func (h *PBKDF2Hasher) Verify(hashed, secret string) bool {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != pbkdf2Scheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[2])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	sum := pbkdf2SHA256([]byte(secret), salt, iterations, len(expected))
+	return subtle.ConstantTimeCompare(sum, expected) == 1
+}
+
+// pbkdf2SHA256 Derives a key with PBKDF2-HMAC-SHA256 (RFC 8018 5.2) | 使用PBKDF2-HMAC-SHA256派生密钥
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var counter [4]byte
+	derived := make([]byte, 0, blocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Write(counter[:])
+		derived = prf.Sum(derived)
+
+		t := derived[len(derived)-hashLen:]
+		copy(u, t)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return derived[:keyLen]
+}
+
+// generateSecret Generates a random client secret | 生成随机客户端密钥
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
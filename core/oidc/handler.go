@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/oauth2"
+)
+
+// OIDC HTTP endpoints, responses are written by integrations like oauth2.Handler
+// OIDC HTTP端点，响应与oauth2.Handler一样由各框架集成写出
+//
+// Endpoints | 端点:
+//   GET  /.well-known/openid-configuration - Discovery document | 发现文档
+//   GET  /oauth2/jwks                      - Signing keys | 签名密钥
+//   GET  /oauth2/userinfo                  - UserInfo (POST allowed) | 用户信息（也支持POST）
+
+// Endpoint paths | 端点路径
+const (
+	DiscoveryPath = "/.well-known/openid-configuration"
+	JWKSPath      = "/oauth2/jwks"
+	UserInfoPath  = "/oauth2/userinfo"
+
+	ErrorInsufficientScope = "insufficient_scope" // RFC 6750 3.1 | RFC 6750 3.1
+)
+
+// DiscoveryEndpoint Serves the discovery document | 提供发现文档
+func (p *Provider) DiscoveryEndpoint(ctx adapter.RequestContext) *oauth2.Response {
+	return &oauth2.Response{Status: http.StatusOK, Body: p.Discovery()}
+}
+
+// JWKSEndpoint Serves the public signing keys | 提供公开的签名密钥
+func (p *Provider) JWKSEndpoint(ctx adapter.RequestContext) *oauth2.Response {
+	return &oauth2.Response{Status: http.StatusOK, Body: p.JWKS()}
+}
+
+// UserInfoEndpoint Serves claims for the Bearer access token (OIDC Core 5.3) | 为Bearer访问令牌提供用户声明
+func (p *Provider) UserInfoEndpoint(ctx adapter.RequestContext) *oauth2.Response {
+	auth := ctx.GetHeader("Authorization")
+	if len(auth) <= 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return &oauth2.Response{
+			Status: http.StatusUnauthorized,
+			Header: map[string]string{"WWW-Authenticate": `Bearer realm="userinfo"`},
+		}
+	}
+
+	info, err := p.UserInfo(strings.TrimSpace(auth[7:]))
+	switch {
+	case err == nil:
+		return &oauth2.Response{
+			Status: http.StatusOK,
+			Header: map[string]string{"Cache-Control": "no-store"},
+			Body:   info,
+		}
+	case errors.Is(err, oauth2.ErrInsufficientScope):
+		return bearerError(http.StatusForbidden, ErrorInsufficientScope, "openid scope required")
+	case errors.Is(err, oauth2.ErrInvalidAccessToken), errors.Is(err, oauth2.ErrInvalidTokenData):
+		return bearerError(http.StatusUnauthorized, oauth2.ErrorInvalidToken, err.Error())
+	default:
+		return &oauth2.Response{
+			Status: http.StatusInternalServerError,
+			Body:   &oauth2.ErrorResponse{Error: oauth2.ErrorServerError, ErrorDescription: err.Error()},
+		}
+	}
+}
+
+// bearerError Builds a Bearer token error response (RFC 6750 3) | 构建Bearer令牌错误响应
+func bearerError(status int, code, description string) *oauth2.Response {
+	return &oauth2.Response{
+		Status: status,
+		Header: map[string]string{"WWW-Authenticate": `Bearer error="` + code + `"`},
+		Body:   &oauth2.ErrorResponse{Error: code, ErrorDescription: description},
+	}
+}
@@ -0,0 +1,329 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OpenID Connect provider on top of oauth2.OAuth2Server
+// 基于oauth2.OAuth2Server的OpenID Connect提供方
+//
+// Flow | 流程:
+// 1. NewProvider() - Registers itself as the server's IDTokenIssuer | 注册为服务器的IDTokenIssuer
+// 2. Authorize with scope "openid" (and nonce) - The code remembers nonce and auth_time | 使用"openid"权限范围（及nonce）授权，授权码记录nonce和auth_time
+// 3. Token endpoint - Returns id_token next to the access token | 令牌端点在访问令牌之外返回id_token
+// 4. UserInfo() - Claims of the token's user, filtered by granted scopes | 令牌用户的声明，按授予的权限范围过滤
+//
+// ID tokens are signed with RS256, keys are published through JWKS() and Discovery().
+// ID令牌使用RS256签名，密钥通过JWKS()和Discovery()公开。
+//
+// Usage | 用法:
+//   provider, _ := oidc.NewProvider(server, "https://sso.example.com", privateKey)
+//   provider.SetClaimsProvider(func(userID string) (map[string]any, error) {...})
+
+// Constants for OIDC | OIDC常量
+const (
+	DefaultIDTokenExpiration = time.Hour // ID token expiration | ID令牌过期时间
+	DefaultKeyBits           = 2048      // Generated RSA key size | 生成的RSA密钥位数
+
+	SigningAlgorithm = "RS256" // ID token signing algorithm | ID令牌签名算法
+
+	ScopeProfile = "profile" // Profile claims scope | 个人资料声明权限范围
+	ScopeEmail   = "email"   // Email claims scope | 邮箱声明权限范围
+	ScopePhone   = "phone"   // Phone claims scope | 电话声明权限范围
+	ScopeAddress = "address" // Address claims scope | 地址声明权限范围
+)
+
+// Error variables | 错误变量
+var (
+	ErrIssuerRequired = fmt.Errorf("issuer is required")
+	ErrInvalidIDToken = fmt.Errorf("invalid id token")
+)
+
+// ScopeClaims Standard claims released by each scope (OIDC Core 5.4) | 各权限范围释放的标准声明
+var ScopeClaims = map[string][]string{
+	ScopeProfile: {
+		"name", "family_name", "given_name", "middle_name", "nickname", "preferred_username",
+		"profile", "picture", "website", "gender", "birthdate", "zoneinfo", "locale", "updated_at",
+	},
+	ScopeEmail:   {"email", "email_verified"},
+	ScopePhone:   {"phone_number", "phone_number_verified"},
+	ScopeAddress: {"address"},
+}
+
+// ClaimsProvider Returns the user's claims, the provider filters them by scope | 返回用户的声明，由提供方按权限范围过滤
+type ClaimsProvider func(userID string) (map[string]any, error)
+
+// IDTokenClaims Claims of an ID token (OIDC Core 2) | ID令牌的声明
+type IDTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce    string `json:"nonce,omitempty"`     // Nonce from the authorization request | 授权请求中的nonce
+	AuthTime int64  `json:"auth_time,omitempty"` // Time the user authenticated | 用户认证时间
+	AtHash   string `json:"at_hash,omitempty"`   // Access token hash | 访问令牌哈希
+	Azp      string `json:"azp,omitempty"`       // Authorized party | 授权方
+}
+
+// Provider OpenID Connect provider | OpenID Connect提供方
+type Provider struct {
+	server            *oauth2.OAuth2Server
+	issuer            string // Issuer identifier, base URL of the endpoints | 签发者标识，也是端点的基础URL
+	keyID             string
+	signingKey        *rsa.PrivateKey
+	idTokenExpiration time.Duration
+	claims            ClaimsProvider
+}
+
+// NewProvider Creates an OIDC provider and enables ID tokens on server | 创建OIDC提供方并为服务器启用ID令牌
+// key: RS256 signing key, a random key is generated when nil (tokens break on restart and across nodes) | RS256签名密钥，为nil时随机生成（重启或多节点时令牌失效）
+func NewProvider(server *oauth2.OAuth2Server, issuer string, key *rsa.PrivateKey) (*Provider, error) {
+	if issuer == "" {
+		return nil, ErrIssuerRequired
+	}
+
+	if key == nil {
+		generated, err := rsa.GenerateKey(rand.Reader, DefaultKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		key = generated
+	}
+
+	p := &Provider{
+		server:            server,
+		issuer:            strings.TrimSuffix(issuer, "/"),
+		idTokenExpiration: DefaultIDTokenExpiration,
+	}
+	p.SetSigningKey(key, "")
+	server.SetIDTokenIssuer(p)
+
+	return p, nil
+}
+
+// SetSigningKey Replaces the signing key, keyID defaults to the RFC 7638 thumbprint | 替换签名密钥，keyID默认为RFC 7638指纹
+func (p *Provider) SetSigningKey(key *rsa.PrivateKey, keyID string) {
+	if keyID == "" {
+		keyID = thumbprint(&key.PublicKey)
+	}
+	p.signingKey = key
+	p.keyID = keyID
+}
+
+// SetIDTokenExpiration Sets ID token expiration | 设置ID令牌过期时间
+func (p *Provider) SetIDTokenExpiration(expiration time.Duration) {
+	p.idTokenExpiration = expiration
+}
+
+// SetClaimsProvider Sets where userinfo claims come from | 设置userinfo声明的来源
+func (p *Provider) SetClaimsProvider(provider ClaimsProvider) {
+	p.claims = provider
+}
+
+// GetIssuer Gets the issuer identifier | 获取签发者标识
+func (p *Provider) GetIssuer() string {
+	return p.issuer
+}
+
+// ============ ID Token | ID令牌 ============
+
+// IssueIDToken Signs the ID token for an access token, implements oauth2.IDTokenIssuer | 为访问令牌签发ID令牌，实现oauth2.IDTokenIssuer
+func (p *Provider) IssueIDToken(token *oauth2.AccessToken, nonce string) (string, error) {
+	issuedAt := time.Now()
+	if token.IssuedAt > 0 {
+		issuedAt = time.Unix(token.IssuedAt, 0)
+	}
+
+	claims := &IDTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    p.issuer,
+			Subject:   token.UserID,
+			Audience:  jwt.ClaimStrings{token.ClientID},
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(issuedAt.Add(p.idTokenExpiration)),
+		},
+		Nonce:    nonce,
+		AuthTime: token.AuthTime,
+		AtHash:   AccessTokenHash(token.Token),
+		Azp:      token.ClientID,
+	}
+
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	jwtToken.Header["kid"] = p.keyID
+
+	signed, err := jwtToken.SignedString(p.signingKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign id token: %w", err)
+	}
+	return signed, nil
+}
+
+// VerifyIDToken Verifies signature, issuer, audience and expiration of an ID token | 校验ID令牌的签名、签发者、受众和过期时间
+func (p *Provider) VerifyIDToken(idToken, clientID string) (*IDTokenClaims, error) {
+	claims := &IDTokenClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (any, error) {
+		return &p.signingKey.PublicKey, nil
+	},
+		jwt.WithValidMethods([]string{SigningAlgorithm}),
+		jwt.WithIssuer(p.issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	return claims, nil
+}
+
+// AccessTokenHash Computes at_hash, the left half of SHA-256 of the access token (OIDC Core 3.1.3.6) | 计算at_hash，即访问令牌SHA-256的左半部分
+func AccessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(sum[:len(sum)/2])
+}
+
+// ============ UserInfo | 用户信息 ============
+
+// UserInfo Returns claims of the token's user, filtered by its scopes (OIDC Core 5.3) | 返回令牌用户的声明，按令牌权限范围过滤
+// Errors come from ValidateAccessTokenWithScope, ErrInsufficientScope without the openid scope | 错误来自ValidateAccessTokenWithScope，缺少openid权限范围时返回ErrInsufficientScope
+func (p *Provider) UserInfo(accessToken string) (map[string]any, error) {
+	token, err := p.server.ValidateAccessTokenWithScope(accessToken, oauth2.ScopeOpenID)
+	if err != nil {
+		return nil, err
+	}
+	if token.UserID == "" {
+		// Client credentials tokens have no end user | 客户端凭证令牌没有终端用户
+		return nil, oauth2.ErrInvalidAccessToken
+	}
+
+	info := map[string]any{"sub": token.UserID}
+	if p.claims == nil {
+		return info, nil
+	}
+
+	claims, err := p.claims(token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range token.Scopes {
+		for _, name := range ScopeClaims[scope] {
+			if value, ok := claims[name]; ok {
+				info[name] = value
+			}
+		}
+	}
+	return info, nil
+}
+
+// ============ Discovery | 发现 ============
+
+// DiscoveryDocument OpenID provider metadata (OIDC Discovery 3) | OpenID提供方元数据
+type DiscoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	DeviceAuthorizationEndpoint       string   `json:"device_authorization_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// JSONWebKey Public signing key (RFC 7517) | 公开的签名密钥
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JSONWebKeySet Key set served at the jwks_uri | jwks_uri提供的密钥集
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Discovery Builds the discovery document | 构建发现文档
+func (p *Provider) Discovery() *DiscoveryDocument {
+	claims := []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "at_hash"}
+	for _, scope := range []string{ScopeProfile, ScopeEmail, ScopePhone, ScopeAddress} {
+		claims = append(claims, ScopeClaims[scope]...)
+	}
+
+	return &DiscoveryDocument{
+		Issuer:                      p.issuer,
+		AuthorizationEndpoint:       p.issuer + oauth2.AuthorizePath,
+		TokenEndpoint:               p.issuer + oauth2.TokenPath,
+		UserInfoEndpoint:            p.issuer + UserInfoPath,
+		JWKSURI:                     p.issuer + JWKSPath,
+		RevocationEndpoint:          p.issuer + oauth2.RevokePath,
+		IntrospectionEndpoint:       p.issuer + oauth2.IntrospectPath,
+		DeviceAuthorizationEndpoint: p.issuer + oauth2.DeviceAuthorizationPath,
+		ScopesSupported:             []string{oauth2.ScopeOpenID, ScopeProfile, ScopeEmail, ScopePhone, ScopeAddress},
+		ResponseTypesSupported:      []string{"code"},
+		GrantTypesSupported: []string{
+			string(oauth2.GrantTypeAuthorizationCode),
+			string(oauth2.GrantTypeRefreshToken),
+			string(oauth2.GrantTypeClientCredentials),
+			string(oauth2.GrantTypePassword),
+			string(oauth2.GrantTypeDeviceCode),
+		},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{SigningAlgorithm},
+		TokenEndpointAuthMethodsSupported: []string{
+			oauth2.AuthMethodClientSecretBasic,
+			oauth2.AuthMethodClientSecretPost,
+			oauth2.AuthMethodNone,
+		},
+		CodeChallengeMethodsSupported: []string{oauth2.CodeChallengeMethodPlain, oauth2.CodeChallengeMethodS256},
+		ClaimsSupported:               claims,
+	}
+}
+
+// JWKS Builds the public key set | 构建公钥集
+func (p *Provider) JWKS() *JSONWebKeySet {
+	return &JSONWebKeySet{Keys: []JSONWebKey{publicJWK(&p.signingKey.PublicKey, p.keyID)}}
+}
+
+// ============ Helper Methods | 辅助方法 ============
+
+// publicJWK Converts an RSA public key to a JWK | 将RSA公钥转换为JWK
+func publicJWK(key *rsa.PublicKey, keyID string) JSONWebKey {
+	return JSONWebKey{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: SigningAlgorithm,
+		Kid: keyID,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// thumbprint Computes the RFC 7638 JWK thumbprint of an RSA key | 计算RSA密钥的RFC 7638 JWK指纹
+func thumbprint(key *rsa.PublicKey) string {
+	jwk := publicJWK(key, "")
+	// Required members in lexicographic order | 按字典序排列的必需成员
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{jwk.E, jwk.Kty, jwk.N})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,172 @@
+package permission
+
+import "strings"
+
+// Permission Matching
+// 权限匹配
+//
+// Pattern syntax | 模式语法:
+//   user:add       exact code | 精确匹配
+//   *              every code | 匹配全部
+//   user:*         one or more segments after "user:" | "user:" 之后的一个或多个段
+//   user:*:view    exactly one segment | 恰好一个段
+//   user:**:view   zero or more segments | 零个或多个段
+//   !user:delete   deny rule, overrides every grant | 拒绝规则，优先于所有授权
+//
+// Usage | 用法:
+//   m := permission.NewMatcher([]string{"user:*", "!user:delete"})
+//   m.Match("user:add")     // true
+//   m.Match("user:delete")  // false (denied)
+
+// Pattern tokens | 模式标记
+const (
+	Separator      = ":"  // Segment separator | 段分隔符
+	Wildcard       = "*"  // Single segment, or one or more when trailing | 单个段，位于末尾时匹配一个或多个段
+	DoubleWildcard = "**" // Zero or more segments | 零个或多个段
+	DenyPrefix     = "!"  // Deny rule prefix | 拒绝规则前缀
+)
+
+// Options Matcher options | 匹配器选项
+type Options struct {
+	CaseInsensitive bool // Compare codes ignoring case | 比较时忽略大小写
+}
+
+// Matcher Compiled set of grant and deny patterns | 编译后的授权和拒绝模式集合
+// A Matcher is read-only after NewMatcher and safe for concurrent use | NewMatcher之后只读，可并发使用
+type Matcher struct {
+	grants          *node
+	denies          *node
+	caseInsensitive bool
+}
+
+// node Trie node, one level per segment | 字典树节点，每层对应一个段
+type node struct {
+	children map[string]*node
+	single   *node // "*" inside a pattern | 模式中间的 "*"
+	multi    *node // "**" | "**"
+	end      bool  // A pattern ends here | 有模式在此结束
+	tail     bool  // A trailing "*" ends here | 有末尾的 "*" 在此结束
+}
+
+// NewMatcher Compiles patterns into a matcher | 将模式编译为匹配器
+func NewMatcher(patterns []string, opts ...Options) *Matcher {
+	m := &Matcher{
+		grants: &node{},
+		denies: &node{},
+	}
+	if len(opts) > 0 {
+		m.caseInsensitive = opts[0].CaseInsensitive
+	}
+
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, DenyPrefix) {
+			m.denies.insert(m.split(strings.TrimPrefix(pattern, DenyPrefix)))
+		} else {
+			m.grants.insert(m.split(pattern))
+		}
+	}
+	return m
+}
+
+// Match Checks if a permission is granted and not denied | 检查权限是否被授予且未被拒绝
+func (m *Matcher) Match(permission string) bool {
+	segments := m.split(permission)
+	return !m.denies.match(segments) && m.grants.match(segments)
+}
+
+// MatchAll Checks if every permission matches (AND) | 检查是否所有权限都匹配（AND）
+func (m *Matcher) MatchAll(permissions []string) bool {
+	for _, permission := range permissions {
+		if !m.Match(permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAny Checks if any permission matches (OR) | 检查是否有任一权限匹配（OR）
+func (m *Matcher) MatchAny(permissions []string) bool {
+	for _, permission := range permissions {
+		if m.Match(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match Checks a permission against a single pattern | 用单个模式检查权限
+// A deny pattern on its own never grants anything | 单独的拒绝模式不授予任何权限
+func Match(pattern, permission string) bool {
+	if pattern == permission && !strings.HasPrefix(pattern, DenyPrefix) {
+		return true
+	}
+	return NewMatcher([]string{pattern}).Match(permission)
+}
+
+// split Splits a code into segments | 将权限码分割为段
+func (m *Matcher) split(code string) []string {
+	if m.caseInsensitive {
+		code = strings.ToLower(code)
+	}
+	return strings.Split(code, Separator)
+}
+
+// insert Adds a pattern to the trie | 将模式加入字典树
+func (n *node) insert(segments []string) {
+	current := n
+	for i, segment := range segments {
+		switch {
+		case segment == Wildcard && i == len(segments)-1:
+			current.tail = true
+			return
+		case segment == Wildcard:
+			if current.single == nil {
+				current.single = &node{}
+			}
+			current = current.single
+		case segment == DoubleWildcard:
+			if current.multi == nil {
+				current.multi = &node{}
+			}
+			current = current.multi
+		default:
+			if current.children == nil {
+				current.children = make(map[string]*node)
+			}
+			child, ok := current.children[segment]
+			if !ok {
+				child = &node{}
+				current.children[segment] = child
+			}
+			current = child
+		}
+	}
+	current.end = true
+}
+
+// match Checks if any pattern below this node matches the segments | 检查该节点下是否有模式匹配这些段
+func (n *node) match(segments []string) bool {
+	if n == nil {
+		return false
+	}
+	if len(segments) == 0 {
+		return n.end || n.multi.match(segments)
+	}
+	if n.tail {
+		return true
+	}
+	if n.children[segments[0]].match(segments[1:]) {
+		return true
+	}
+	if n.single.match(segments[1:]) {
+		return true
+	}
+	if n.multi != nil {
+		for i := 0; i <= len(segments); i++ {
+			if n.multi.match(segments[i:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
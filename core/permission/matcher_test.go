@@ -0,0 +1,164 @@
+package permission
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		permission string
+		want       bool
+	}{
+		// Exact | 精确匹配
+		{"user:add", "user:add", true},
+		{"user:add", "user:delete", false},
+		{"user:add", "user:add:self", false},
+		{"user", "user:add", false},
+
+		// Global wildcard | 全局通配符
+		{"*", "user", true},
+		{"*", "user:add", true},
+		{"*", "user:1:view", true},
+
+		// Trailing "*" keeps its prefix semantics | 末尾 "*" 保持前缀语义
+		{"user:*", "user:add", true},
+		{"user:*", "user:1:view", true},
+		{"user:*", "user", false},
+		{"user:*", "order:add", false},
+
+		// Inner "*" is exactly one segment | 中间的 "*" 恰好匹配一个段
+		{"user:*:view", "user:1:view", true},
+		{"user:*:view", "user:view", false},
+		{"user:*:view", "user:1:2:view", false},
+		{"user:*:view", "user:1:edit", false},
+		{"*:view", "user:view", true},
+		{"*:view", "user:1:view", false},
+
+		// "**" is zero or more segments | "**" 匹配零个或多个段
+		{"user:**:view", "user:view", true},
+		{"user:**:view", "user:1:view", true},
+		{"user:**:view", "user:1:2:3:view", true},
+		{"user:**:view", "user:1:edit", false},
+		{"user:**", "user", true},
+		{"user:**", "user:1:2", true},
+		{"**", "anything:at:all", true},
+		{"**:view", "view", true},
+		{"**:view", "user:1:view", true},
+		{"**:view", "user:1:view:x", false},
+		{"a:**:b:**:c", "a:b:c", true},
+		{"a:**:b:**:c", "a:x:b:y:z:c", true},
+		{"a:**:b:**:c", "a:x:y:c", false},
+		{"user:**:*", "user", false},
+		{"user:**:*", "user:1", true},
+
+		// Deny patterns alone never grant | 单独的拒绝模式不授权
+		{"!user:add", "user:add", false},
+		{"!*", "user:add", false},
+
+		// Case sensitive by default | 默认区分大小写
+		{"User:Add", "user:add", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s~%s", tt.pattern, tt.permission), func(t *testing.T) {
+			if got := Match(tt.pattern, tt.permission); got != tt.want {
+				t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatcherDeny(t *testing.T) {
+	m := NewMatcher([]string{"user:*", "order:**", "!user:delete", "!order:**:refund", "!admin:*"})
+
+	tests := []struct {
+		permission string
+		want       bool
+	}{
+		{"user:add", true},
+		{"user:delete", false},
+		{"user:delete:self", true},
+		{"order:1:view", true},
+		{"order:refund", false},
+		{"order:1:2:refund", false},
+		{"admin:add", false},
+		{"admin", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.permission); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.permission, got, tt.want)
+		}
+	}
+
+	// Deny wins regardless of order and grant specificity | 无论顺序与授权精确程度，拒绝优先
+	if NewMatcher([]string{"!user:add", "user:add", "*"}).Match("user:add") {
+		t.Error("deny must override an exact grant")
+	}
+	if NewMatcher([]string{"*", "!*"}).Match("anything") {
+		t.Error("deny-all must override grant-all")
+	}
+}
+
+func TestMatcherCaseInsensitive(t *testing.T) {
+	m := NewMatcher([]string{"User:*", "!USER:Delete"}, Options{CaseInsensitive: true})
+	if !m.Match("user:ADD") {
+		t.Error("expected case-insensitive grant")
+	}
+	if m.Match("user:delete") {
+		t.Error("expected case-insensitive deny")
+	}
+
+	strict := NewMatcher([]string{"User:*"})
+	if strict.Match("user:add") {
+		t.Error("matching must be case sensitive by default")
+	}
+}
+
+func TestMatcherAllAny(t *testing.T) {
+	m := NewMatcher([]string{"user:*", "!user:delete"})
+
+	if !m.MatchAll([]string{"user:add", "user:edit"}) {
+		t.Error("MatchAll should pass when every permission matches")
+	}
+	if m.MatchAll([]string{"user:add", "user:delete"}) {
+		t.Error("MatchAll should fail on a denied permission")
+	}
+	if !m.MatchAll(nil) {
+		t.Error("MatchAll of nothing is true")
+	}
+	if !m.MatchAny([]string{"order:add", "user:add"}) {
+		t.Error("MatchAny should pass when one permission matches")
+	}
+	if m.MatchAny([]string{"order:add", "user:delete"}) {
+		t.Error("MatchAny should fail when nothing matches")
+	}
+	if m.MatchAny(nil) {
+		t.Error("MatchAny of nothing is false")
+	}
+}
+
+func TestMatcherEmpty(t *testing.T) {
+	m := NewMatcher(nil)
+	if m.Match("user:add") || m.Match("") {
+		t.Error("empty matcher grants nothing")
+	}
+}
+
+func BenchmarkMatcherMatchAll(b *testing.B) {
+	patterns := make([]string, 0, 200)
+	required := make([]string, 0, 50)
+	for i := 0; i < 200; i++ {
+		patterns = append(patterns, fmt.Sprintf("module%d:*:view", i))
+	}
+	for i := 0; i < 50; i++ {
+		required = append(required, fmt.Sprintf("module%d:item:view", i*4))
+	}
+	m := NewMatcher(patterns)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.MatchAll(required)
+	}
+}
@@ -0,0 +1,644 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Policy Expressions
+// 策略表达式
+//
+// A small boolean language over attributes, compiled once and evaluated per request.
+// 基于属性的小型布尔表达式语言，编译一次、每次请求求值。
+//
+// Syntax | 语法:
+//   subject.id == resource.ownerId            attribute paths | 属性路径
+//   resource.status != "archived"             string, number, true/false/null literals | 字符串、数字、布尔、空值字面量
+//   resource.size <= 1024                     == != < <= > >= | 比较运算
+//   "editor" in subject.roles                 membership in lists or map keys | 列表或映射键包含
+//   contains(resource.title, "draft")         substring test | 子串判断
+//   hasRole("admin") || !resource.locked      function calls, && || ! and parentheses | 函数调用、逻辑运算与括号
+//
+// A comparison with a missing attribute is false, only "== null" and "!= null" test for absence.
+// 涉及缺失属性的比较均为false，只有"== null"和"!= null"用于判断是否缺失。
+
+// Expression errors | 表达式错误
+var (
+	ErrSyntax  = fmt.Errorf("policy expression syntax error")
+	ErrEval    = fmt.Errorf("policy expression evaluation error")
+	ErrNotBool = fmt.Errorf("policy expression must evaluate to a boolean")
+)
+
+// Env Supplies attribute values and functions to an expression | 为表达式提供属性值和函数
+type Env interface {
+	// Lookup Resolves a dotted attribute path, false when it does not exist | 解析点分属性路径，不存在时返回false
+	Lookup(path []string) (any, bool)
+
+	// Call Invokes a named function | 调用具名函数
+	Call(name string, args []any) (any, error)
+}
+
+// Expression Compiled policy expression | 编译后的策略表达式
+type Expression struct {
+	source string
+	root   exprNode
+}
+
+// Compile Parses an expression | 解析表达式
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, p.peek().text, p.peek().pos)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// MustCompile Parses an expression and panics on error | 解析表达式，出错时panic
+func MustCompile(source string) *Expression {
+	expr, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return expr
+}
+
+// String Returns the expression source | 返回表达式源码
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval Evaluates the expression to a boolean | 将表达式求值为布尔值
+func (e *Expression) Eval(env Env) (bool, error) {
+	value, err := e.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	result, ok := truthy(value)
+	if !ok {
+		return false, fmt.Errorf("%w: got %T", ErrNotBool, value)
+	}
+	return result, nil
+}
+
+// ============ Attributes | 属性 ============
+
+// AttributeProvider Lets a resource expose attributes without reflection | 允许资源不经反射暴露属性
+type AttributeProvider interface {
+	PolicyAttribute(name string) (any, bool)
+}
+
+// Attribute Reads a named attribute of a map, struct or AttributeProvider | 读取映射、结构体或AttributeProvider的具名属性
+// Struct fields match by json tag first, then by name ignoring case | 结构体字段先按json标签匹配，再忽略大小写按字段名匹配
+func Attribute(value any, name string) (any, bool) {
+	if value == nil {
+		return nil, false
+	}
+	if provider, ok := value.(AttributeProvider); ok {
+		return provider.PolicyAttribute(name)
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		item := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !item.IsValid() {
+			return nil, false
+		}
+		return item.Interface(), true
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			tag := strings.Split(field.Tag.Get("json"), ",")[0]
+			if tag == name || strings.EqualFold(field.Name, name) {
+				return v.Field(i).Interface(), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ============ Evaluation | 求值 ============
+
+type exprNode interface {
+	eval(env Env) (any, error)
+}
+
+type literalNode struct{ value any }
+
+type pathNode struct{ path []string }
+
+type callNode struct {
+	name string
+	args []exprNode
+}
+
+type notNode struct{ operand exprNode }
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *literalNode) eval(Env) (any, error) {
+	return n.value, nil
+}
+
+func (n *pathNode) eval(env Env) (any, error) {
+	value, _ := env.Lookup(n.path)
+	return value, nil // Missing attributes are null | 缺失的属性为null
+}
+
+func (n *callNode) eval(env Env) (any, error) {
+	args := make([]any, 0, len(n.args))
+	for _, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+	if fn, ok := builtins[n.name]; ok {
+		return fn(args)
+	}
+	return env.Call(n.name, args)
+}
+
+func (n *notNode) eval(env Env) (any, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := truthy(value)
+	if !ok {
+		return nil, fmt.Errorf("%w: ! needs a boolean, got %T", ErrEval, value)
+	}
+	return !b, nil
+}
+
+func (n *binaryNode) eval(env Env) (any, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit logic | 短路逻辑
+	if n.op == "&&" || n.op == "||" {
+		l, ok := truthy(left)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s needs booleans, got %T", ErrEval, n.op, left)
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := n.right.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := truthy(right)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s needs booleans, got %T", ErrEval, n.op, right)
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==", "!=":
+		// Only the null literal tests for absence | 只有null字面量用于判断是否缺失
+		if isNull(n.left) || isNull(n.right) {
+			return (left == nil) == (right == nil) == (n.op == "=="), nil
+		}
+		if left == nil || right == nil {
+			return false, nil // Missing attributes never compare | 缺失属性不参与比较
+		}
+		return equal(left, right) == (n.op == "=="), nil
+	case "in":
+		return contains(right, left)
+	default:
+		return compare(n.op, left, right)
+	}
+}
+
+// isNull Checks for the null literal | 检查是否为null字面量
+func isNull(node exprNode) bool {
+	literal, ok := node.(*literalNode)
+	return ok && literal.value == nil
+}
+
+// truthy Converts a value to a boolean, null counts as false | 将值转换为布尔值，null视为false
+func truthy(value any) (bool, bool) {
+	switch v := value.(type) {
+	case nil:
+		return false, true
+	case bool:
+		return v, true
+	}
+	return false, false
+}
+
+// toNumber Converts numeric kinds to float64 | 将数值类型转换为float64
+func toNumber(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// equal Compares values, numbers by value and scalars by text ("42" == 42) | 比较值，数字按数值、标量按文本比较（"42" == 42）
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if x, ok := toNumber(a); ok {
+		if y, ok := toNumber(b); ok {
+			return x == y
+		}
+	}
+	if isScalar(a) && isScalar(b) {
+		return fmt.Sprint(a) == fmt.Sprint(b)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// isScalar Checks for strings, numbers and booleans | 检查是否为字符串、数字或布尔值
+func isScalar(value any) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// compare Orders numbers or strings | 比较数字或字符串的大小
+func compare(op string, a, b any) (any, error) {
+	var cmp int
+	x, xok := toNumber(a)
+	y, yok := toNumber(b)
+	switch {
+	case xok && yok:
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		}
+	default:
+		s, sok := a.(string)
+		t, tok := b.(string)
+		if !sok || !tok {
+			if a == nil || b == nil {
+				return false, nil // Missing attributes never satisfy an ordering | 缺失属性不满足任何大小比较
+			}
+			return nil, fmt.Errorf("%w: cannot compare %T %s %T", ErrEval, a, op, b)
+		}
+		cmp = strings.Compare(s, t)
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+// contains Checks membership in a list or map keys | 检查是否包含于列表或映射键中
+func contains(container, item any) (any, error) {
+	if container == nil || item == nil {
+		return false, nil // Missing attributes are never members | 缺失属性不属于任何集合
+	}
+
+	v := reflect.ValueOf(container)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equal(v.Index(i).Interface(), item) {
+				return true, nil
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			if equal(key.Interface(), item) {
+				return true, nil
+			}
+		}
+	case reflect.String:
+		return nil, fmt.Errorf("%w: in needs a list or map, use contains() for substrings", ErrEval)
+	default:
+		return nil, fmt.Errorf("%w: in needs a list or map, got %T", ErrEval, container)
+	}
+	return false, nil
+}
+
+// builtins Functions available in every environment | 所有环境中都可用的函数
+var builtins = map[string]func(args []any) (any, error){
+	"contains": builtinContains,
+}
+
+// builtinContains contains(s, sub) reports whether s contains sub | contains(s, sub)判断s是否包含子串sub
+func builtinContains(args []any) (any, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("%w: contains expects 2 arguments", ErrEval)
+	}
+	if args[0] == nil || args[1] == nil {
+		return false, nil
+	}
+	s, ok := args[0].(string)
+	sub, subOK := args[1].(string)
+	if !ok || !subOK {
+		return nil, fmt.Errorf("%w: contains expects strings, got %T and %T", ErrEval, args[0], args[1])
+	}
+	return strings.Contains(s, sub), nil
+}
+
+// ============ Parsing | 解析 ============
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize Splits source into tokens | 将源码切分为记号
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("%w: unterminated string at %d", ErrSyntax, start)
+			}
+			i++
+			tokens = append(tokens, token{tokenString, sb.String(), start})
+		default:
+			start := i
+			two := ""
+			if i+1 < len(runes) {
+				two = string(runes[i : i+2])
+			}
+			switch two {
+			case "==", "!=", "<=", ">=", "&&", "||":
+				tokens = append(tokens, token{tokenOp, two, start})
+				i += 2
+				continue
+			}
+			switch r {
+			case '<', '>', '!', '(', ')', '.', ',':
+				tokens = append(tokens, token{tokenOp, string(r), start})
+				i++
+			default:
+				return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, r, start)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isOp Checks if the next token is the given operator | 检查下一个记号是否为指定运算符
+func (p *parser) isOp(op string) bool {
+	t := p.peek()
+	return (t.kind == tokenOp || t.kind == tokenIdent) && t.text == op
+}
+
+func (p *parser) expect(op string) error {
+	if !p.isOp(op) {
+		t := p.peek()
+		return fmt.Errorf("%w: expected %q at %d", ErrSyntax, op, t.pos)
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("&&") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (exprNode, error) {
+	if p.isOp("!") {
+		p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (exprNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">", "in"} {
+		if p.isOp(op) {
+			p.next()
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &binaryNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePrimary() (exprNode, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &literalNode{value: t.text}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad number %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		return &literalNode{value: n}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null", "nil":
+			return &literalNode{value: nil}, nil
+		case "in":
+			return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+		}
+		if p.isOp("(") {
+			return p.parseCall(t.text)
+		}
+		path := []string{t.text}
+		for p.isOp(".") {
+			p.next()
+			field := p.next()
+			if field.kind != tokenIdent {
+				return nil, fmt.Errorf("%w: expected attribute name at %d", ErrSyntax, field.pos)
+			}
+			path = append(path, field.text)
+		}
+		return &pathNode{path: path}, nil
+	case tokenOp:
+		if t.text == "(" {
+			inner, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	if t.kind == tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected end of expression", ErrSyntax)
+	}
+	return nil, fmt.Errorf("%w: unexpected %q at %d", ErrSyntax, t.text, t.pos)
+}
+
+func (p *parser) parseCall(name string) (exprNode, error) {
+	p.next() // "("
+	call := &callNode{name: name}
+	if p.isOp(")") {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		if p.isOp(",") {
+			p.next()
+			continue
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return call, nil
+	}
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type mapEnv map[string]any
+
+func (e mapEnv) Lookup(path []string) (any, bool) {
+	var value any = map[string]any(e)
+	for _, name := range path {
+		v, ok := Attribute(value, name)
+		if !ok {
+			return nil, false
+		}
+		value = v
+	}
+	return value, true
+}
+
+func (e mapEnv) Call(name string, args []any) (any, error) {
+	if name == "upper" && len(args) == 1 {
+		return fmt.Sprintf("%v", args[0]) == "ADMIN", nil
+	}
+	return nil, fmt.Errorf("%w: unknown function %s", ErrEval, name)
+}
+
+type article struct {
+	AuthorID string `json:"authorId"`
+	Words    int
+	Tags     []string `json:"tags"`
+}
+
+func TestEval(t *testing.T) {
+	env := mapEnv{
+		"subject": map[string]any{"id": "42", "roles": []string{"editor"}, "level": 3},
+		"resource": &article{
+			AuthorID: "42",
+			Words:    800,
+			Tags:     []string{"go", "auth"},
+		},
+		"context": map[string]string{"method": "PUT"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`subject.id == resource.authorId`, true},
+		{`subject.id == 42`, true},
+		{`subject.id != "7"`, true},
+		{`resource.words > 500 && resource.words <= 800`, true},
+		{`resource.Words >= 1000`, false},
+		{`subject.level < 2.5`, false},
+		{`"editor" in subject.roles`, true},
+		{`"admin" in subject.roles`, false},
+		{`"go" in resource.tags`, true},
+		{`"method" in context`, true},
+		{`contains(context.method, "P")`, true},
+		{`contains(resource.missing, "P")`, false},
+		{`!(context.method == "GET") && (subject.level == 3 || false)`, true},
+		{`resource.missing == null`, true},
+		{`resource.authorId != null`, true},
+		{`resource.missing == subject.missing`, false},
+		{`resource.missing != "7"`, false},
+		{`resource.missing in subject.roles`, false},
+		{`resource.missing`, false},
+		{`!resource.missing`, true},
+		{`resource.missing > 1`, false},
+		{`'a' < 'b'`, true},
+		{`upper("ADMIN")`, true},
+		{`true || unknown()`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := MustCompile(tt.expr).Eval(env)
+			if err != nil {
+				t.Fatalf("Eval(%q) error: %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, expr := range []string{``, `a ==`, `(a == 1`, `a == "x`, `a # b`, `a.`, `in`, `f(1,`} {
+		if _, err := Compile(expr); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Compile(%q) error = %v, want ErrSyntax", expr, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := mapEnv{"n": 1, "s": "x"}
+	tests := []struct {
+		expr string
+		want error
+	}{
+		{`n`, ErrNotBool},
+		{`s && true`, ErrEval},
+		{`!n`, ErrEval},
+		{`n < s`, ErrEval},
+		{`unknown()`, ErrEval},
+		{`"x" in s`, ErrEval},
+		{`1 in n`, ErrEval},
+		{`contains(n, "1")`, ErrEval},
+	}
+	for _, tt := range tests {
+		if _, err := MustCompile(tt.expr).Eval(env); !errors.Is(err, tt.want) {
+			t.Errorf("Eval(%q) error = %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"crypto/rsa"
 	"time"
 
 	"github.com/click33/sa-token-go/core/adapter"
@@ -10,6 +11,9 @@ import (
 	"github.com/click33/sa-token-go/core/listener"
 	"github.com/click33/sa-token-go/core/manager"
 	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/click33/sa-token-go/core/oidc"
+	"github.com/click33/sa-token-go/core/permission"
+	"github.com/click33/sa-token-go/core/policy"
 	"github.com/click33/sa-token-go/core/security"
 	"github.com/click33/sa-token-go/core/session"
 	"github.com/click33/sa-token-go/core/token"
@@ -44,19 +48,42 @@ const (
 
 // Core types | 核心类型
 type (
-	Manager             = manager.Manager
-	TokenInfo           = manager.TokenInfo
-	Session             = session.Session
-	TokenGenerator      = token.Generator
-	SaTokenContext      = context.SaTokenContext
-	Builder             = builder.Builder
-	NonceManager        = security.NonceManager
-	RefreshTokenInfo    = security.RefreshTokenInfo
-	RefreshTokenManager = security.RefreshTokenManager
-	OAuth2Server        = oauth2.OAuth2Server
-	OAuth2Client        = oauth2.Client
-	OAuth2AccessToken   = oauth2.AccessToken
-	OAuth2GrantType     = oauth2.GrantType
+	Manager                = manager.Manager
+	TokenInfo              = manager.TokenInfo
+	Session                = session.Session
+	TokenGenerator         = token.Generator
+	SaTokenContext         = context.SaTokenContext
+	Builder                = builder.Builder
+	NonceManager           = security.NonceManager
+	RefreshTokenInfo       = security.RefreshTokenInfo
+	RefreshTokenManager    = security.RefreshTokenManager
+	OAuth2Server           = oauth2.OAuth2Server
+	OAuth2Client           = oauth2.Client
+	OAuth2AccessToken      = oauth2.AccessToken
+	OAuth2GrantType        = oauth2.GrantType
+	OAuth2PasswordVerifier = oauth2.PasswordVerifier
+	OAuth2Handler          = oauth2.Handler
+	OAuth2Response         = oauth2.Response
+	OAuth2ClientStore      = oauth2.ClientStore
+	OAuth2SecretHasher     = oauth2.SecretHasher
+	OAuth2Consent          = oauth2.Consent
+	OAuth2ConsentStore     = oauth2.ConsentStore
+	OAuth2DeviceAuth       = oauth2.DeviceAuthorization
+	ScopePermissionMapper  = manager.ScopePermissionMapper
+	PermissionProvider     = manager.PermissionProvider
+	Role                   = manager.Role
+	DisableInfo            = manager.DisableInfo
+	DisableError           = manager.DisableError
+	PermissionMatcher      = permission.Matcher
+	Policy                 = manager.Policy
+	PolicyRequest          = manager.PolicyRequest
+	PolicyExpression       = policy.Expression
+	TenantResolver         = manager.TenantResolver
+	TenantConfigFunc       = manager.TenantConfigFunc
+	TenantValidator        = manager.TenantValidator
+	OIDCProvider           = oidc.Provider
+	OIDCClaimsProvider     = oidc.ClaimsProvider
+	OIDCIDTokenClaims      = oidc.IDTokenClaims
 )
 
 // Adapter interfaces | 适配器接口
@@ -77,17 +104,18 @@ type (
 
 // Event constants | 事件常量
 const (
-	EventLogin           = listener.EventLogin
-	EventLogout          = listener.EventLogout
-	EventKickout         = listener.EventKickout
-	EventDisable         = listener.EventDisable
-	EventUntie           = listener.EventUntie
-	EventRenew           = listener.EventRenew
-	EventCreateSession   = listener.EventCreateSession
-	EventDestroySession  = listener.EventDestroySession
-	EventPermissionCheck = listener.EventPermissionCheck
-	EventRoleCheck       = listener.EventRoleCheck
-	EventAll             = listener.EventAll
+	EventLogin             = listener.EventLogin
+	EventLogout            = listener.EventLogout
+	EventKickout           = listener.EventKickout
+	EventDisable           = listener.EventDisable
+	EventUntie             = listener.EventUntie
+	EventRenew             = listener.EventRenew
+	EventCreateSession     = listener.EventCreateSession
+	EventDestroySession    = listener.EventDestroySession
+	EventPermissionCheck   = listener.EventPermissionCheck
+	EventRoleCheck         = listener.EventRoleCheck
+	EventRefreshTokenReuse = listener.EventRefreshTokenReuse
+	EventAll               = listener.EventAll
 )
 
 const (
@@ -95,6 +123,24 @@ const (
 	GrantTypeRefreshToken      = oauth2.GrantTypeRefreshToken
 	GrantTypeClientCredentials = oauth2.GrantTypeClientCredentials
 	GrantTypePassword          = oauth2.GrantTypePassword
+	GrantTypeDeviceCode        = oauth2.GrantTypeDeviceCode
+)
+
+// OAuth2 endpoint paths | OAuth2端点路径
+const (
+	OAuth2AuthorizePath  = oauth2.AuthorizePath
+	OAuth2TokenPath      = oauth2.TokenPath
+	OAuth2RevokePath     = oauth2.RevokePath
+	OAuth2IntrospectPath = oauth2.IntrospectPath
+	OAuth2RegisterPath   = oauth2.RegisterPath
+
+	OAuth2DeviceAuthorizationPath = oauth2.DeviceAuthorizationPath
+	OAuth2DeviceVerificationPath  = oauth2.DeviceVerificationPath
+
+	OIDCDiscoveryPath = oidc.DiscoveryPath
+	OIDCJWKSPath      = oidc.JWKSPath
+	OIDCUserInfoPath  = oidc.UserInfoPath
+	OAuth2ScopeOpenID = oauth2.ScopeOpenID
 )
 
 // ============ Utility Functions | 工具函数 ============
@@ -146,6 +192,21 @@ func NewContext(ctx RequestContext, mgr *Manager) *SaTokenContext {
 	return context.NewContext(ctx, mgr)
 }
 
+// CompilePolicy Compiles a policy expression | 编译策略表达式
+func CompilePolicy(expr string) (*PolicyExpression, error) {
+	return policy.Compile(expr)
+}
+
+// HeaderTenantResolver Resolves the tenant from a request header | 从请求头解析租户
+func HeaderTenantResolver(header string) TenantResolver {
+	return manager.HeaderTenantResolver(header)
+}
+
+// SubdomainTenantResolver Resolves the tenant from the subdomain below baseDomain | 从baseDomain下的子域名解析租户
+func SubdomainTenantResolver(baseDomain string) TenantResolver {
+	return manager.SubdomainTenantResolver(baseDomain)
+}
+
 // NewSession Creates a new session | 创建新的Session
 func NewSession(id string, storage Storage, prefix string) *Session {
 	return session.NewSession(id, storage, prefix)
@@ -185,7 +246,28 @@ func NewRefreshTokenManager(storage Storage, prefix string, cfg *Config) *Refres
 	return security.NewRefreshTokenManager(storage, prefix, manager.TokenKeyPrefix, cfg)
 }
 
+// ClientFingerprint Derives a client fingerprint from IP and User-Agent | 根据IP和User-Agent生成客户端指纹
+func ClientFingerprint(clientIP, userAgent string) string {
+	return security.ClientFingerprint(clientIP, userAgent)
+}
+
 // NewOAuth2Server Creates a new OAuth2 server | 创建新的OAuth2服务器
 func NewOAuth2Server(storage Storage, prefix string) *OAuth2Server {
 	return oauth2.NewOAuth2Server(storage, prefix)
 }
+
+// NewOAuth2StorageClientStore Creates an OAuth2 client store shared through storage | 创建通过存储共享的OAuth2客户端存储
+func NewOAuth2StorageClientStore(storage Storage, prefix string) OAuth2ClientStore {
+	return oauth2.NewStorageClientStore(storage, prefix)
+}
+
+// NewOAuth2Handler Creates OAuth2 endpoint handlers, the logged-in user authorizes | 创建OAuth2端点处理器，由当前登录用户授权
+func NewOAuth2Handler(mgr *Manager) *OAuth2Handler {
+	return context.NewOAuth2Handler(mgr)
+}
+
+// NewOIDCProvider Creates an OpenID Connect provider on the manager's OAuth2 server | 在管理器的OAuth2服务器上创建OpenID Connect提供方
+// key: RS256 signing key, generated when nil | RS256签名密钥，为nil时自动生成
+func NewOIDCProvider(mgr *Manager, issuer string, key *rsa.PrivateKey) (*OIDCProvider, error) {
+	return oidc.NewProvider(mgr.GetOAuth2Server(), issuer, key)
+}
@@ -2,13 +2,18 @@ package security
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/click33/sa-token-go/core/adapter"
 	"github.com/click33/sa-token-go/core/config"
+	"github.com/click33/sa-token-go/core/listener"
 	"github.com/click33/sa-token-go/core/token"
 	"github.com/click33/sa-token-go/core/utils"
 )
@@ -22,6 +27,15 @@ import (
 // 3. RefreshAccessToken() - Use refresh token to get new access token | 使用刷新令牌获取新访问令牌
 // 4. Refresh token expires (long-lived, 30 days) | 刷新令牌过期（长期，30天）
 //
+// Rotation | 轮换:
+// Every refresh issues a new refresh token in the same family and retires the old one. | 每次刷新都会在同一家族内签发新的刷新令牌并使旧令牌失效。
+// Presenting a retired refresh token revokes the whole family (reuse detection). | 使用已轮换的刷新令牌会撤销整个令牌家族（重用检测）。
+//
+// Expiration | 过期:
+// Absolute (default): every rotation keeps the original ExpireTime. | 绝对过期（默认）：每次轮换保持原有的过期时间。
+// Sliding: every rotation extends ExpireTime by RefreshTimeout. | 滑动过期：每次轮换将过期时间延长RefreshTimeout。
+// RefreshMaxLifetime caps both modes, counted from the family creation. | RefreshMaxLifetime从家族创建起限制两种模式的最长寿命。
+//
 // Usage | 用法:
 //   tokenInfo, _ := manager.LoginWithRefreshToken(loginID, "web")
 //   // ... access token expires ...
@@ -33,6 +47,10 @@ const (
 	DefaultAccessTTL   = 2 * time.Hour       // 2 hours | 2小时
 	RefreshTokenLength = 32                  // Refresh token byte length | 刷新令牌字节长度
 	RefreshKeySuffix   = "refresh:"          // Key suffix after prefix | 前缀后的键后缀
+	FamilyKeySuffix    = "refresh-family:"   // Family key suffix after prefix | 令牌家族键后缀
+	FamilyIDLength     = 16                  // Family ID byte length | 家族ID字节长度
+	UserIndexKeySuffix = "refresh-user:"     // Per-loginID family index key suffix | 按登录ID索引令牌家族的键后缀
+	UsedKeySuffix      = "refresh-used:"     // Marker claimed when a refresh token is consumed | 刷新令牌被消费时占用的标记键后缀
 )
 
 // Error variables | 错误变量
@@ -40,6 +58,9 @@ var (
 	ErrInvalidRefreshToken = fmt.Errorf("invalid refresh token")
 	ErrRefreshTokenExpired = fmt.Errorf("refresh token expired")
 	ErrInvalidRefreshData  = fmt.Errorf("invalid refresh token data")
+	ErrRefreshTokenReused  = fmt.Errorf("refresh token reused, token family revoked")
+	ErrFingerprintMismatch = fmt.Errorf("refresh token fingerprint mismatch")
+	ErrFingerprintRequired = fmt.Errorf("client fingerprint required for bound refresh token")
 )
 
 // RefreshTokenInfo refresh token information | 刷新令牌信息
@@ -50,6 +71,11 @@ type RefreshTokenInfo struct {
 	Device       string `json:"device"`       // Device type | 设备类型
 	CreateTime   int64  `json:"createTime"`   // Creation timestamp | 创建时间戳
 	ExpireTime   int64  `json:"expireTime"`   // Expiration timestamp | 过期时间戳
+	FamilyID     string `json:"familyId"`     // Token family shared by all rotations | 所有轮换共享的令牌家族ID
+	Rotated      bool   `json:"rotated"`      // Whether already exchanged for a new refresh token | 是否已被轮换
+
+	FamilyCreateTime int64  `json:"familyCreateTime"`      // Creation timestamp of the token family | 令牌家族创建时间戳
+	Fingerprint      string `json:"fingerprint,omitempty"` // Client fingerprint bound at issuance | 签发时绑定的客户端指纹
 }
 
 // MarshalBinary implements encoding.BinaryMarshaler for Redis storage | 实现encoding.BinaryMarshaler接口用于Redis存储
@@ -62,19 +88,34 @@ func (r *RefreshTokenInfo) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, r)
 }
 
+// AccessTokenIssuer Issues and revokes access tokens for refresh flows | 为刷新流程签发和撤销访问令牌
+// Manager implements it so refreshed tokens go through the login path (account index, events) | Manager实现该接口，使刷新出的Token走登录流程（账号索引、事件）
+type AccessTokenIssuer interface {
+	// IssueAccessToken issues and stores a new access token | 签发并存储新的访问令牌
+	IssueAccessToken(loginID, device string) (string, error)
+
+	// RevokeAccessToken revokes an access token given in its stored form | 撤销以存储形式给出的访问令牌
+	RevokeAccessToken(loginID, device, storedToken string)
+}
+
 // RefreshTokenManager Refresh token manager | 刷新令牌管理器
 type RefreshTokenManager struct {
 	storage        adapter.Storage
 	keyPrefix      string // Configurable prefix | 可配置的前缀
 	tokenKeyPrefix string // Token key prefix | 令牌键前缀
 	tokenGen       *token.Generator
-	refreshTTL     time.Duration // Refresh token TTL (30 days) | 刷新令牌有效期（30天）
+	refreshTTL     time.Duration // Refresh token TTL (configurable, default 30 days) | 刷新令牌有效期（可配置，默认30天）
 	accessTTL      time.Duration // Access token TTL (configurable) | 访问令牌有效期（可配置）
+	sliding        bool          // Extend expiration on every rotation | 每次轮换时延长过期时间
+	maxLifetime    time.Duration // Absolute lifetime cap of a family, 0 for no cap | 令牌家族的最长寿命，0表示不限制
+	bindPrint      bool          // Check client fingerprint on refresh | 刷新时校验客户端指纹
+	eventManager   *listener.Manager
+	issuer         AccessTokenIssuer // Optional access token issuer | 可选的访问令牌签发者
 }
 
 // NewRefreshTokenManager Creates a new refresh token manager | 创建新的刷新令牌管理器
 // prefix: key prefix (e.g., "satoken:" or "" for Java compatibility) | 键前缀（如："satoken:" 或 "" 兼容Java）
-// cfg: configuration, uses Timeout for access token TTL | 配置，使用Timeout作为访问令牌有效期
+// cfg: configuration, uses Timeout for access token TTL and Refresh* options for refresh tokens | 配置，使用Timeout作为访问令牌有效期，Refresh*选项控制刷新令牌
 func NewRefreshTokenManager(storage adapter.Storage, prefix, keyPrefix string, cfg *config.Config) *RefreshTokenManager {
 	accessTTL := time.Duration(cfg.Timeout) * time.Second
 
@@ -82,174 +123,541 @@ func NewRefreshTokenManager(storage adapter.Storage, prefix, keyPrefix string, c
 		accessTTL = DefaultAccessTTL
 	}
 
+	refreshTTL := time.Duration(cfg.RefreshTimeout) * time.Second
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTTL
+	}
+
+	var maxLifetime time.Duration
+	if cfg.RefreshMaxLifetime > 0 {
+		maxLifetime = time.Duration(cfg.RefreshMaxLifetime) * time.Second
+	}
+
 	return &RefreshTokenManager{
 		storage:        storage,
 		keyPrefix:      prefix,
 		tokenKeyPrefix: keyPrefix,
 		tokenGen:       token.NewGenerator(cfg),
-		refreshTTL:     DefaultRefreshTTL,
+		refreshTTL:     refreshTTL,
 		accessTTL:      accessTTL,
+		sliding:        cfg.RefreshSliding,
+		maxLifetime:    maxLifetime,
+		bindPrint:      cfg.RefreshBindFingerprint,
 	}
 }
 
+// ClientFingerprint Derives a client fingerprint from IP and User-Agent | 根据IP和User-Agent生成客户端指纹
+func ClientFingerprint(clientIP, userAgent string) string {
+	sum := sha256.Sum256([]byte(clientIP + "|" + userAgent))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetEventManager Sets event manager used to report refresh token reuse | 设置用于上报刷新令牌重用的事件管理器
+func (rtm *RefreshTokenManager) SetEventManager(eventManager *listener.Manager) {
+	rtm.eventManager = eventManager
+}
+
+// SetAccessTokenIssuer Sets access token issuer, tokens are stored directly when nil | 设置访问令牌签发者，为nil时直接写入存储
+func (rtm *RefreshTokenManager) SetAccessTokenIssuer(issuer AccessTokenIssuer) {
+	rtm.issuer = issuer
+}
+
 // GenerateTokenPair Generates access token and refresh token pair | 生成访问令牌和刷新令牌对
+// accessTokenOverride: an access token already issued by the caller | 调用方已签发的访问令牌
 func (rtm *RefreshTokenManager) GenerateTokenPair(loginID, device string, accessTokenOverride ...string) (*RefreshTokenInfo, error) {
+	var accessToken string
+	if len(accessTokenOverride) > 0 {
+		accessToken = accessTokenOverride[0]
+	}
+	return rtm.GenerateBoundTokenPair(loginID, device, "", accessToken)
+}
+
+// GenerateBoundTokenPair Generates token pair bound to a client fingerprint | 生成绑定客户端指纹的令牌对
+// The fingerprint is only stored when RefreshBindFingerprint is enabled, empty accessToken issues a new one | 仅在启用RefreshBindFingerprint时保存指纹，accessToken为空时签发新的访问令牌
+// Returns ErrFingerprintRequired when binding is enabled and the fingerprint is empty | 启用绑定且指纹为空时返回ErrFingerprintRequired
+func (rtm *RefreshTokenManager) GenerateBoundTokenPair(loginID, device, fingerprint, accessToken string) (*RefreshTokenInfo, error) {
 	if loginID == "" {
 		return nil, fmt.Errorf("loginID cannot be empty")
 	}
+	if rtm.bindPrint && fingerprint == "" {
+		return nil, ErrFingerprintRequired
+	}
 
 	// Generate access token | 生成访问令牌
-	var accessToken string
-	if len(accessTokenOverride) > 0 && accessTokenOverride[0] != "" {
-		accessToken = accessTokenOverride[0]
+	if accessToken != "" {
+
+		// Save token-loginID mapping unless the issuer already did | 签发者未保存时保存 Token-LoginID 映射
+		if rtm.issuer == nil {
+			if err := rtm.storage.Set(rtm.getTokenKey(accessToken), loginID, rtm.accessTTL); err != nil {
+				return nil, fmt.Errorf("failed to save token: %w", err)
+			}
+		}
 	} else {
 		var err error
-		accessToken, err = rtm.tokenGen.Generate(loginID, device)
+		accessToken, err = rtm.issueAccessToken(loginID, device)
 		if err != nil {
-			return nil, fmt.Errorf("failed to generate access token: %w", err)
+			return nil, err
 		}
 	}
 
-	// Save token-loginID mapping (符合 Java sa-token 设计) | 保存 Token-LoginID 映射
-	tokenKey := rtm.getTokenKey(accessToken)
-	if err := rtm.storage.Set(tokenKey, loginID, rtm.accessTTL); err != nil {
-		return nil, fmt.Errorf("failed to save token: %w", err)
-	}
-
 	// Generate refresh token | 生成刷新令牌
-	refreshTokenBytes := make([]byte, RefreshTokenLength)
-	if _, err := rand.Read(refreshTokenBytes); err != nil {
+	refreshToken, err := randomHex(RefreshTokenLength)
+	if err != nil {
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
-	refreshToken := hex.EncodeToString(refreshTokenBytes)
+
+	// Start a new token family | 创建新的令牌家族
+	familyID, err := randomHex(FamilyIDLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token family: %w", err)
+	}
 
 	now := time.Now()
 	info := &RefreshTokenInfo{
-		RefreshToken: refreshToken,
-		AccessToken:  accessToken,
-		LoginID:      loginID,
-		Device:       device,
-		CreateTime:   now.Unix(),
-		ExpireTime:   now.Add(rtm.refreshTTL).Unix(),
+		RefreshToken:     refreshToken,
+		AccessToken:      accessToken,
+		LoginID:          loginID,
+		Device:           device,
+		CreateTime:       now.Unix(),
+		ExpireTime:       rtm.capExpireTime(now.Add(rtm.refreshTTL).Unix(), now.Unix()),
+		FamilyID:         familyID,
+		FamilyCreateTime: now.Unix(),
+	}
+	if rtm.bindPrint {
+		info.Fingerprint = fingerprint
 	}
 
-	key := rtm.getRefreshKey(refreshToken)
-	if err := rtm.storage.Set(key, info, rtm.refreshTTL); err != nil {
+	if err := rtm.saveRefreshInfo(info); err != nil {
 		return nil, fmt.Errorf("failed to store refresh token: %w", err)
 	}
 
+	if err := rtm.storage.Set(rtm.getFamilyKey(familyID), rtm.tokenGen.Digest(refreshToken), rtm.getRemainingTTL(info)); err != nil {
+		return nil, fmt.Errorf("failed to store token family: %w", err)
+	}
+
+	// Index the family under its loginID | 将令牌家族索引到登录ID下
+	if err := rtm.storage.Set(rtm.getUserIndexKey(loginID, familyID), device, rtm.getRemainingTTL(info)); err != nil {
+		return nil, fmt.Errorf("failed to index token family: %w", err)
+	}
+
 	return info, nil
 }
 
-// RefreshAccessToken Generates new access token using refresh token | 使用刷新令牌生成新的访问令牌
-func (rtm *RefreshTokenManager) RefreshAccessToken(refreshToken string) (*RefreshTokenInfo, error) {
+// RefreshAccessToken Rotates refresh token and generates new access token | 轮换刷新令牌并生成新的访问令牌
+// The returned info carries a new refresh token, the presented one can no longer be used | 返回的信息包含新的刷新令牌，旧刷新令牌不能再次使用
+// fingerprint: client fingerprint, required for tokens bound at issuance | 客户端指纹，签发时绑定了指纹的令牌必须提供
+func (rtm *RefreshTokenManager) RefreshAccessToken(refreshToken string, fingerprint ...string) (*RefreshTokenInfo, error) {
 	if refreshToken == "" {
 		return nil, ErrInvalidRefreshToken
 	}
 
 	// Get refresh token info | 获取刷新令牌信息
-	key := rtm.getRefreshKey(refreshToken)
-
-	// Get refresh token info | 获取刷新令牌信息
-	data, err := rtm.storage.Get(key)
-	if err != nil || data == nil {
-		return nil, ErrInvalidRefreshToken
-	}
-
-	// Convert to RefreshTokenInfo | 转换为 RefreshTokenInfo
-	dataBytes, err := utils.ToBytes(data)
+	oldInfo, err := rtm.loadRefreshInfo(refreshToken)
 	if err != nil {
-		return nil, ErrInvalidRefreshData
+		return nil, err
 	}
 
-	// Unmarshal data | 反序列化数据
-	oldInfo := &RefreshTokenInfo{}
-	err = oldInfo.UnmarshalBinary(dataBytes)
-	if err != nil {
-		return nil, ErrInvalidRefreshData
+	// Reuse detection: a rotated token means it leaked | 重用检测：已轮换的令牌再次出现说明已泄露
+	if oldInfo.Rotated {
+		rtm.RevokeFamily(oldInfo.FamilyID)
+		rtm.triggerReuse(oldInfo)
+		return nil, ErrRefreshTokenReused
 	}
 
 	// Check expiration | 检查是否过期
 	if time.Now().Unix() > oldInfo.ExpireTime {
-		rtm.storage.Delete(key)
+		rtm.RevokeFamily(oldInfo.FamilyID)
+		rtm.storage.Delete(rtm.getRefreshKey(refreshToken))
 		return nil, ErrRefreshTokenExpired
 	}
 
-	// Generate new access token | 生成新的访问令牌
-	newAccessToken, err := rtm.tokenGen.Generate(oldInfo.LoginID, oldInfo.Device)
+	// Check client fingerprint, tokens issued without one are rejected too | 校验客户端指纹，签发时未绑定指纹的令牌同样被拒绝
+	if rtm.bindPrint {
+		presented := ""
+		if len(fingerprint) > 0 {
+			presented = fingerprint[0]
+		}
+		if oldInfo.Fingerprint == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(oldInfo.Fingerprint)) != 1 {
+			return nil, ErrFingerprintMismatch
+		}
+	}
+
+	// Consume atomically, only one concurrent request wins the rotation | 原子地消费令牌，并发请求中只有一个能完成轮换
+	claimed, err := adapter.SetNX(rtm.storage, rtm.getUsedKey(refreshToken), oldInfo.FamilyID, rtm.getRemainingTTL(oldInfo))
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate new access token: %w", err)
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+	if !claimed {
+		rtm.RevokeFamily(oldInfo.FamilyID)
+		rtm.triggerReuse(oldInfo)
+		return nil, ErrRefreshTokenReused
 	}
 
-	// Update access token info | 更新访问令牌信息
-	oldInfo.AccessToken = newAccessToken
+	// Invalidate previous access token | 使旧的访问令牌失效
+	rtm.revokeAccessToken(oldInfo)
 
-	// Save token-loginID mapping (符合 Java sa-token 设计) | 保存 Token-LoginID 映射
-	tokenKey := rtm.getTokenKey(newAccessToken)
-	if err := rtm.storage.Set(tokenKey, oldInfo.LoginID, rtm.accessTTL); err != nil {
-		return nil, fmt.Errorf("failed to save token: %w", err)
+	// Generate new access token, a failed issue (e.g. a ban) releases the claim so a retry is not taken for reuse | 生成新的访问令牌，签发失败（例如被封禁）时释放占用，重试不会被视为重用
+	newAccessToken, err := rtm.issueAccessToken(oldInfo.LoginID, oldInfo.Device)
+	if err != nil {
+		rtm.storage.Delete(rtm.getUsedKey(refreshToken))
+		return nil, err
+	}
+
+	// Issue next refresh token in the same family | 在同一家族内签发新的刷新令牌
+	newRefreshToken, err := randomHex(RefreshTokenLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	// Tokens issued before family creation time was recorded | 记录家族创建时间之前签发的令牌
+	familyCreateTime := oldInfo.FamilyCreateTime
+	if familyCreateTime == 0 {
+		familyCreateTime = oldInfo.CreateTime
+	}
+
+	now := time.Now()
+	expireTime := oldInfo.ExpireTime
+	if rtm.sliding {
+		expireTime = now.Add(rtm.refreshTTL).Unix()
+	}
+
+	newInfo := &RefreshTokenInfo{
+		RefreshToken:     newRefreshToken,
+		AccessToken:      newAccessToken,
+		LoginID:          oldInfo.LoginID,
+		Device:           oldInfo.Device,
+		CreateTime:       now.Unix(),
+		ExpireTime:       rtm.capExpireTime(expireTime, familyCreateTime),
+		FamilyID:         oldInfo.FamilyID,
+		FamilyCreateTime: familyCreateTime,
+		Fingerprint:      oldInfo.Fingerprint,
 	}
 
-	// Update storage | 更新存储
-	if err := rtm.storage.Set(key, oldInfo, rtm.refreshTTL); err != nil {
+	if err := rtm.saveRefreshInfo(newInfo); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	if err := rtm.storage.Set(rtm.getFamilyKey(newInfo.FamilyID), rtm.tokenGen.Digest(newRefreshToken), rtm.getRemainingTTL(newInfo)); err != nil {
+		return nil, fmt.Errorf("failed to update token family: %w", err)
+	}
+
+	// Sliding expiration moves the index entry as well | 滑动过期时同步更新索引项
+	if err := rtm.storage.Set(rtm.getUserIndexKey(newInfo.LoginID, newInfo.FamilyID), newInfo.Device, rtm.getRemainingTTL(newInfo)); err != nil {
+		return nil, fmt.Errorf("failed to index token family: %w", err)
+	}
+
+	// Keep the retired token until it expires so reuse can be detected | 保留已轮换的令牌直到过期，以便检测重用
+	// The loaded access token is already in its stored form, only the refresh token is digested again | 加载的访问令牌已是存储形式，只需重新摘要刷新令牌
+	retired := *oldInfo
+	retired.RefreshToken = rtm.tokenGen.Digest(refreshToken)
+	retired.Rotated = true
+	if err := rtm.storage.Set(rtm.getRefreshKey(refreshToken), &retired, rtm.getRemainingTTL(oldInfo)); err != nil {
 		return nil, fmt.Errorf("failed to update refresh token: %w", err)
 	}
 
-	return oldInfo, nil
+	return newInfo, nil
 }
 
-// RevokeRefreshToken Revokes a refresh token | 撤销刷新令牌
+// RevokeRefreshToken Revokes a refresh token and its token family | 撤销刷新令牌及其令牌家族
 func (rtm *RefreshTokenManager) RevokeRefreshToken(refreshToken string) error {
 	if refreshToken == "" {
 		return nil
 	}
+
+	if info, err := rtm.loadRefreshInfo(refreshToken); err == nil && info.FamilyID != "" {
+		if err := rtm.RevokeFamily(info.FamilyID); err != nil {
+			return err
+		}
+	}
+
 	key := rtm.getRefreshKey(refreshToken)
 	return rtm.storage.Delete(key)
 }
 
+// RevokeFamily Revokes the active refresh token and access token of a token family | 撤销令牌家族当前有效的刷新令牌和访问令牌
+func (rtm *RefreshTokenManager) RevokeFamily(familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+
+	familyKey := rtm.getFamilyKey(familyID)
+	data, err := rtm.storage.Get(familyKey)
+	if err != nil || data == nil {
+		return nil // Already revoked | 已撤销
+	}
+
+	if current, ok := data.(string); ok {
+		refreshKey := rtm.getStoredRefreshKey(current)
+		if info, err := rtm.loadRefreshInfoByKey(refreshKey); err == nil {
+			rtm.revokeAccessToken(info)
+			rtm.storage.Delete(rtm.getUserIndexKey(info.LoginID, familyID))
+		}
+		rtm.storage.Delete(refreshKey)
+	}
+
+	return rtm.storage.Delete(familyKey)
+}
+
+// ListRefreshTokens Lists the active refresh token of every token family of a user | 列出用户每个令牌家族当前有效的刷新令牌
+// Returns token.ErrRawTokenUnavailable when TokenHashAtRest is enabled | 启用TokenHashAtRest时返回token.ErrRawTokenUnavailable
+func (rtm *RefreshTokenManager) ListRefreshTokens(loginID string) ([]*RefreshTokenInfo, error) {
+	if rtm.tokenGen.HashAtRest() {
+		return nil, token.ErrRawTokenUnavailable
+	}
+	return rtm.listRefreshTokens(loginID)
+}
+
+// ListRefreshTokenDigests Lists the active refresh token of every token family of a user in at-rest form | 以存储形式列出用户每个令牌家族当前有效的刷新令牌
+// RefreshToken and AccessToken hold digests when TokenHashAtRest is enabled | 启用TokenHashAtRest时RefreshToken和AccessToken为摘要
+func (rtm *RefreshTokenManager) ListRefreshTokenDigests(loginID string) ([]*RefreshTokenInfo, error) {
+	return rtm.listRefreshTokens(loginID)
+}
+
+// listRefreshTokens Loads the stored info of every token family of a user | 加载用户每个令牌家族的存储信息
+func (rtm *RefreshTokenManager) listRefreshTokens(loginID string) ([]*RefreshTokenInfo, error) {
+	keys, err := rtm.storage.Keys(rtm.getUserIndexKey(loginID, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]*RefreshTokenInfo, 0, len(keys))
+	for _, key := range keys {
+		familyID, ok := rtm.indexedFamily(key, loginID)
+		if !ok {
+			continue
+		}
+
+		data, err := rtm.storage.Get(rtm.getFamilyKey(familyID))
+		current, ok := data.(string)
+		if err != nil || !ok {
+			rtm.storage.Delete(key) // Stale index entry | 过期的索引项
+			continue
+		}
+
+		info, err := rtm.loadRefreshInfoByKey(rtm.getStoredRefreshKey(current))
+		if err != nil || info.LoginID != loginID {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].CreateTime < infos[j].CreateTime
+	})
+
+	return infos, nil
+}
+
+// RevokeAllRefreshTokens Revokes every token family of a user, optionally limited to one device | 撤销用户的所有令牌家族，可限定设备
+func (rtm *RefreshTokenManager) RevokeAllRefreshTokens(loginID string, device ...string) error {
+	keys, err := rtm.storage.Keys(rtm.getUserIndexKey(loginID, "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		familyID, ok := rtm.indexedFamily(key, loginID)
+		if !ok {
+			continue
+		}
+		if len(device) > 0 && device[0] != "" {
+			data, err := rtm.storage.Get(key)
+			if deviceType, ok := data.(string); err != nil || !ok || deviceType != device[0] {
+				continue
+			}
+		}
+
+		if err := rtm.RevokeFamily(familyID); err != nil {
+			return err
+		}
+		rtm.storage.Delete(key)
+	}
+
+	return nil
+}
+
 // GetRefreshTokenInfo Gets refresh token information | 获取刷新令牌信息
 func (rtm *RefreshTokenManager) GetRefreshTokenInfo(refreshToken string) (*RefreshTokenInfo, error) {
 	if refreshToken == "" {
 		return nil, ErrInvalidRefreshToken
 	}
 
-	key := rtm.getRefreshKey(refreshToken)
+	return rtm.loadRefreshInfo(refreshToken)
+}
+
+// IsValid Checks if refresh token is valid | 检查刷新令牌是否有效
+func (rtm *RefreshTokenManager) IsValid(refreshToken string) bool {
+	info, err := rtm.GetRefreshTokenInfo(refreshToken)
+	if err != nil {
+		return false
+	}
+
+	return time.Now().Unix() <= info.ExpireTime
+}
+
+// issueAccessToken Issues access token through the issuer or directly | 通过签发者或直接签发访问令牌
+func (rtm *RefreshTokenManager) issueAccessToken(loginID, device string) (string, error) {
+	if rtm.issuer != nil {
+		return rtm.issuer.IssueAccessToken(loginID, device)
+	}
+
+	accessToken, err := rtm.tokenGen.Generate(loginID, device)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	// Save token-loginID mapping (符合 Java sa-token 设计) | 保存 Token-LoginID 映射
+	if err := rtm.storage.Set(rtm.getTokenKey(accessToken), loginID, rtm.accessTTL); err != nil {
+		return "", fmt.Errorf("failed to save token: %w", err)
+	}
 
+	return accessToken, nil
+}
+
+// revokeAccessToken Revokes the access token recorded in refresh token info | 撤销刷新令牌信息中记录的访问令牌
+func (rtm *RefreshTokenManager) revokeAccessToken(info *RefreshTokenInfo) {
+	if info.AccessToken == "" {
+		return
+	}
+	if rtm.issuer != nil {
+		rtm.issuer.RevokeAccessToken(info.LoginID, info.Device, info.AccessToken)
+		return
+	}
+	rtm.storage.Delete(rtm.getStoredTokenKey(info.AccessToken))
+}
+
+// triggerReuse Fires refresh token reuse event | 触发刷新令牌重用事件
+func (rtm *RefreshTokenManager) triggerReuse(info *RefreshTokenInfo) {
+	if rtm.eventManager == nil {
+		return
+	}
+	rtm.eventManager.Trigger(&listener.EventData{
+		Event:   listener.EventRefreshTokenReuse,
+		LoginID: info.LoginID,
+		Device:  info.Device,
+		Extra: map[string]any{
+			"familyId": info.FamilyID,
+		},
+	})
+}
+
+// saveRefreshInfo Stores refresh token info, keeping only token digests when TokenHashAtRest is enabled | 存储刷新令牌信息，启用TokenHashAtRest时只保留Token摘要
+func (rtm *RefreshTokenManager) saveRefreshInfo(info *RefreshTokenInfo) error {
+	stored := *info
+	stored.RefreshToken = rtm.tokenGen.Digest(info.RefreshToken)
+	stored.AccessToken = rtm.tokenGen.Digest(info.AccessToken)
+	return rtm.storage.Set(rtm.getRefreshKey(info.RefreshToken), &stored, rtm.getRemainingTTL(info))
+}
+
+// capExpireTime Caps expiration by the maximum family lifetime | 按令牌家族最长寿命限制过期时间
+func (rtm *RefreshTokenManager) capExpireTime(expireTime, familyCreateTime int64) int64 {
+	if rtm.maxLifetime <= 0 {
+		return expireTime
+	}
+	if limit := time.Unix(familyCreateTime, 0).Add(rtm.maxLifetime).Unix(); expireTime > limit {
+		return limit
+	}
+	return expireTime
+}
+
+// getRemainingTTL Gets storage TTL until refresh token expiration | 获取距刷新令牌过期的存储有效期
+func (rtm *RefreshTokenManager) getRemainingTTL(info *RefreshTokenInfo) time.Duration {
+	ttl := time.Until(time.Unix(info.ExpireTime, 0))
+	if ttl < time.Second {
+		return time.Second
+	}
+	return ttl
+}
+
+// loadRefreshInfo Loads refresh token info from storage | 从存储加载刷新令牌信息
+func (rtm *RefreshTokenManager) loadRefreshInfo(refreshToken string) (*RefreshTokenInfo, error) {
+	info, err := rtm.loadRefreshInfoByKey(rtm.getRefreshKey(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the digest is persisted in hash mode | 摘要模式下只持久化了摘要
+	info.RefreshToken = refreshToken
+	return info, nil
+}
+
+// loadRefreshInfoByKey Loads refresh token info by storage key | 根据存储键加载刷新令牌信息
+func (rtm *RefreshTokenManager) loadRefreshInfoByKey(key string) (*RefreshTokenInfo, error) {
 	data, err := rtm.storage.Get(key)
 	if err != nil || data == nil {
 		return nil, ErrInvalidRefreshToken
 	}
 
-	dataBytes, err := utils.ToBytes(data)
+	// Convert to RefreshTokenInfo | 转换为 RefreshTokenInfo
+	var dataBytes []byte
+	switch v := data.(type) {
+	case *RefreshTokenInfo:
+		dataBytes, err = v.MarshalBinary()
+	default:
+		dataBytes, err = utils.ToBytes(data)
+	}
 	if err != nil {
 		return nil, ErrInvalidRefreshData
 	}
 
 	info := &RefreshTokenInfo{}
-	err = info.UnmarshalBinary(dataBytes)
-	if err != nil {
+	if err := info.UnmarshalBinary(dataBytes); err != nil {
 		return nil, ErrInvalidRefreshData
 	}
 
 	return info, nil
 }
 
-// IsValid Checks if refresh token is valid | 检查刷新令牌是否有效
-func (rtm *RefreshTokenManager) IsValid(refreshToken string) bool {
-	info, err := rtm.GetRefreshTokenInfo(refreshToken)
-	if err != nil {
-		return false
+// randomHex Generates random hex string | 生成随机十六进制字符串
+func randomHex(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
 	}
-
-	return time.Now().Unix() <= info.ExpireTime
+	return hex.EncodeToString(bytes), nil
 }
 
 // getRefreshKey Gets storage key for refresh token | 获取刷新令牌的存储键
 func (rtm *RefreshTokenManager) getRefreshKey(refreshToken string) string {
-	return rtm.keyPrefix + RefreshKeySuffix + refreshToken
+	return rtm.getStoredRefreshKey(rtm.tokenGen.Digest(refreshToken))
+}
+
+// getStoredRefreshKey Gets refresh token storage key from its stored form | 根据存储形式获取刷新令牌的存储键
+func (rtm *RefreshTokenManager) getStoredRefreshKey(storedValue string) string {
+	return rtm.keyPrefix + RefreshKeySuffix + storedValue
+}
+
+// getUsedKey Gets storage key of the consumed marker of a refresh token | 获取刷新令牌已消费标记的存储键
+func (rtm *RefreshTokenManager) getUsedKey(refreshToken string) string {
+	return rtm.keyPrefix + UsedKeySuffix + rtm.tokenGen.Digest(refreshToken)
+}
+
+// getFamilyKey Gets storage key for token family | 获取令牌家族的存储键
+func (rtm *RefreshTokenManager) getFamilyKey(familyID string) string {
+	return rtm.keyPrefix + FamilyKeySuffix + familyID
+}
+
+// getUserIndexKey Gets storage key indexing a token family under its loginID | 获取按登录ID索引令牌家族的存储键
+func (rtm *RefreshTokenManager) getUserIndexKey(loginID, familyID string) string {
+	return rtm.keyPrefix + UserIndexKeySuffix + loginID + ":" + familyID
+}
+
+// indexedFamily Gets the family ID of an index key owned by exactly loginID | 获取恰好属于loginID的索引键中的家族ID
+// The "<loginID>:*" pattern also matches login IDs extending this one, such as "a:b" for "a" | "<loginID>:*"模式也会匹配以其开头的登录ID，例如"a"会匹配"a:b"
+func (rtm *RefreshTokenManager) indexedFamily(key, loginID string) (string, bool) {
+	familyID := strings.TrimPrefix(key, rtm.getUserIndexKey(loginID, ""))
+	if familyID == key || familyID == "" || strings.Contains(familyID, ":") {
+		return "", false
+	}
+	return familyID, true
 }
 
 // getTokenKey Gets token storage key | 获取Token存储键
 func (rtm *RefreshTokenManager) getTokenKey(tokenValue string) string {
-	return rtm.keyPrefix + rtm.tokenKeyPrefix + tokenValue
+	return rtm.getStoredTokenKey(rtm.tokenGen.Digest(tokenValue))
+}
+
+// getStoredTokenKey Gets token storage key from its stored form | 根据存储形式获取Token存储键
+func (rtm *RefreshTokenManager) getStoredTokenKey(storedValue string) string {
+	return rtm.keyPrefix + rtm.tokenKeyPrefix + storedValue
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strings"
 	"time"
 
 	"github.com/click33/sa-token-go/core/config"
@@ -22,12 +23,16 @@ const (
 	HashRandomBytesLen  = 16 // Random bytes length for hash token | 哈希Token的随机字节长度
 	TimestampRandomLen  = 8  // Random bytes length for timestamp token | 时间戳Token的随机字节长度
 	DefaultSimpleLength = 16 // Default simple token length | 默认简单Token长度
+
+	DigestPrefixSHA256 = "sha256:" // Prefix of SHA-256 token digests | SHA-256 Token摘要前缀
+	DigestPrefixHMAC   = "hmac:"   // Prefix of HMAC-SHA256 token digests | HMAC-SHA256 Token摘要前缀
 )
 
 // Error variables | 错误变量
 var (
 	ErrInvalidToken            = fmt.Errorf("invalid token")
 	ErrUnexpectedSigningMethod = fmt.Errorf("unexpected signing method")
+	ErrRawTokenUnavailable     = fmt.Errorf("raw token is not stored when TokenHashAtRest is enabled")
 )
 
 // Generator Token generator | Token生成器
@@ -77,6 +82,29 @@ func (g *Generator) Generate(loginID string, device string) (string, error) {
 	}
 }
 
+// Digest Returns the at-rest form of a token value | 返回Token值的存储形式
+// The token is returned unchanged when TokenHashAtRest is disabled | 未启用TokenHashAtRest时原样返回
+func (g *Generator) Digest(tokenValue string) string {
+	if !g.config.TokenHashAtRest || tokenValue == "" {
+		return tokenValue
+	}
+
+	if g.config.TokenHashSecret != "" {
+		return DigestPrefixHMAC + utils.HMACSHA256Hash(g.config.TokenHashSecret, tokenValue)
+	}
+	return DigestPrefixSHA256 + utils.SHA256Hash(tokenValue)
+}
+
+// HashAtRest Checks if tokens are stored as digests | 检查Token是否以摘要形式存储
+func (g *Generator) HashAtRest() bool {
+	return g.config.TokenHashAtRest
+}
+
+// IsDigest Checks if a stored value is a token digest | 检查存储值是否为Token摘要
+func IsDigest(value string) bool {
+	return strings.HasPrefix(value, DigestPrefixSHA256) || strings.HasPrefix(value, DigestPrefixHMAC)
+}
+
 // ============ Token Generation Methods | Token生成方法 ============
 
 // generateUUID Generates UUID token | 生成UUID Token
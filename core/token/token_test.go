@@ -117,3 +117,27 @@ func TestAllTokenStyles(t *testing.T) {
 		})
 	}
 }
+
+func TestDigest(t *testing.T) {
+	plain := NewGenerator(&config.Config{TokenStyle: config.TokenStyleUUID})
+	if got := plain.Digest("abc"); got != "abc" {
+		t.Errorf("Digest should return raw token when hashing is disabled, got %s", got)
+	}
+
+	sha := NewGenerator(&config.Config{TokenStyle: config.TokenStyleUUID, TokenHashAtRest: true})
+	digest := sha.Digest("abc")
+	if !IsDigest(digest) || digest == "abc" {
+		t.Errorf("Digest should hash token when hashing is enabled, got %s", digest)
+	}
+	if sha.Digest("abc") != digest {
+		t.Error("Digest should be deterministic")
+	}
+	if sha.Digest(digest) == digest {
+		t.Error("Digest of a digest must not pass through unchanged")
+	}
+
+	hmac := NewGenerator(&config.Config{TokenStyle: config.TokenStyleUUID, TokenHashAtRest: true, TokenHashSecret: "k"})
+	if got := hmac.Digest("abc"); got == digest || !IsDigest(got) {
+		t.Errorf("HMAC digest should differ from SHA-256 digest, got %s", got)
+	}
+}
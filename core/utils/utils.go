@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -11,6 +12,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/click33/sa-token-go/core/permission"
 )
 
 // Constants for time durations | 时间常量
@@ -24,8 +27,9 @@ const (
 
 // Constants for string operations | 字符串操作常量
 const (
-	DefaultSeparator = ","
-	WildcardChar     = "*"
+	DefaultSeparator    = ","
+	WildcardChar        = "*"
+	PermissionSeparator = ":"
 )
 
 // ============ Random Generation | 随机生成 ============
@@ -299,6 +303,12 @@ func MatchPattern(pattern, str string) bool {
 	return true
 }
 
+// MatchPermission Matches permission code against pattern | 权限码匹配
+// See permission.Match for the pattern syntax | 模式语法见 permission.Match
+func MatchPermission(pattern, permissionCode string) bool {
+	return permission.Match(pattern, permissionCode)
+}
+
 // ============ Time & Duration | 时间和时长 ============
 
 // FormatDuration Formats duration in seconds to human-readable format | 格式化时间段（秒）为人类可读格式
@@ -502,6 +512,13 @@ func SHA256Hash(s string) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// HMACSHA256Hash Generates keyed HMAC-SHA256 hash of string | 生成字符串的HMAC-SHA256哈希
+func HMACSHA256Hash(key, s string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 // Base64Encode Encodes string to base64 | Base64编码
 func Base64Encode(s string) string {
 	return base64.StdEncoding.EncodeToString([]byte(s))
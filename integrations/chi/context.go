@@ -135,6 +135,11 @@ func (c *ChiContext) GetURL() string {
 	return c.r.URL.String()
 }
 
+// GetHost implements adapter.HostProvider.
+func (c *ChiContext) GetHost() string {
+	return c.r.Host
+}
+
 // GetUserAgent implements adapter.RequestContext.
 func (c *ChiContext) GetUserAgent() string {
 	return c.r.UserAgent()
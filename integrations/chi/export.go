@@ -1,6 +1,7 @@
 package chi
 
 import (
+	"crypto/rsa"
 	"time"
 
 	"github.com/click33/sa-token-go/core"
@@ -31,19 +32,37 @@ const (
 
 // Core types | 核心类型
 type (
-	Manager             = core.Manager
-	TokenInfo           = core.TokenInfo
-	Session             = core.Session
-	TokenGenerator      = core.TokenGenerator
-	SaTokenContext      = core.SaTokenContext
-	Builder             = core.Builder
-	NonceManager        = core.NonceManager
-	RefreshTokenInfo    = core.RefreshTokenInfo
-	RefreshTokenManager = core.RefreshTokenManager
-	OAuth2Server        = core.OAuth2Server
-	OAuth2Client        = core.OAuth2Client
-	OAuth2AccessToken   = core.OAuth2AccessToken
-	OAuth2GrantType     = core.OAuth2GrantType
+	Manager                = core.Manager
+	TokenInfo              = core.TokenInfo
+	Session                = core.Session
+	TokenGenerator         = core.TokenGenerator
+	SaTokenContext         = core.SaTokenContext
+	Builder                = core.Builder
+	NonceManager           = core.NonceManager
+	RefreshTokenInfo       = core.RefreshTokenInfo
+	RefreshTokenManager    = core.RefreshTokenManager
+	OAuth2Server           = core.OAuth2Server
+	OAuth2Client           = core.OAuth2Client
+	OAuth2AccessToken      = core.OAuth2AccessToken
+	OAuth2GrantType        = core.OAuth2GrantType
+	OAuth2PasswordVerifier = core.OAuth2PasswordVerifier
+	OAuth2ClientStore      = core.OAuth2ClientStore
+	OAuth2Consent          = core.OAuth2Consent
+	OAuth2ConsentStore     = core.OAuth2ConsentStore
+	OAuth2DeviceAuth       = core.OAuth2DeviceAuth
+	ScopePermissionMapper  = core.ScopePermissionMapper
+	PermissionProvider     = core.PermissionProvider
+	Role                   = core.Role
+	DisableInfo            = core.DisableInfo
+	PermissionMatcher      = core.PermissionMatcher
+	Policy                 = core.Policy
+	PolicyRequest          = core.PolicyRequest
+	PolicyExpression       = core.PolicyExpression
+	TenantResolver         = core.TenantResolver
+	TenantConfigFunc       = core.TenantConfigFunc
+	TenantValidator        = core.TenantValidator
+	OIDCProvider           = core.OIDCProvider
+	OIDCClaimsProvider     = core.OIDCClaimsProvider
 )
 
 // Adapter interfaces | 适配器接口
@@ -64,17 +83,18 @@ type (
 
 // Event constants | 事件常量
 const (
-	EventLogin           = core.EventLogin
-	EventLogout          = core.EventLogout
-	EventKickout         = core.EventKickout
-	EventDisable         = core.EventDisable
-	EventUntie           = core.EventUntie
-	EventRenew           = core.EventRenew
-	EventCreateSession   = core.EventCreateSession
-	EventDestroySession  = core.EventDestroySession
-	EventPermissionCheck = core.EventPermissionCheck
-	EventRoleCheck       = core.EventRoleCheck
-	EventAll             = core.EventAll
+	EventLogin             = core.EventLogin
+	EventLogout            = core.EventLogout
+	EventKickout           = core.EventKickout
+	EventDisable           = core.EventDisable
+	EventUntie             = core.EventUntie
+	EventRenew             = core.EventRenew
+	EventCreateSession     = core.EventCreateSession
+	EventDestroySession    = core.EventDestroySession
+	EventPermissionCheck   = core.EventPermissionCheck
+	EventRoleCheck         = core.EventRoleCheck
+	EventRefreshTokenReuse = core.EventRefreshTokenReuse
+	EventAll               = core.EventAll
 )
 
 // OAuth2 grant type constants | OAuth2授权类型常量
@@ -83,6 +103,7 @@ const (
 	GrantTypeRefreshToken      = core.GrantTypeRefreshToken
 	GrantTypeClientCredentials = core.GrantTypeClientCredentials
 	GrantTypePassword          = core.GrantTypePassword
+	GrantTypeDeviceCode        = core.GrantTypeDeviceCode
 )
 
 // Utility functions | 工具函数
@@ -155,6 +176,36 @@ func NewOAuth2Server(storage Storage, prefix string) *OAuth2Server {
 	return core.NewOAuth2Server(storage, prefix)
 }
 
+// NewOAuth2StorageClientStore creates an OAuth2 client store shared through storage | 创建通过存储共享的OAuth2客户端存储
+func NewOAuth2StorageClientStore(storage Storage, prefix string) OAuth2ClientStore {
+	return core.NewOAuth2StorageClientStore(storage, prefix)
+}
+
+// NewOIDCProvider creates an OpenID Connect provider on the manager's OAuth2 server | 在管理器的OAuth2服务器上创建OpenID Connect提供方
+func NewOIDCProvider(mgr *Manager, issuer string, key *rsa.PrivateKey) (*OIDCProvider, error) {
+	return core.NewOIDCProvider(mgr, issuer, key)
+}
+
+// CompilePolicy compiles a policy expression | 编译策略表达式
+func CompilePolicy(expr string) (*PolicyExpression, error) {
+	return core.CompilePolicy(expr)
+}
+
+// HeaderTenantResolver resolves the tenant from a request header | 从请求头解析租户
+func HeaderTenantResolver(header string) TenantResolver {
+	return core.HeaderTenantResolver(header)
+}
+
+// SubdomainTenantResolver resolves the tenant from the subdomain below baseDomain | 从baseDomain下的子域名解析租户
+func SubdomainTenantResolver(baseDomain string) TenantResolver {
+	return core.SubdomainTenantResolver(baseDomain)
+}
+
+// ClientFingerprint derives a client fingerprint from IP and User-Agent | 根据IP和User-Agent生成客户端指纹
+func ClientFingerprint(clientIP, userAgent string) string {
+	return core.ClientFingerprint(clientIP, userAgent)
+}
+
 // ============ Global StpUtil functions | 全局StpUtil函数 ============
 
 // SetManager sets the global Manager (must be called first) | 设置全局Manager（必须先调用此方法）
@@ -167,6 +218,16 @@ func GetManager() *Manager {
 	return stputil.GetManager()
 }
 
+// PutManager registers a Manager under its login type | 按登录类型注册Manager
+func PutManager(mgr *Manager) {
+	stputil.PutManager(mgr)
+}
+
+// Use gets the Manager of a login type | 获取指定登录类型的Manager
+func Use(loginType string) *Manager {
+	return stputil.Use(loginType)
+}
+
 // ============ Authentication | 登录认证 ============
 
 // Login performs user login | 用户登录
@@ -214,6 +275,11 @@ func GetTokenValue(loginID interface{}, device ...string) (string, error) {
 	return stputil.GetTokenValue(loginID, device...)
 }
 
+// GetTokenDigest gets the at-rest form of the token for a login ID | 获取登录ID对应Token的存储形式
+func GetTokenDigest(loginID interface{}, device ...string) (string, error) {
+	return stputil.GetTokenDigest(loginID, device...)
+}
+
 // GetTokenInfo gets token information | 获取Token信息
 func GetTokenInfo(tokenValue string) (*TokenInfo, error) {
 	return stputil.GetTokenInfo(tokenValue)
@@ -253,6 +319,56 @@ func Untie(loginID interface{}) error {
 	return stputil.Untie(loginID)
 }
 
+// DisableService disables one service of an account | 封禁账号的指定服务
+func DisableService(loginID interface{}, service string, duration time.Duration) error {
+	return stputil.DisableService(loginID, service, duration)
+}
+
+// DisableLevel disables one service of an account at a level | 按等级封禁账号的指定服务
+func DisableLevel(loginID interface{}, service string, level int, duration time.Duration) error {
+	return stputil.DisableLevel(loginID, service, level, duration)
+}
+
+// DisableWithInfo disables an account with a ban record carrying reason and operator | 使用带原因和操作人的封禁记录封禁账号
+func DisableWithInfo(info *DisableInfo, duration time.Duration) error {
+	return stputil.DisableWithInfo(info, duration)
+}
+
+// UntieService lifts the bans of the given services | 解除指定服务的封禁
+func UntieService(loginID interface{}, services ...string) error {
+	return stputil.UntieService(loginID, services...)
+}
+
+// IsDisableService checks if a service of the account is disabled | 检查账号的指定服务是否被封禁
+func IsDisableService(loginID interface{}, service string) bool {
+	return stputil.IsDisableService(loginID, service)
+}
+
+// IsDisableLevel checks if a service of the account is disabled at level or above | 检查账号的指定服务是否被封禁且等级不低于level
+func IsDisableLevel(loginID interface{}, service string, level int) bool {
+	return stputil.IsDisableLevel(loginID, service, level)
+}
+
+// CheckDisableLevel returns an error when the service is disabled at level or above | 服务被封禁且等级不低于level时返回错误
+func CheckDisableLevel(loginID interface{}, service string, level int) error {
+	return stputil.CheckDisableLevel(loginID, service, level)
+}
+
+// GetDisableLevel gets the disable level of a service, -2 when not disabled | 获取服务的封禁等级，未封禁时返回-2
+func GetDisableLevel(loginID interface{}, service string) int {
+	return stputil.GetDisableLevel(loginID, service)
+}
+
+// GetDisableInfo gets the ban record of a service | 获取服务的封禁记录
+func GetDisableInfo(loginID interface{}, service string) (*DisableInfo, error) {
+	return stputil.GetDisableInfo(loginID, service)
+}
+
+// GetDisableList lists the ban records of an account | 列出账号的封禁记录
+func GetDisableList(loginID interface{}) ([]*DisableInfo, error) {
+	return stputil.GetDisableList(loginID)
+}
+
 // ============ Permission Check | 权限验证 ============
 
 // CheckPermission checks if the account has specified permission | 检查账号是否拥有指定权限
@@ -345,9 +461,14 @@ func LoginWithRefreshToken(loginID interface{}, device ...string) (*RefreshToken
 	return stputil.LoginWithRefreshToken(loginID, device...)
 }
 
+// LoginWithBoundRefreshToken performs login and binds the refresh token to a client fingerprint | 登录并将刷新令牌绑定到客户端指纹
+func LoginWithBoundRefreshToken(loginID interface{}, device, fingerprint string) (*RefreshTokenInfo, error) {
+	return stputil.LoginWithBoundRefreshToken(loginID, device, fingerprint)
+}
+
 // RefreshAccessToken refreshes the access token using a refresh token | 使用刷新令牌刷新访问令牌
-func RefreshAccessToken(refreshToken string) (*RefreshTokenInfo, error) {
-	return stputil.RefreshAccessToken(refreshToken)
+func RefreshAccessToken(refreshToken string, fingerprint ...string) (*RefreshTokenInfo, error) {
+	return stputil.RefreshAccessToken(refreshToken, fingerprint...)
 }
 
 // RevokeRefreshToken revokes a refresh token | 撤销刷新令牌
@@ -355,6 +476,21 @@ func RevokeRefreshToken(refreshToken string) error {
 	return stputil.RevokeRefreshToken(refreshToken)
 }
 
+// ListRefreshTokens lists active refresh tokens of a user | 列出用户有效的刷新令牌
+func ListRefreshTokens(loginID interface{}) ([]*RefreshTokenInfo, error) {
+	return stputil.ListRefreshTokens(loginID)
+}
+
+// ListRefreshTokenDigests lists active refresh tokens of a user in at-rest form | 以存储形式列出用户有效的刷新令牌
+func ListRefreshTokenDigests(loginID interface{}) ([]*RefreshTokenInfo, error) {
+	return stputil.ListRefreshTokenDigests(loginID)
+}
+
+// RevokeAllRefreshTokens revokes all refresh tokens of a user | 撤销用户的所有刷新令牌
+func RevokeAllRefreshTokens(loginID interface{}, device ...string) error {
+	return stputil.RevokeAllRefreshTokens(loginID, device...)
+}
+
 // GetOAuth2Server gets the OAuth2 server instance | 获取OAuth2服务器实例
 func GetOAuth2Server() *OAuth2Server {
 	return stputil.GetOAuth2Server()
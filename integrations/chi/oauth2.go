@@ -0,0 +1,102 @@
+package chi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/click33/sa-token-go/core"
+)
+
+// ============ OAuth2 Endpoints | OAuth2端点 ============
+
+// OAuth2Router route registrar implemented by chi.Router | chi.Router实现的路由注册接口
+type OAuth2Router interface {
+	Get(pattern string, h http.HandlerFunc)
+	Post(pattern string, h http.HandlerFunc)
+}
+
+// OAuth2Handler gets the OAuth2 endpoint handlers for customization | 获取OAuth2端点处理器（用于自定义）
+func (p *Plugin) OAuth2Handler() *core.OAuth2Handler {
+	return p.oauth2Handler
+}
+
+// RegisterOAuth2Routes registers authorize, token, revoke, introspect and device authorization endpoints | 注册授权、令牌、撤销、内省和设备授权端点
+// Call OAuth2Handler().SetTrustedOrigins first to mount device verification decisions | 需先调用OAuth2Handler().SetTrustedOrigins才会挂载设备验证决定端点
+func (p *Plugin) RegisterOAuth2Routes(r OAuth2Router) {
+	r.Get(core.OAuth2AuthorizePath, p.OAuth2AuthorizeHandler)
+	r.Post(core.OAuth2TokenPath, p.OAuth2TokenHandler)
+	r.Post(core.OAuth2RevokePath, p.OAuth2RevokeHandler)
+	r.Post(core.OAuth2IntrospectPath, p.OAuth2IntrospectHandler)
+	r.Post(core.OAuth2DeviceAuthorizationPath, p.OAuth2DeviceAuthorizationHandler)
+	r.Get(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	// Decisions are only mounted once trusted origins are set | 设置可信来源后才挂载决定端点
+	if p.oauth2Handler.AcceptsDeviceDecisions() {
+		r.Post(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	}
+}
+
+// OAuth2AuthorizeHandler authorization endpoint, requires a logged-in user | 授权端点，需要用户已登录
+func (p *Plugin) OAuth2AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.Authorize(NewChiContext(w, r)))
+}
+
+// OAuth2TokenHandler token endpoint | 令牌端点
+func (p *Plugin) OAuth2TokenHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.Token(NewChiContext(w, r)))
+}
+
+// OAuth2RevokeHandler token revocation endpoint (RFC 7009) | 令牌撤销端点
+func (p *Plugin) OAuth2RevokeHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.Revoke(NewChiContext(w, r)))
+}
+
+// OAuth2IntrospectHandler token introspection endpoint (RFC 7662) | 令牌内省端点
+func (p *Plugin) OAuth2IntrospectHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.Introspect(NewChiContext(w, r)))
+}
+
+// OAuth2DeviceAuthorizationHandler device authorization endpoint (RFC 8628) | 设备授权端点
+func (p *Plugin) OAuth2DeviceAuthorizationHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.DeviceAuthorization(NewChiContext(w, r)))
+}
+
+// OAuth2DeviceVerificationHandler device user code verification, requires a logged-in user | 设备用户码验证端点，需要用户已登录
+func (p *Plugin) OAuth2DeviceVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.DeviceVerification(NewChiContext(w, r)))
+}
+
+// OAuth2RegisterHandler dynamic client registration endpoint (RFC 7591), not mounted by RegisterOAuth2Routes | 动态客户端注册端点，RegisterOAuth2Routes不会自动挂载
+func (p *Plugin) OAuth2RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	writeOAuth2Response(w, p.oauth2Handler.Register(NewChiContext(w, r)))
+}
+
+// ============ OIDC Endpoints | OIDC端点 ============
+
+// RegisterOIDCRoutes registers discovery, JWKS and userinfo endpoints of an OIDC provider | 注册OIDC提供方的发现、JWKS和用户信息端点
+func (p *Plugin) RegisterOIDCRoutes(r OAuth2Router, provider *core.OIDCProvider) {
+	r.Get(core.OIDCDiscoveryPath, OIDCHandler(provider.DiscoveryEndpoint))
+	r.Get(core.OIDCJWKSPath, OIDCHandler(provider.JWKSEndpoint))
+	r.Get(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+	r.Post(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+}
+
+// OIDCHandler adapts an OIDC provider endpoint, e.g. OIDCHandler(provider.UserInfoEndpoint) | 适配OIDC提供方端点，如OIDCHandler(provider.UserInfoEndpoint)
+func OIDCHandler(endpoint func(core.RequestContext) *core.OAuth2Response) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeOAuth2Response(w, endpoint(NewChiContext(w, r)))
+	}
+}
+
+// writeOAuth2Response writes an OAuth2 endpoint response | 写入OAuth2端点响应
+func writeOAuth2Response(w http.ResponseWriter, resp *core.OAuth2Response) {
+	for key, value := range resp.Header {
+		w.Header().Set(key, value)
+	}
+	if resp.Body == nil {
+		w.WriteHeader(resp.Status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.Status)
+	json.NewEncoder(w).Encode(resp.Body)
+}
@@ -1,25 +1,35 @@
 package chi
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 
 	"github.com/click33/sa-token-go/core"
+	"github.com/click33/sa-token-go/stputil"
 )
 
 // Plugin Chi plugin for Sa-Token | Chi插件
 type Plugin struct {
-	manager *core.Manager
+	manager       *core.Manager
+	oauth2Handler *core.OAuth2Handler
 }
 
 // NewPlugin creates a Chi plugin | 创建Chi插件
 func NewPlugin(manager *core.Manager) *Plugin {
 	return &Plugin{
-		manager: manager,
+		manager:       manager,
+		oauth2Handler: core.NewOAuth2Handler(manager),
 	}
 }
 
+// Use returns a plugin checking another login type, e.g. plugin.Use("admin").AuthMiddleware() | 返回检查其他登录类型的插件，例如plugin.Use("admin").AuthMiddleware()
+// The login type must be registered with stputil.PutManager() | 登录类型需先通过stputil.PutManager()注册
+func (p *Plugin) Use(loginType string) *Plugin {
+	return NewPlugin(stputil.Use(loginType))
+}
+
 // AuthMiddleware authentication middleware | 认证中间件
 func (p *Plugin) AuthMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -46,7 +56,7 @@ func (p *Plugin) PermissionRequired(permission string) func(http.Handler) http.H
 			ctx := NewChiContext(w, r)
 			saCtx := core.NewContext(ctx, p.manager)
 
-			if err := saCtx.CheckLogin(); err != nil {
+			if _, err := saCtx.GetCallerID(); err != nil {
 				writeErrorResponse(w, err)
 				return
 			}
@@ -62,6 +72,67 @@ func (p *Plugin) PermissionRequired(permission string) func(http.Handler) http.H
 	}
 }
 
+// ScopeRequired OAuth2 scope validation middleware | OAuth2权限范围验证中间件
+// Checks the Bearer access token carries all scopes | 校验Bearer访问令牌是否包含所有权限范围
+func (p *Plugin) ScopeRequired(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewChiContext(w, r)
+			saCtx := core.NewContext(ctx, p.manager)
+
+			token, err := saCtx.CheckOAuth2Scope(scopes...)
+			if err != nil {
+				writeErrorResponse(w, core.NewOAuth2ScopeError(err, scopes...))
+				return
+			}
+
+			reqCtx := context.WithValue(r.Context(), "satoken", saCtx)
+			reqCtx = context.WithValue(reqCtx, "oauth2Token", token)
+			next.ServeHTTP(w, r.WithContext(reqCtx))
+		})
+	}
+}
+
+// ResourceResolver Loads the resource a request targets | 加载请求的目标资源
+type ResourceResolver func(r *http.Request) (any, error)
+
+// ResourceParam Loads the resource by a request parameter | 根据请求参数加载资源
+// param extracts the id, e.g. func(r *http.Request) string { return chi.URLParam(r, "id") } | param负责提取ID，例如chi.URLParam
+func ResourceParam(param func(r *http.Request) string, load func(id string) (any, error)) ResourceResolver {
+	return func(r *http.Request) (any, error) {
+		return load(param(r))
+	}
+}
+
+// PolicyRequired Attribute-based policy middleware | 基于属性的策略中间件
+func (p *Plugin) PolicyRequired(action string, resolve ResourceResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewChiContext(w, r)
+			saCtx := core.NewContext(ctx, p.manager)
+
+			var resource any
+			if resolve != nil {
+				loaded, err := resolve(r)
+				if err != nil {
+					writeErrorResponse(w, core.NewResourceError(err))
+					return
+				}
+				resource = loaded
+			}
+
+			if err := saCtx.Check(action, resource); err != nil {
+				writeErrorResponse(w, core.NewPolicyError(err, action))
+				return
+			}
+
+			reqCtx := context.WithValue(r.Context(), "satoken", saCtx)
+			reqCtx = context.WithValue(reqCtx, "resource", resource)
+			next.ServeHTTP(w, r.WithContext(reqCtx))
+		})
+	}
+}
+
 // RoleRequired role validation middleware | 角色验证中间件
 func (p *Plugin) RoleRequired(role string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -103,7 +174,8 @@ func (p *Plugin) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		device = "default"
 	}
 
-	token, err := p.manager.Login(req.Username, device)
+	mgr := p.manager.ResolveTenant(NewChiContext(w, r)) // Tenant of the request | 请求所属租户
+	token, err := mgr.Login(req.Username, device)
 	if err != nil {
 		writeErrorResponse(w, core.NewError(core.CodeServerError, "login failed", err))
 		return
@@ -124,6 +196,22 @@ func GetSaToken(r *http.Request) (*core.SaTokenContext, bool) {
 	return ctx, ok
 }
 
+// GetOAuth2Token gets OAuth2 access token set by ScopeRequired | 获取ScopeRequired设置的OAuth2访问令牌
+func GetOAuth2Token(r *http.Request) (*core.OAuth2AccessToken, bool) {
+	value := r.Context().Value("oauth2Token")
+	if value == nil {
+		return nil, false
+	}
+	token, ok := value.(*core.OAuth2AccessToken)
+	return token, ok
+}
+
+// GetResource gets the resource loaded by PolicyRequired | 获取PolicyRequired加载的资源
+func GetResource(r *http.Request) (any, bool) {
+	value := r.Context().Value("resource")
+	return value, value != nil
+}
+
 // ============ Error Handling Helpers | 错误处理辅助函数 ============
 
 // writeErrorResponse writes a standardized error response | 写入标准化的错误响应
@@ -133,6 +221,11 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 	var message string
 	var httpStatus int
 
+	// Typed login state errors carry their own codes | 登录状态错误携带各自的错误码
+	if loginErr, ok := core.AsLoginError(err); ok {
+		err = loginErr
+	}
+
 	// Check if it's a SaTokenError | 检查是否为SaTokenError
 	if errors.As(err, &saErr) {
 		code = saErr.Code
@@ -167,9 +260,10 @@ func writeSuccessResponse(w http.ResponseWriter, data interface{}) {
 // getHTTPStatusFromCode converts Sa-Token error code to HTTP status | 将Sa-Token错误码转换为HTTP状态码
 func getHTTPStatusFromCode(code int) int {
 	switch code {
-	case core.CodeNotLogin:
+	case core.CodeNotLogin, core.CodeTokenInvalid, core.CodeTokenExpired,
+		core.CodeKickedOut, core.CodeTokenReplaced, core.CodeActiveTimeout:
 		return http.StatusUnauthorized
-	case core.CodePermissionDenied:
+	case core.CodePermissionDenied, core.CodeAccountDisabled:
 		return http.StatusForbidden
 	case core.CodeBadRequest:
 		return http.StatusBadRequest
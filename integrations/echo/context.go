@@ -124,6 +124,11 @@ func (e *EchoContext) GetURL() string {
 	return e.c.Request().URL.String()
 }
 
+// GetHost implements adapter.HostProvider.
+func (e *EchoContext) GetHost() string {
+	return e.c.Request().Host
+}
+
 // GetUserAgent implements adapter.RequestContext.
 func (e *EchoContext) GetUserAgent() string {
 	return e.c.Request().UserAgent()
@@ -117,6 +117,11 @@ func (f *FiberContext) GetURL() string {
 	return string(f.c.Request().URI().FullURI())
 }
 
+// GetHost implements adapter.HostProvider.
+func (f *FiberContext) GetHost() string {
+	return string(f.c.Request().Host())
+}
+
 // GetUserAgent implements adapter.RequestContext.
 func (f *FiberContext) GetUserAgent() string {
 	return f.c.Get("User-Agent")
@@ -0,0 +1,92 @@
+package fiber
+
+import (
+	"github.com/click33/sa-token-go/core"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ============ OAuth2 Endpoints | OAuth2端点 ============
+
+// OAuth2Handler gets the OAuth2 endpoint handlers for customization | 获取OAuth2端点处理器（用于自定义）
+func (p *Plugin) OAuth2Handler() *core.OAuth2Handler {
+	return p.oauth2Handler
+}
+
+// RegisterOAuth2Routes registers authorize, token, revoke, introspect and device authorization endpoints | 注册授权、令牌、撤销、内省和设备授权端点
+// Call OAuth2Handler().SetTrustedOrigins first to mount device verification decisions | 需先调用OAuth2Handler().SetTrustedOrigins才会挂载设备验证决定端点
+func (p *Plugin) RegisterOAuth2Routes(r fiber.Router) {
+	r.Get(core.OAuth2AuthorizePath, p.OAuth2AuthorizeHandler)
+	r.Post(core.OAuth2TokenPath, p.OAuth2TokenHandler)
+	r.Post(core.OAuth2RevokePath, p.OAuth2RevokeHandler)
+	r.Post(core.OAuth2IntrospectPath, p.OAuth2IntrospectHandler)
+	r.Post(core.OAuth2DeviceAuthorizationPath, p.OAuth2DeviceAuthorizationHandler)
+	r.Get(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	// Decisions are only mounted once trusted origins are set | 设置可信来源后才挂载决定端点
+	if p.oauth2Handler.AcceptsDeviceDecisions() {
+		r.Post(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	}
+}
+
+// OAuth2AuthorizeHandler authorization endpoint, requires a logged-in user | 授权端点，需要用户已登录
+func (p *Plugin) OAuth2AuthorizeHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.Authorize(NewFiberContext(c)))
+}
+
+// OAuth2TokenHandler token endpoint | 令牌端点
+func (p *Plugin) OAuth2TokenHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.Token(NewFiberContext(c)))
+}
+
+// OAuth2RevokeHandler token revocation endpoint (RFC 7009) | 令牌撤销端点
+func (p *Plugin) OAuth2RevokeHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.Revoke(NewFiberContext(c)))
+}
+
+// OAuth2IntrospectHandler token introspection endpoint (RFC 7662) | 令牌内省端点
+func (p *Plugin) OAuth2IntrospectHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.Introspect(NewFiberContext(c)))
+}
+
+// OAuth2DeviceAuthorizationHandler device authorization endpoint (RFC 8628) | 设备授权端点
+func (p *Plugin) OAuth2DeviceAuthorizationHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.DeviceAuthorization(NewFiberContext(c)))
+}
+
+// OAuth2DeviceVerificationHandler device user code verification, requires a logged-in user | 设备用户码验证端点，需要用户已登录
+func (p *Plugin) OAuth2DeviceVerificationHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.DeviceVerification(NewFiberContext(c)))
+}
+
+// OAuth2RegisterHandler dynamic client registration endpoint (RFC 7591), not mounted by RegisterOAuth2Routes | 动态客户端注册端点，RegisterOAuth2Routes不会自动挂载
+func (p *Plugin) OAuth2RegisterHandler(c *fiber.Ctx) error {
+	return writeOAuth2Response(c, p.oauth2Handler.Register(NewFiberContext(c)))
+}
+
+// ============ OIDC Endpoints | OIDC端点 ============
+
+// RegisterOIDCRoutes registers discovery, JWKS and userinfo endpoints of an OIDC provider | 注册OIDC提供方的发现、JWKS和用户信息端点
+func (p *Plugin) RegisterOIDCRoutes(r fiber.Router, provider *core.OIDCProvider) {
+	r.Get(core.OIDCDiscoveryPath, OIDCHandler(provider.DiscoveryEndpoint))
+	r.Get(core.OIDCJWKSPath, OIDCHandler(provider.JWKSEndpoint))
+	r.Get(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+	r.Post(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+}
+
+// OIDCHandler adapts an OIDC provider endpoint, e.g. OIDCHandler(provider.UserInfoEndpoint) | 适配OIDC提供方端点，如OIDCHandler(provider.UserInfoEndpoint)
+func OIDCHandler(endpoint func(core.RequestContext) *core.OAuth2Response) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return writeOAuth2Response(c, endpoint(NewFiberContext(c)))
+	}
+}
+
+// writeOAuth2Response writes an OAuth2 endpoint response | 写入OAuth2端点响应
+func writeOAuth2Response(c *fiber.Ctx, resp *core.OAuth2Response) error {
+	for key, value := range resp.Header {
+		c.Set(key, value)
+	}
+	if resp.Body == nil {
+		c.Status(resp.Status)
+		return nil
+	}
+	return c.Status(resp.Status).JSON(resp.Body)
+}
@@ -4,21 +4,30 @@ import (
 	"errors"
 
 	"github.com/click33/sa-token-go/core"
+	"github.com/click33/sa-token-go/stputil"
 	"github.com/gofiber/fiber/v2"
 )
 
 // Plugin Fiber plugin for Sa-Token | Fiber插件
 type Plugin struct {
-	manager *core.Manager
+	manager       *core.Manager
+	oauth2Handler *core.OAuth2Handler
 }
 
 // NewPlugin creates a Fiber plugin | 创建Fiber插件
 func NewPlugin(manager *core.Manager) *Plugin {
 	return &Plugin{
-		manager: manager,
+		manager:       manager,
+		oauth2Handler: core.NewOAuth2Handler(manager),
 	}
 }
 
+// Use returns a plugin checking another login type, e.g. plugin.Use("admin").AuthMiddleware() | 返回检查其他登录类型的插件，例如plugin.Use("admin").AuthMiddleware()
+// The login type must be registered with stputil.PutManager() | 登录类型需先通过stputil.PutManager()注册
+func (p *Plugin) Use(loginType string) *Plugin {
+	return NewPlugin(stputil.Use(loginType))
+}
+
 // AuthMiddleware authentication middleware | 认证中间件
 func (p *Plugin) AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -40,7 +49,7 @@ func (p *Plugin) PermissionRequired(permission string) fiber.Handler {
 		ctx := NewFiberContext(c)
 		saCtx := core.NewContext(ctx, p.manager)
 
-		if err := saCtx.CheckLogin(); err != nil {
+		if _, err := saCtx.GetCallerID(); err != nil {
 			return writeErrorResponse(c, err)
 		}
 
@@ -53,6 +62,60 @@ func (p *Plugin) PermissionRequired(permission string) fiber.Handler {
 	}
 }
 
+// ScopeRequired OAuth2 scope validation middleware | OAuth2权限范围验证中间件
+// Checks the Bearer access token carries all scopes | 校验Bearer访问令牌是否包含所有权限范围
+func (p *Plugin) ScopeRequired(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := NewFiberContext(c)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		token, err := saCtx.CheckOAuth2Scope(scopes...)
+		if err != nil {
+			return writeErrorResponse(c, core.NewOAuth2ScopeError(err, scopes...))
+		}
+
+		c.Locals("satoken", saCtx)
+		c.Locals("oauth2Token", token)
+		return c.Next()
+	}
+}
+
+// ResourceResolver Loads the resource a request targets | 加载请求的目标资源
+type ResourceResolver func(c *fiber.Ctx) (any, error)
+
+// ResourceParam Loads the resource by a route parameter | 根据路由参数加载资源
+func ResourceParam(name string, load func(id string) (any, error)) ResourceResolver {
+	return func(c *fiber.Ctx) (any, error) {
+		return load(c.Params(name))
+	}
+}
+
+// PolicyRequired Attribute-based policy middleware | 基于属性的策略中间件
+// Route params are available to policies as context.params | 路由参数在策略中可通过context.params访问
+func (p *Plugin) PolicyRequired(action string, resolve ResourceResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := NewFiberContext(c)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		var resource any
+		if resolve != nil {
+			loaded, err := resolve(c)
+			if err != nil {
+				return writeErrorResponse(c, core.NewResourceError(err))
+			}
+			resource = loaded
+		}
+
+		if err := saCtx.Check(action, resource, map[string]any{"params": c.AllParams()}); err != nil {
+			return writeErrorResponse(c, core.NewPolicyError(err, action))
+		}
+
+		c.Locals("satoken", saCtx)
+		c.Locals("resource", resource)
+		return c.Next()
+	}
+}
+
 // RoleRequired role validation middleware | 角色验证中间件
 func (p *Plugin) RoleRequired(role string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -89,7 +152,8 @@ func (p *Plugin) LoginHandler(c *fiber.Ctx) error {
 		device = "default"
 	}
 
-	token, err := p.manager.Login(req.Username, device)
+	mgr := p.manager.ResolveTenant(NewFiberContext(c)) // Tenant of the request | 请求所属租户
+	token, err := mgr.Login(req.Username, device)
 	if err != nil {
 		return writeErrorResponse(c, core.NewError(core.CodeServerError, "login failed", err))
 	}
@@ -109,6 +173,22 @@ func GetSaToken(c *fiber.Ctx) (*core.SaTokenContext, bool) {
 	return ctx, ok
 }
 
+// GetOAuth2Token gets OAuth2 access token set by ScopeRequired | 获取ScopeRequired设置的OAuth2访问令牌
+func GetOAuth2Token(c *fiber.Ctx) (*core.OAuth2AccessToken, bool) {
+	value := c.Locals("oauth2Token")
+	if value == nil {
+		return nil, false
+	}
+	token, ok := value.(*core.OAuth2AccessToken)
+	return token, ok
+}
+
+// GetResource gets the resource loaded by PolicyRequired | 获取PolicyRequired加载的资源
+func GetResource(c *fiber.Ctx) (any, bool) {
+	value := c.Locals("resource")
+	return value, value != nil
+}
+
 // ============ Error Handling Helpers | 错误处理辅助函数 ============
 
 // writeErrorResponse writes a standardized error response | 写入标准化的错误响应
@@ -118,6 +198,11 @@ func writeErrorResponse(c *fiber.Ctx, err error) error {
 	var message string
 	var httpStatus int
 
+	// Typed login state errors carry their own codes | 登录状态错误携带各自的错误码
+	if loginErr, ok := core.AsLoginError(err); ok {
+		err = loginErr
+	}
+
 	// Check if it's a SaTokenError | 检查是否为SaTokenError
 	if errors.As(err, &saErr) {
 		code = saErr.Code
@@ -149,9 +234,10 @@ func writeSuccessResponse(c *fiber.Ctx, data interface{}) error {
 // getHTTPStatusFromCode converts Sa-Token error code to HTTP status | 将Sa-Token错误码转换为HTTP状态码
 func getHTTPStatusFromCode(code int) int {
 	switch code {
-	case core.CodeNotLogin:
+	case core.CodeNotLogin, core.CodeTokenInvalid, core.CodeTokenExpired,
+		core.CodeKickedOut, core.CodeTokenReplaced, core.CodeActiveTimeout:
 		return fiber.StatusUnauthorized
-	case core.CodePermissionDenied:
+	case core.CodePermissionDenied, core.CodeAccountDisabled:
 		return fiber.StatusForbidden
 	case core.CodeBadRequest:
 		return fiber.StatusBadRequest
@@ -14,7 +14,10 @@ type Annotation struct {
 	CheckRole       []string `json:"checkRole"`
 	CheckPermission []string `json:"checkPermission"`
 	CheckDisable    bool     `json:"checkDisable"`
+	DisableService  []string `json:"disableService"` // Services to check, empty for the whole account | 检查的服务，为空表示整个账号
+	DisableLevel    int      `json:"disableLevel"`   // Lowest level that rejects, 0 for any ban | 拒绝请求的最低等级，0表示任何封禁
 	Ignore          bool     `json:"ignore"`
+	LoginType       string   `json:"loginType"` // Login type to check, "" for the global manager | 检查的登录类型，""表示全局Manager
 }
 
 // GetHandler gets handler with annotations | 获取带注解的处理器
@@ -30,23 +33,24 @@ func GetHandler(handler ghttp.HandlerFunc, annotations ...*Annotation) ghttp.Han
 			return
 		}
 
-		// Get token from context using configured TokenName | 从上下文获取Token（使用配置的TokenName）
-		ctx := NewGFContext(r)
-		saCtx := core.NewContext(ctx, stputil.GetManager())
-		token := saCtx.GetTokenValue()
-		if token == "" {
-			writeErrorResponse(r, core.NewNotLoginError())
+		// Resolve the annotated login type per request, it may be registered after the route | 每次请求时解析注解指定的登录类型，可以在注册路由之后注册
+		mgr, resolveErr := resolveManager(annotations)
+		if resolveErr != nil {
+			writeErrorResponse(r, resolveErr)
 			return
 		}
 
-		// Check login | 检查登录
-		if !stputil.IsLogin(token) {
-			writeErrorResponse(r, core.NewNotLoginError())
-			return
+		// Get token from context using configured TokenName | 从上下文获取Token（使用配置的TokenName）
+		ctx := NewGFContext(r)
+		saCtx := core.NewContext(ctx, mgr)
+		// Check login, OAuth2 access tokens only pass permission checks | 检查登录，OAuth2访问令牌只能通过权限校验
+		var loginID string
+		var err error
+		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
+			loginID, err = saCtx.GetCallerID()
+		} else if err = saCtx.CheckLogin(); err == nil {
+			loginID, err = saCtx.GetLoginID()
 		}
-
-		// Get login ID | 获取登录ID
-		loginID, err := stputil.GetLoginID(token)
 		if err != nil {
 			writeErrorResponse(r, err)
 			return
@@ -54,8 +58,8 @@ func GetHandler(handler ghttp.HandlerFunc, annotations ...*Annotation) ghttp.Han
 
 		// Check if account is disabled | 检查是否被封禁
 		if len(annotations) > 0 && annotations[0].CheckDisable {
-			if stputil.IsDisable(loginID) {
-				writeErrorResponse(r, core.NewAccountDisabledError(loginID))
+			if err := annotations[0].checkDisable(saCtx.GetManager(), loginID); err != nil {
+				writeErrorResponse(r, err)
 				return
 			}
 		}
@@ -64,7 +68,7 @@ func GetHandler(handler ghttp.HandlerFunc, annotations ...*Annotation) ghttp.Han
 		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
 			hasPermission := false
 			for _, perm := range annotations[0].CheckPermission {
-				if stputil.HasPermission(loginID, strings.TrimSpace(perm)) {
+				if saCtx.HasPermission(strings.TrimSpace(perm)) {
 					hasPermission = true
 					break
 				}
@@ -79,7 +83,7 @@ func GetHandler(handler ghttp.HandlerFunc, annotations ...*Annotation) ghttp.Han
 		if len(annotations) > 0 && len(annotations[0].CheckRole) > 0 {
 			hasRole := false
 			for _, role := range annotations[0].CheckRole {
-				if stputil.HasRole(loginID, strings.TrimSpace(role)) {
+				if saCtx.HasRole(strings.TrimSpace(role)) {
 					hasRole = true
 					break
 				}
@@ -99,6 +103,28 @@ func GetHandler(handler ghttp.HandlerFunc, annotations ...*Annotation) ghttp.Han
 	}
 }
 
+// resolveManager resolves the annotated login type, the global manager when none is given | 解析注解指定的登录类型，未指定时返回全局Manager
+func resolveManager(annotations []*Annotation) (*core.Manager, error) {
+	if len(annotations) == 0 || annotations[0].LoginType == "" {
+		return stputil.GetManager(), nil
+	}
+	return stputil.LookupManager(annotations[0].LoginType)
+}
+
+// checkDisable checks the annotated services, the whole account when none is given | 检查注解指定的服务，未指定时检查整个账号
+func (a *Annotation) checkDisable(mgr *core.Manager, loginID string) error {
+	services := a.DisableService
+	if len(services) == 0 {
+		services = []string{""}
+	}
+	for _, service := range services {
+		if err := mgr.CheckDisable(loginID, strings.TrimSpace(service), a.DisableLevel); err != nil {
+			return core.NewDisableError(err)
+		}
+	}
+	return nil
+}
+
 // CheckLoginMiddleware decorator for login checking | 检查登录装饰器
 func CheckLoginMiddleware() ghttp.HandlerFunc {
 	return GetHandler(nil, &Annotation{CheckLogin: true})
@@ -114,9 +140,9 @@ func CheckPermissionMiddleware(perms ...string) ghttp.HandlerFunc {
 	return GetHandler(nil, &Annotation{CheckPermission: perms})
 }
 
-// CheckDisableMiddleware decorator for checking if account is disabled | 检查是否被封禁装饰器
-func CheckDisableMiddleware() ghttp.HandlerFunc {
-	return GetHandler(nil, &Annotation{CheckDisable: true})
+// CheckDisableMiddleware decorator for checking if the account or the given services are disabled | 检查账号或指定服务是否被封禁装饰器
+func CheckDisableMiddleware(services ...string) ghttp.HandlerFunc {
+	return GetHandler(nil, &Annotation{CheckDisable: true, DisableService: services})
 }
 
 // IgnoreMiddleware decorator to ignore authentication | 忽略认证装饰器
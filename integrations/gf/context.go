@@ -106,6 +106,11 @@ func (g *GFContext) GetURL() string {
 	return g.c.Request.URL.String()
 }
 
+// GetHost implements adapter.HostProvider.
+func (g *GFContext) GetHost() string {
+	return g.c.Request.Host
+}
+
 // GetUserAgent implements adapter.RequestContext.
 func (g *GFContext) GetUserAgent() string {
 	return g.c.Header.Get("User-Agent")
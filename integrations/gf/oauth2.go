@@ -0,0 +1,91 @@
+package gf
+
+import (
+	"github.com/click33/sa-token-go/core"
+	"github.com/gogf/gf/v2/net/ghttp"
+)
+
+// ============ OAuth2 Endpoints | OAuth2端点 ============
+
+// OAuth2Handler gets the OAuth2 endpoint handlers for customization | 获取OAuth2端点处理器（用于自定义）
+func (p *Plugin) OAuth2Handler() *core.OAuth2Handler {
+	return p.oauth2Handler
+}
+
+// RegisterOAuth2Routes registers authorize, token, revoke, introspect and device authorization endpoints | 注册授权、令牌、撤销、内省和设备授权端点
+// Call OAuth2Handler().SetTrustedOrigins first to mount device verification decisions | 需先调用OAuth2Handler().SetTrustedOrigins才会挂载设备验证决定端点
+func (p *Plugin) RegisterOAuth2Routes(group *ghttp.RouterGroup) {
+	group.GET(core.OAuth2AuthorizePath, p.OAuth2AuthorizeHandler)
+	group.POST(core.OAuth2TokenPath, p.OAuth2TokenHandler)
+	group.POST(core.OAuth2RevokePath, p.OAuth2RevokeHandler)
+	group.POST(core.OAuth2IntrospectPath, p.OAuth2IntrospectHandler)
+	group.POST(core.OAuth2DeviceAuthorizationPath, p.OAuth2DeviceAuthorizationHandler)
+	group.GET(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	// Decisions are only mounted once trusted origins are set | 设置可信来源后才挂载决定端点
+	if p.oauth2Handler.AcceptsDeviceDecisions() {
+		group.POST(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	}
+}
+
+// OAuth2AuthorizeHandler authorization endpoint, requires a logged-in user | 授权端点，需要用户已登录
+func (p *Plugin) OAuth2AuthorizeHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.Authorize(NewGFContext(r)))
+}
+
+// OAuth2TokenHandler token endpoint | 令牌端点
+func (p *Plugin) OAuth2TokenHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.Token(NewGFContext(r)))
+}
+
+// OAuth2RevokeHandler token revocation endpoint (RFC 7009) | 令牌撤销端点
+func (p *Plugin) OAuth2RevokeHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.Revoke(NewGFContext(r)))
+}
+
+// OAuth2IntrospectHandler token introspection endpoint (RFC 7662) | 令牌内省端点
+func (p *Plugin) OAuth2IntrospectHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.Introspect(NewGFContext(r)))
+}
+
+// OAuth2DeviceAuthorizationHandler device authorization endpoint (RFC 8628) | 设备授权端点
+func (p *Plugin) OAuth2DeviceAuthorizationHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.DeviceAuthorization(NewGFContext(r)))
+}
+
+// OAuth2DeviceVerificationHandler device user code verification, requires a logged-in user | 设备用户码验证端点，需要用户已登录
+func (p *Plugin) OAuth2DeviceVerificationHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.DeviceVerification(NewGFContext(r)))
+}
+
+// OAuth2RegisterHandler dynamic client registration endpoint (RFC 7591), not mounted by RegisterOAuth2Routes | 动态客户端注册端点，RegisterOAuth2Routes不会自动挂载
+func (p *Plugin) OAuth2RegisterHandler(r *ghttp.Request) {
+	writeOAuth2Response(r, p.oauth2Handler.Register(NewGFContext(r)))
+}
+
+// ============ OIDC Endpoints | OIDC端点 ============
+
+// RegisterOIDCRoutes registers discovery, JWKS and userinfo endpoints of an OIDC provider | 注册OIDC提供方的发现、JWKS和用户信息端点
+func (p *Plugin) RegisterOIDCRoutes(group *ghttp.RouterGroup, provider *core.OIDCProvider) {
+	group.GET(core.OIDCDiscoveryPath, OIDCHandler(provider.DiscoveryEndpoint))
+	group.GET(core.OIDCJWKSPath, OIDCHandler(provider.JWKSEndpoint))
+	group.GET(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+	group.POST(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+}
+
+// OIDCHandler adapts an OIDC provider endpoint, e.g. OIDCHandler(provider.UserInfoEndpoint) | 适配OIDC提供方端点，如OIDCHandler(provider.UserInfoEndpoint)
+func OIDCHandler(endpoint func(core.RequestContext) *core.OAuth2Response) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		writeOAuth2Response(r, endpoint(NewGFContext(r)))
+	}
+}
+
+// writeOAuth2Response writes an OAuth2 endpoint response | 写入OAuth2端点响应
+func writeOAuth2Response(r *ghttp.Request, resp *core.OAuth2Response) {
+	for key, value := range resp.Header {
+		r.Response.Header().Set(key, value)
+	}
+	r.Response.WriteHeader(resp.Status)
+	if resp.Body != nil {
+		r.Response.WriteJson(resp.Body)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/click33/sa-token-go/core"
+	"github.com/click33/sa-token-go/stputil"
 	"github.com/gogf/gf/v2/frame/g"
 	"github.com/gogf/gf/v2/net/ghttp"
 )
@@ -19,16 +20,24 @@ var (
 
 // Plugin GoFrame plugin for Sa-Token | GoFrame插件
 type Plugin struct {
-	manager *core.Manager
+	manager       *core.Manager
+	oauth2Handler *core.OAuth2Handler
 }
 
 // NewPlugin creates an GoFrame plugin | 创建GoFrame插件
 func NewPlugin(manager *core.Manager) *Plugin {
 	return &Plugin{
-		manager: manager,
+		manager:       manager,
+		oauth2Handler: core.NewOAuth2Handler(manager),
 	}
 }
 
+// Use returns a plugin checking another login type, e.g. plugin.Use("admin").AuthMiddleware() | 返回检查其他登录类型的插件，例如plugin.Use("admin").AuthMiddleware()
+// The login type must be registered with stputil.PutManager() | 登录类型需先通过stputil.PutManager()注册
+func (p *Plugin) Use(loginType string) *Plugin {
+	return NewPlugin(stputil.Use(loginType))
+}
+
 // AuthMiddleware authentication middleware | 认证中间件
 func (p *Plugin) AuthMiddleware() ghttp.HandlerFunc {
 	return func(r *ghttp.Request) {
@@ -53,7 +62,7 @@ func (p *Plugin) PermissionRequired(permission string) ghttp.HandlerFunc {
 		ctx := NewGFContext(r)
 		saCtx := core.NewContext(ctx, p.manager)
 
-		if err := saCtx.CheckLogin(); err != nil {
+		if _, err := saCtx.GetCallerID(); err != nil {
 			writeErrorResponse(r, err)
 			return
 		}
@@ -67,6 +76,61 @@ func (p *Plugin) PermissionRequired(permission string) ghttp.HandlerFunc {
 
 }
 
+// ScopeRequired OAuth2 scope validation middleware | OAuth2权限范围验证中间件
+// Checks the Bearer access token carries all scopes | 校验Bearer访问令牌是否包含所有权限范围
+func (p *Plugin) ScopeRequired(scopes ...string) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		ctx := NewGFContext(r)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		token, err := saCtx.CheckOAuth2Scope(scopes...)
+		if err != nil {
+			writeErrorResponse(r, core.NewOAuth2ScopeError(err, scopes...))
+			return
+		}
+		r.SetCtxVar("satoken", saCtx)
+		r.SetCtxVar("oauth2Token", token)
+		r.Middleware.Next()
+	}
+}
+
+// ResourceResolver Loads the resource a request targets | 加载请求的目标资源
+type ResourceResolver func(r *ghttp.Request) (any, error)
+
+// ResourceParam Loads the resource by a route parameter | 根据路由参数加载资源
+func ResourceParam(name string, load func(id string) (any, error)) ResourceResolver {
+	return func(r *ghttp.Request) (any, error) {
+		return load(r.GetRouter(name).String())
+	}
+}
+
+// PolicyRequired Attribute-based policy middleware | 基于属性的策略中间件
+// Route params are available to policies as context.params | 路由参数在策略中可通过context.params访问
+func (p *Plugin) PolicyRequired(action string, resolve ResourceResolver) ghttp.HandlerFunc {
+	return func(r *ghttp.Request) {
+		ctx := NewGFContext(r)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		var resource any
+		if resolve != nil {
+			loaded, err := resolve(r)
+			if err != nil {
+				writeErrorResponse(r, core.NewResourceError(err))
+				return
+			}
+			resource = loaded
+		}
+
+		if err := saCtx.Check(action, resource, map[string]any{"params": r.GetRouterMap()}); err != nil {
+			writeErrorResponse(r, core.NewPolicyError(err, action))
+			return
+		}
+		r.SetCtxVar("satoken", saCtx)
+		r.SetCtxVar("resource", resource)
+		r.Middleware.Next()
+	}
+}
+
 // RoleRequired role validation middleware | 角色验证中间件
 func (p *Plugin) RoleRequired(role string) ghttp.HandlerFunc {
 	return func(r *ghttp.Request) {
@@ -120,8 +184,7 @@ func (p *Plugin) HandlerPermissionRequiredMiddleware(middlewareType MiddlewareTy
 
 		ctx := NewGFContext(r)
 		saCtx := core.NewContext(ctx, p.manager)
-		loginID, err := saCtx.GetLoginID()
-		if err != nil {
+		if _, err := saCtx.GetCallerID(); err != nil {
 			if len(permFailedFunc) > 0 && permFailedFunc[0] != nil {
 				permFailedFunc[0](r)
 				return
@@ -133,9 +196,9 @@ func (p *Plugin) HandlerPermissionRequiredMiddleware(middlewareType MiddlewareTy
 		var hasPerm bool
 		switch middlewareType {
 		case MiddlewareTypeOr:
-			hasPerm = saCtx.GetManager().HasPermissionsOr(loginID, permissions) // OR check | 任一权限满足即可
+			hasPerm = saCtx.HasPermissionsOr(permissions) // OR check | 任一权限满足即可
 		case MiddlewareTypeAnd:
-			hasPerm = saCtx.GetManager().HasPermissionsAnd(loginID, permissions) // AND check | 所有权限都需满足
+			hasPerm = saCtx.HasPermissionsAnd(permissions) // AND check | 所有权限都需满足
 		default:
 			hasPerm = false
 		}
@@ -163,8 +226,7 @@ func (p *Plugin) HandlerRoleRequiredMiddleware(middlewareType MiddlewareType, ro
 
 		ctx := NewGFContext(r)
 		saCtx := core.NewContext(ctx, p.manager)
-		loginID, err := saCtx.GetLoginID()
-		if err != nil {
+		if _, err := saCtx.GetLoginID(); err != nil {
 			if len(roleFailedFunc) > 0 && roleFailedFunc[0] != nil {
 				roleFailedFunc[0](r)
 				return
@@ -176,9 +238,9 @@ func (p *Plugin) HandlerRoleRequiredMiddleware(middlewareType MiddlewareType, ro
 		var hasRole bool
 		switch middlewareType {
 		case MiddlewareTypeOr:
-			hasRole = saCtx.GetManager().HasRolesOr(loginID, roles) // OR mode | 任一角色满足即可
+			hasRole = saCtx.HasRolesOr(roles) // OR mode | 任一角色满足即可
 		case MiddlewareTypeAnd:
-			hasRole = saCtx.GetManager().HasRolesAnd(loginID, roles) // AND mode | 所有角色都需满足
+			hasRole = saCtx.HasRolesAnd(roles) // AND mode | 所有角色都需满足
 		default:
 			hasRole = false
 		}
@@ -214,7 +276,8 @@ func (p *Plugin) LoginHandler(r *ghttp.Request) {
 		device = "default"
 	}
 
-	token, err := p.manager.Login(req.Username, device)
+	mgr := p.manager.ResolveTenant(NewGFContext(r)) // Tenant of the request | 请求所属租户
+	token, err := mgr.Login(req.Username, device)
 	if err != nil {
 		writeErrorResponse(r, core.NewError(core.CodeServerError, "login failed", err))
 		return
@@ -237,8 +300,8 @@ func (p *Plugin) UserInfoHandler(r *ghttp.Request) {
 	}
 
 	// Get user permissions and roles | 获取用户权限和角色
-	permissions, _ := p.manager.GetPermissions(loginID)
-	roles, _ := p.manager.GetRoles(loginID)
+	permissions, _ := saCtx.GetManager().GetPermissions(loginID)
+	roles, _ := saCtx.GetManager().GetRoles(loginID)
 
 	writeSuccessResponse(r, g.Map{
 		"loginId":     loginID,
@@ -257,6 +320,22 @@ func GetSaToken(r *ghttp.Request) (*core.SaTokenContext, bool) {
 	return ctx, ok
 }
 
+// GetOAuth2Token gets OAuth2 access token set by ScopeRequired | 获取ScopeRequired设置的OAuth2访问令牌
+func GetOAuth2Token(r *ghttp.Request) (*core.OAuth2AccessToken, bool) {
+	value := r.GetCtx().Value("oauth2Token")
+	if value == nil {
+		return nil, false
+	}
+	token, ok := value.(*core.OAuth2AccessToken)
+	return token, ok
+}
+
+// GetResource gets the resource loaded by PolicyRequired | 获取PolicyRequired加载的资源
+func GetResource(r *ghttp.Request) (any, bool) {
+	value := r.GetCtx().Value("resource")
+	return value, value != nil
+}
+
 // ============ Error Handling Helpers | 错误处理辅助函数 ============
 
 // writeErrorResponse writes a standardized error response | 写入标准化的错误响应
@@ -266,6 +345,11 @@ func writeErrorResponse(r *ghttp.Request, err error) {
 	var message string
 	var httpStatus int
 
+	// Typed login state errors carry their own codes | 登录状态错误携带各自的错误码
+	if loginErr, ok := core.AsLoginError(err); ok {
+		err = loginErr
+	}
+
 	// Check if it's a SaTokenError | 检查是否为SaTokenError
 	if errors.As(err, &saErr) {
 		code = saErr.Code
@@ -297,9 +381,10 @@ func writeSuccessResponse(r *ghttp.Request, data interface{}) {
 // getHTTPStatusFromCode converts Sa-Token error code to HTTP status | 将Sa-Token错误码转换为HTTP状态码
 func getHTTPStatusFromCode(code int) int {
 	switch code {
-	case core.CodeNotLogin:
+	case core.CodeNotLogin, core.CodeTokenInvalid, core.CodeTokenExpired,
+		core.CodeKickedOut, core.CodeTokenReplaced, core.CodeActiveTimeout:
 		return http.StatusUnauthorized
-	case core.CodePermissionDenied:
+	case core.CodePermissionDenied, core.CodeAccountDisabled:
 		return http.StatusForbidden
 	case core.CodeBadRequest:
 		return http.StatusBadRequest
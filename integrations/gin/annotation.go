@@ -2,6 +2,7 @@ package gin
 
 import (
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/click33/sa-token-go/core"
@@ -16,6 +17,8 @@ const (
 	TagSaCheckPermission = "sa_check_permission"
 	TagSaCheckDisable    = "sa_check_disable"
 	TagSaIgnore          = "sa_ignore"
+	TagSaLoginType       = "sa_login_type"
+	TagSaDisableLevel    = "sa_disable_level"
 )
 
 // Annotation annotation structure | 注解结构体
@@ -24,7 +27,10 @@ type Annotation struct {
 	CheckRole       []string `json:"checkRole"`
 	CheckPermission []string `json:"checkPermission"`
 	CheckDisable    bool     `json:"checkDisable"`
+	DisableService  []string `json:"disableService"` // Services to check, empty for the whole account | 检查的服务，为空表示整个账号
+	DisableLevel    int      `json:"disableLevel"`   // Lowest level that rejects, 0 for any ban | 拒绝请求的最低等级，0表示任何封禁
 	Ignore          bool     `json:"ignore"`
+	LoginType       string   `json:"loginType"` // Login type to check, "" for the global manager | 检查的登录类型，""表示全局Manager
 }
 
 // ParseTag parses struct tags | 解析结构体标签
@@ -55,8 +61,21 @@ func ParseTag(tag string) *Annotation {
 			}
 		case part == TagSaCheckDisable || part == "disable":
 			ann.CheckDisable = true
+		case strings.HasPrefix(part, TagSaCheckDisable+"=") || strings.HasPrefix(part, "disable="):
+			services := strings.TrimPrefix(part, TagSaCheckDisable+"=")
+			services = strings.TrimPrefix(services, "disable=")
+			ann.CheckDisable = true
+			if services != "" {
+				ann.DisableService = strings.Split(services, "|")
+			}
+		case strings.HasPrefix(part, TagSaDisableLevel+"=") || strings.HasPrefix(part, "level="):
+			level := strings.TrimPrefix(part, TagSaDisableLevel+"=")
+			ann.DisableLevel, _ = strconv.Atoi(strings.TrimPrefix(level, "level="))
 		case part == TagSaIgnore || part == "ignore":
 			ann.Ignore = true
+		case strings.HasPrefix(part, TagSaLoginType+"=") || strings.HasPrefix(part, "type="):
+			loginType := strings.TrimPrefix(part, TagSaLoginType+"=")
+			ann.LoginType = strings.TrimPrefix(loginType, "type=")
 		}
 	}
 
@@ -99,25 +118,25 @@ func GetHandler(handler interface{}, annotations ...*Annotation) ginfw.HandlerFu
 			return
 		}
 
-		// Get token from context using configured TokenName | 从上下文获取Token（使用配置的TokenName）
-		ctx := NewGinContext(c)
-		saCtx := core.NewContext(ctx, stputil.GetManager())
-		token := saCtx.GetTokenValue()
-		if token == "" {
-			writeErrorResponse(c, core.NewNotLoginError())
+		// Resolve the annotated login type per request, it may be registered after the route | 每次请求时解析注解指定的登录类型，可以在注册路由之后注册
+		mgr, resolveErr := resolveManager(annotations)
+		if resolveErr != nil {
+			writeErrorResponse(c, resolveErr)
 			c.Abort()
 			return
 		}
 
-		// Check login | 检查登录
-		if !stputil.IsLogin(token) {
-			writeErrorResponse(c, core.NewNotLoginError())
-			c.Abort()
-			return
+		// Get token from context using configured TokenName | 从上下文获取Token（使用配置的TokenName）
+		ctx := NewGinContext(c)
+		saCtx := core.NewContext(ctx, mgr)
+		// Check login, OAuth2 access tokens only pass permission checks | 检查登录，OAuth2访问令牌只能通过权限校验
+		var loginID string
+		var err error
+		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
+			loginID, err = saCtx.GetCallerID()
+		} else if err = saCtx.CheckLogin(); err == nil {
+			loginID, err = saCtx.GetLoginID()
 		}
-
-		// Get login ID | 获取登录ID
-		loginID, err := stputil.GetLoginID(token)
 		if err != nil {
 			writeErrorResponse(c, err)
 			c.Abort()
@@ -126,8 +145,8 @@ func GetHandler(handler interface{}, annotations ...*Annotation) ginfw.HandlerFu
 
 		// Check if account is disabled | 检查是否被封禁
 		if len(annotations) > 0 && annotations[0].CheckDisable {
-			if stputil.IsDisable(loginID) {
-				writeErrorResponse(c, core.NewAccountDisabledError(loginID))
+			if err := annotations[0].checkDisable(saCtx.GetManager(), loginID); err != nil {
+				writeErrorResponse(c, err)
 				c.Abort()
 				return
 			}
@@ -137,7 +156,7 @@ func GetHandler(handler interface{}, annotations ...*Annotation) ginfw.HandlerFu
 		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
 			hasPermission := false
 			for _, perm := range annotations[0].CheckPermission {
-				if stputil.HasPermission(loginID, strings.TrimSpace(perm)) {
+				if saCtx.HasPermission(strings.TrimSpace(perm)) {
 					hasPermission = true
 					break
 				}
@@ -153,7 +172,7 @@ func GetHandler(handler interface{}, annotations ...*Annotation) ginfw.HandlerFu
 		if len(annotations) > 0 && len(annotations[0].CheckRole) > 0 {
 			hasRole := false
 			for _, role := range annotations[0].CheckRole {
-				if stputil.HasRole(loginID, strings.TrimSpace(role)) {
+				if saCtx.HasRole(strings.TrimSpace(role)) {
 					hasRole = true
 					break
 				}
@@ -173,6 +192,28 @@ func GetHandler(handler interface{}, annotations ...*Annotation) ginfw.HandlerFu
 	}
 }
 
+// resolveManager resolves the annotated login type, the global manager when none is given | 解析注解指定的登录类型，未指定时返回全局Manager
+func resolveManager(annotations []*Annotation) (*core.Manager, error) {
+	if len(annotations) == 0 || annotations[0].LoginType == "" {
+		return stputil.GetManager(), nil
+	}
+	return stputil.LookupManager(annotations[0].LoginType)
+}
+
+// checkDisable checks the annotated services, the whole account when none is given | 检查注解指定的服务，未指定时检查整个账号
+func (a *Annotation) checkDisable(mgr *core.Manager, loginID string) error {
+	services := a.DisableService
+	if len(services) == 0 {
+		services = []string{""}
+	}
+	for _, service := range services {
+		if err := mgr.CheckDisable(loginID, strings.TrimSpace(service), a.DisableLevel); err != nil {
+			return core.NewDisableError(err)
+		}
+	}
+	return nil
+}
+
 func callHandler(handler interface{}, c *ginfw.Context) bool {
 	if handler == nil {
 		return false
@@ -224,9 +265,9 @@ func CheckPermission(perms ...string) ginfw.HandlerFunc {
 	return GetHandler(nil, &Annotation{CheckPermission: perms})
 }
 
-// CheckDisable decorator for checking if account is disabled | 检查是否被封禁装饰器
-func CheckDisable() ginfw.HandlerFunc {
-	return GetHandler(nil, &Annotation{CheckDisable: true})
+// CheckDisable decorator for checking if the account or the given services are disabled | 检查账号或指定服务是否被封禁装饰器
+func CheckDisable(services ...string) ginfw.HandlerFunc {
+	return GetHandler(nil, &Annotation{CheckDisable: true, DisableService: services})
 }
 
 // Ignore decorator to ignore authentication | 忽略认证装饰器
@@ -296,25 +337,25 @@ func Middleware(annotations ...*Annotation) ginfw.HandlerFunc {
 			return
 		}
 
-		// 获取Token（使用配置的TokenName）
-		ctx := NewGinContext(c)
-		saCtx := core.NewContext(ctx, stputil.GetManager())
-		token := saCtx.GetTokenValue()
-		if token == "" {
-			writeErrorResponse(c, core.NewNotLoginError())
+		// 每次请求时解析注解指定的登录类型
+		mgr, resolveErr := resolveManager(annotations)
+		if resolveErr != nil {
+			writeErrorResponse(c, resolveErr)
 			c.Abort()
 			return
 		}
 
-		// 检查登录
-		if !stputil.IsLogin(token) {
-			writeErrorResponse(c, core.NewNotLoginError())
-			c.Abort()
-			return
+		// 获取Token（使用配置的TokenName）
+		ctx := NewGinContext(c)
+		saCtx := core.NewContext(ctx, mgr)
+		// 检查登录（OAuth2访问令牌只能通过权限校验）
+		var loginID string
+		var err error
+		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
+			loginID, err = saCtx.GetCallerID()
+		} else if err = saCtx.CheckLogin(); err == nil {
+			loginID, err = saCtx.GetLoginID()
 		}
-
-		// 获取登录ID
-		loginID, err := stputil.GetLoginID(token)
 		if err != nil {
 			writeErrorResponse(c, err)
 			c.Abort()
@@ -323,8 +364,8 @@ func Middleware(annotations ...*Annotation) ginfw.HandlerFunc {
 
 		// 检查是否被封禁
 		if len(annotations) > 0 && annotations[0].CheckDisable {
-			if stputil.IsDisable(loginID) {
-				writeErrorResponse(c, core.NewAccountDisabledError(loginID))
+			if err := annotations[0].checkDisable(saCtx.GetManager(), loginID); err != nil {
+				writeErrorResponse(c, err)
 				c.Abort()
 				return
 			}
@@ -334,7 +375,7 @@ func Middleware(annotations ...*Annotation) ginfw.HandlerFunc {
 		if len(annotations) > 0 && len(annotations[0].CheckPermission) > 0 {
 			hasPermission := false
 			for _, perm := range annotations[0].CheckPermission {
-				if stputil.HasPermission(loginID, strings.TrimSpace(perm)) {
+				if saCtx.HasPermission(strings.TrimSpace(perm)) {
 					hasPermission = true
 					break
 				}
@@ -350,7 +391,7 @@ func Middleware(annotations ...*Annotation) ginfw.HandlerFunc {
 		if len(annotations) > 0 && len(annotations[0].CheckRole) > 0 {
 			hasRole := false
 			for _, role := range annotations[0].CheckRole {
-				if stputil.HasRole(loginID, strings.TrimSpace(role)) {
+				if saCtx.HasRole(strings.TrimSpace(role)) {
 					hasRole = true
 					break
 				}
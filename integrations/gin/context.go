@@ -97,6 +97,11 @@ func (g *GinContext) GetURL() string {
 	return g.c.Request.URL.String()
 }
 
+// GetHost implements adapter.HostProvider.
+func (g *GinContext) GetHost() string {
+	return g.c.Request.Host
+}
+
 // GetUserAgent implements adapter.RequestContext.
 func (g *GinContext) GetUserAgent() string {
 	return g.c.GetHeader("User-Agent")
@@ -0,0 +1,92 @@
+package gin
+
+import (
+	"github.com/click33/sa-token-go/core"
+	"github.com/gin-gonic/gin"
+)
+
+// ============ OAuth2 Endpoints | OAuth2端点 ============
+
+// OAuth2Handler gets the OAuth2 endpoint handlers for customization | 获取OAuth2端点处理器（用于自定义）
+func (p *Plugin) OAuth2Handler() *core.OAuth2Handler {
+	return p.oauth2Handler
+}
+
+// RegisterOAuth2Routes registers authorize, token, revoke, introspect and device authorization endpoints | 注册授权、令牌、撤销、内省和设备授权端点
+// Call OAuth2Handler().SetTrustedOrigins first to mount device verification decisions | 需先调用OAuth2Handler().SetTrustedOrigins才会挂载设备验证决定端点
+func (p *Plugin) RegisterOAuth2Routes(r gin.IRoutes) {
+	r.GET(core.OAuth2AuthorizePath, p.OAuth2AuthorizeHandler)
+	r.POST(core.OAuth2TokenPath, p.OAuth2TokenHandler)
+	r.POST(core.OAuth2RevokePath, p.OAuth2RevokeHandler)
+	r.POST(core.OAuth2IntrospectPath, p.OAuth2IntrospectHandler)
+	r.POST(core.OAuth2DeviceAuthorizationPath, p.OAuth2DeviceAuthorizationHandler)
+	r.GET(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	// Decisions are only mounted once trusted origins are set | 设置可信来源后才挂载决定端点
+	if p.oauth2Handler.AcceptsDeviceDecisions() {
+		r.POST(core.OAuth2DeviceVerificationPath, p.OAuth2DeviceVerificationHandler)
+	}
+}
+
+// OAuth2AuthorizeHandler authorization endpoint, requires a logged-in user | 授权端点，需要用户已登录
+func (p *Plugin) OAuth2AuthorizeHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.Authorize(NewGinContext(c)))
+}
+
+// OAuth2TokenHandler token endpoint | 令牌端点
+func (p *Plugin) OAuth2TokenHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.Token(NewGinContext(c)))
+}
+
+// OAuth2RevokeHandler token revocation endpoint (RFC 7009) | 令牌撤销端点
+func (p *Plugin) OAuth2RevokeHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.Revoke(NewGinContext(c)))
+}
+
+// OAuth2IntrospectHandler token introspection endpoint (RFC 7662) | 令牌内省端点
+func (p *Plugin) OAuth2IntrospectHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.Introspect(NewGinContext(c)))
+}
+
+// OAuth2DeviceAuthorizationHandler device authorization endpoint (RFC 8628) | 设备授权端点
+func (p *Plugin) OAuth2DeviceAuthorizationHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.DeviceAuthorization(NewGinContext(c)))
+}
+
+// OAuth2DeviceVerificationHandler device user code verification, requires a logged-in user | 设备用户码验证端点，需要用户已登录
+func (p *Plugin) OAuth2DeviceVerificationHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.DeviceVerification(NewGinContext(c)))
+}
+
+// OAuth2RegisterHandler dynamic client registration endpoint (RFC 7591), not mounted by RegisterOAuth2Routes | 动态客户端注册端点，RegisterOAuth2Routes不会自动挂载
+func (p *Plugin) OAuth2RegisterHandler(c *gin.Context) {
+	writeOAuth2Response(c, p.oauth2Handler.Register(NewGinContext(c)))
+}
+
+// ============ OIDC Endpoints | OIDC端点 ============
+
+// RegisterOIDCRoutes registers discovery, JWKS and userinfo endpoints of an OIDC provider | 注册OIDC提供方的发现、JWKS和用户信息端点
+func (p *Plugin) RegisterOIDCRoutes(r gin.IRoutes, provider *core.OIDCProvider) {
+	r.GET(core.OIDCDiscoveryPath, OIDCHandler(provider.DiscoveryEndpoint))
+	r.GET(core.OIDCJWKSPath, OIDCHandler(provider.JWKSEndpoint))
+	r.GET(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+	r.POST(core.OIDCUserInfoPath, OIDCHandler(provider.UserInfoEndpoint))
+}
+
+// OIDCHandler adapts an OIDC provider endpoint, e.g. OIDCHandler(provider.UserInfoEndpoint) | 适配OIDC提供方端点，如OIDCHandler(provider.UserInfoEndpoint)
+func OIDCHandler(endpoint func(core.RequestContext) *core.OAuth2Response) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeOAuth2Response(c, endpoint(NewGinContext(c)))
+	}
+}
+
+// writeOAuth2Response writes an OAuth2 endpoint response | 写入OAuth2端点响应
+func writeOAuth2Response(c *gin.Context, resp *core.OAuth2Response) {
+	for key, value := range resp.Header {
+		c.Header(key, value)
+	}
+	if resp.Body == nil {
+		c.Status(resp.Status)
+		return
+	}
+	c.JSON(resp.Status, resp.Body)
+}
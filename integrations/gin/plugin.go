@@ -5,21 +5,30 @@ import (
 	"net/http"
 
 	"github.com/click33/sa-token-go/core"
+	"github.com/click33/sa-token-go/stputil"
 	"github.com/gin-gonic/gin"
 )
 
 // Plugin Gin plugin for Sa-Token | Gin插件
 type Plugin struct {
-	manager *core.Manager
+	manager       *core.Manager
+	oauth2Handler *core.OAuth2Handler
 }
 
 // NewPlugin creates a Gin plugin | 创建Gin插件
 func NewPlugin(manager *core.Manager) *Plugin {
 	return &Plugin{
-		manager: manager,
+		manager:       manager,
+		oauth2Handler: core.NewOAuth2Handler(manager),
 	}
 }
 
+// Use returns a plugin checking another login type, e.g. plugin.Use("admin").AuthMiddleware() | 返回检查其他登录类型的插件，例如plugin.Use("admin").AuthMiddleware()
+// The login type must be registered with stputil.PutManager() | 登录类型需先通过stputil.PutManager()注册
+func (p *Plugin) Use(loginType string) *Plugin {
+	return NewPlugin(stputil.Use(loginType))
+}
+
 // AuthMiddleware authentication middleware | 认证中间件
 func (p *Plugin) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -46,7 +55,7 @@ func (p *Plugin) PermissionRequired(permission string) gin.HandlerFunc {
 		saCtx := core.NewContext(ctx, p.manager)
 
 		// Check login | 检查登录
-		if err := saCtx.CheckLogin(); err != nil {
+		if _, err := saCtx.GetCallerID(); err != nil {
 			writeErrorResponse(c, err)
 			c.Abort()
 			return
@@ -64,6 +73,70 @@ func (p *Plugin) PermissionRequired(permission string) gin.HandlerFunc {
 	}
 }
 
+// ScopeRequired OAuth2 scope validation middleware | OAuth2权限范围验证中间件
+// Checks the Bearer access token carries all scopes | 校验Bearer访问令牌是否包含所有权限范围
+func (p *Plugin) ScopeRequired(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := NewGinContext(c)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		token, err := saCtx.CheckOAuth2Scope(scopes...)
+		if err != nil {
+			writeErrorResponse(c, core.NewOAuth2ScopeError(err, scopes...))
+			c.Abort()
+			return
+		}
+
+		c.Set("satoken", saCtx)
+		c.Set("oauth2Token", token)
+		c.Next()
+	}
+}
+
+// ResourceResolver Loads the resource a request targets | 加载请求的目标资源
+type ResourceResolver func(c *gin.Context) (any, error)
+
+// ResourceParam Loads the resource by a route parameter | 根据路由参数加载资源
+func ResourceParam(name string, load func(id string) (any, error)) ResourceResolver {
+	return func(c *gin.Context) (any, error) {
+		return load(c.Param(name))
+	}
+}
+
+// PolicyRequired Attribute-based policy middleware | 基于属性的策略中间件
+// Route params are available to policies as context.params | 路由参数在策略中可通过context.params访问
+func (p *Plugin) PolicyRequired(action string, resolve ResourceResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := NewGinContext(c)
+		saCtx := core.NewContext(ctx, p.manager)
+
+		var resource any
+		if resolve != nil {
+			loaded, err := resolve(c)
+			if err != nil {
+				writeErrorResponse(c, core.NewResourceError(err))
+				c.Abort()
+				return
+			}
+			resource = loaded
+		}
+
+		params := make(map[string]string, len(c.Params))
+		for _, param := range c.Params {
+			params[param.Key] = param.Value
+		}
+		if err := saCtx.Check(action, resource, map[string]any{"params": params}); err != nil {
+			writeErrorResponse(c, core.NewPolicyError(err, action))
+			c.Abort()
+			return
+		}
+
+		c.Set("satoken", saCtx)
+		c.Set("resource", resource)
+		c.Next()
+	}
+}
+
 // RoleRequired role validation middleware | 角色验证中间件
 func (p *Plugin) RoleRequired(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -111,14 +184,15 @@ func (p *Plugin) LoginHandler(c *gin.Context) {
 		device = "default"
 	}
 
-	token, err := p.manager.Login(req.Username, device)
+	mgr := p.manager.ResolveTenant(NewGinContext(c)) // Tenant of the request | 请求所属租户
+	token, err := mgr.Login(req.Username, device)
 	if err != nil {
 		writeErrorResponse(c, core.NewError(core.CodeServerError, "login failed", err))
 		return
 	}
 
 	// Set cookie (optional) | 设置Cookie（可选）
-	cfg := p.manager.GetConfig()
+	cfg := mgr.GetConfig()
 	if cfg.IsReadCookie {
 		maxAge := int(cfg.Timeout)
 		if maxAge < 0 {
@@ -151,7 +225,7 @@ func (p *Plugin) LogoutHandler(c *gin.Context) {
 		return
 	}
 
-	if err := p.manager.Logout(loginID); err != nil {
+	if err := saCtx.GetManager().Logout(loginID); err != nil {
 		writeErrorResponse(c, core.NewError(core.CodeServerError, "logout failed", err))
 		return
 	}
@@ -173,8 +247,8 @@ func (p *Plugin) UserInfoHandler(c *gin.Context) {
 	}
 
 	// Get user permissions and roles | 获取用户权限和角色
-	permissions, _ := p.manager.GetPermissions(loginID)
-	roles, _ := p.manager.GetRoles(loginID)
+	permissions, _ := saCtx.GetManager().GetPermissions(loginID)
+	roles, _ := saCtx.GetManager().GetRoles(loginID)
 
 	writeSuccessResponse(c, gin.H{
 		"loginId":     loginID,
@@ -193,6 +267,21 @@ func GetSaToken(c *gin.Context) (*core.SaTokenContext, bool) {
 	return ctx, ok
 }
 
+// GetOAuth2Token gets OAuth2 access token set by ScopeRequired | 获取ScopeRequired设置的OAuth2访问令牌
+func GetOAuth2Token(c *gin.Context) (*core.OAuth2AccessToken, bool) {
+	value, exists := c.Get("oauth2Token")
+	if !exists {
+		return nil, false
+	}
+	token, ok := value.(*core.OAuth2AccessToken)
+	return token, ok
+}
+
+// GetResource gets the resource loaded by PolicyRequired | 获取PolicyRequired加载的资源
+func GetResource(c *gin.Context) (any, bool) {
+	return c.Get("resource")
+}
+
 // ============ Error Handling Helpers | 错误处理辅助函数 ============
 
 // writeErrorResponse writes a standardized error response | 写入标准化的错误响应
@@ -202,6 +291,11 @@ func writeErrorResponse(c *gin.Context, err error) {
 	var message string
 	var httpStatus int
 
+	// Typed login state errors carry their own codes | 登录状态错误携带各自的错误码
+	if loginErr, ok := core.AsLoginError(err); ok {
+		err = loginErr
+	}
+
 	// Check if it's a SaTokenError | 检查是否为SaTokenError
 	if errors.As(err, &saErr) {
 		code = saErr.Code
@@ -233,9 +327,10 @@ func writeSuccessResponse(c *gin.Context, data interface{}) {
 // getHTTPStatusFromCode converts Sa-Token error code to HTTP status | 将Sa-Token错误码转换为HTTP状态码
 func getHTTPStatusFromCode(code int) int {
 	switch code {
-	case core.CodeNotLogin:
+	case core.CodeNotLogin, core.CodeTokenInvalid, core.CodeTokenExpired,
+		core.CodeKickedOut, core.CodeTokenReplaced, core.CodeActiveTimeout:
 		return http.StatusUnauthorized
-	case core.CodePermissionDenied:
+	case core.CodePermissionDenied, core.CodeAccountDisabled:
 		return http.StatusForbidden
 	case core.CodeBadRequest:
 		return http.StatusBadRequest
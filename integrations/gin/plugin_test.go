@@ -0,0 +1,929 @@
+package gin
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/click33/sa-token-go/core"
+	"github.com/click33/sa-token-go/core/config"
+	"github.com/click33/sa-token-go/core/manager"
+	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/click33/sa-token-go/storage/memory"
+	"github.com/click33/sa-token-go/stputil"
+	ginfw "github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScopeRequired 测试OAuth2权限范围中间件
+func TestScopeRequired(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	server := mgr.GetOAuth2Server()
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "app",
+		ClientSecret: "secret",
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+		Scopes:       []string{"user:*", "order:read"},
+	})
+	token, err := server.ClientCredentialsToken("app", "secret", []string{"user:*"})
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	router.GET("/users", plugin.ScopeRequired("user:read"), func(c *ginfw.Context) {
+		oauthToken, ok := GetOAuth2Token(c)
+		assert.True(t, ok)
+		c.String(http.StatusOK, oauthToken.ClientID)
+	})
+	router.GET("/orders", plugin.ScopeRequired("order:read"), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		status int
+	}{
+		{"granted scope", "/users", "Bearer " + token.Token, http.StatusOK},
+		{"missing scope", "/orders", "Bearer " + token.Token, http.StatusForbidden},
+		{"unknown token", "/users", "Bearer unknown", http.StatusUnauthorized},
+		{"no token", "/users", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+// TestOAuth2Endpoints 测试OAuth2授权、令牌、内省和撤销端点
+func TestOAuth2Endpoints(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		Scopes:       []string{"read", "write"},
+	})
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{ClientID: "spa", RedirectURIs: []string{"https://spa.example.com/cb"}, Public: true})
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{ClientID: "other", ClientSecret: "other-secret"})
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{ClientID: "rs", ClientSecret: "rs-secret", Introspector: true})
+	loginToken, err := mgr.Login("user1")
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	plugin.RegisterOAuth2Routes(router)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	postForm := func(path string, form url.Values, basic bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basic {
+			req.SetBasicAuth("app", "s3cret")
+		}
+		return serve(req)
+	}
+
+	// Anonymous users cannot authorize | 未登录用户无法授权
+	authorizeURL := "/oauth2/authorize?response_type=code&client_id=app&scope=read&state=xyz"
+	w := serve(httptest.NewRequest(http.MethodGet, authorizeURL, nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Unknown client never redirects | 未知客户端不会重定向
+	w = serve(httptest.NewRequest(http.MethodGet, "/oauth2/authorize?response_type=code&client_id=evil", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Without consent or a consent page the request is refused | 没有授权同意也没有同意页时拒绝请求
+	req := httptest.NewRequest(http.MethodGet, authorizeURL, nil)
+	req.Header.Set("satoken", loginToken)
+	w = serve(req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, oauth2.ErrorConsentRequired, location.Query().Get("error"))
+	assert.Empty(t, location.Query().Get("code"))
+
+	_, err = mgr.GetOAuth2Server().GrantConsent("user1", "app", []string{"read"})
+	assert.NoError(t, err)
+	req = httptest.NewRequest(http.MethodGet, authorizeURL, nil)
+	req.Header.Set("satoken", loginToken)
+	w = serve(req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err = url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+	code := location.Query().Get("code")
+	assert.NotEmpty(t, code)
+
+	// Token request with HTTP Basic client authentication | 使用HTTP Basic客户端认证请求令牌
+	w = postForm("/oauth2/token", url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {"https://app.example.com/cb"},
+	}, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	var tokenResp oauth2.TokenResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokenResp))
+	assert.Equal(t, "read", tokenResp.Scope)
+	assert.NotEmpty(t, tokenResp.RefreshToken)
+
+	// Code reuse is an invalid_grant | 授权码重复使用返回invalid_grant
+	w = postForm("/oauth2/token", url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {"https://app.example.com/cb"},
+	}, true)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_grant"`)
+
+	// Wrong secret in the form body | 表单中的错误密钥
+	w = postForm("/oauth2/token", url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokenResp.RefreshToken},
+		"client_id":     {"app"},
+		"client_secret": {"wrong"},
+	}, false)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"invalid_client"`)
+
+	w = postForm("/oauth2/token", url.Values{"grant_type": {"implicit"}}, true)
+	assert.Contains(t, w.Body.String(), `"error":"unsupported_grant_type"`)
+
+	// Introspection | 令牌内省
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.AccessToken}}, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var introspect oauth2.IntrospectionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &introspect))
+	assert.True(t, introspect.Active)
+	assert.Equal(t, "user1", introspect.Sub)
+	assert.Equal(t, "app", introspect.ClientID)
+	assert.Greater(t, introspect.Exp, introspect.Iat)
+
+	// Refresh token expiry comes from storage | 刷新令牌的过期时间来自存储
+	assert.NoError(t, mgr.GetStorage().Expire("satoken:"+oauth2.RefreshKeySuffix+tokenResp.RefreshToken, time.Hour))
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.RefreshToken}, "token_type_hint": {"refresh_token"}}, true)
+	var refreshIntrospect oauth2.IntrospectionResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshIntrospect))
+	assert.True(t, refreshIntrospect.Active)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), refreshIntrospect.Exp, 5)
+
+	// Public clients cannot introspect, other clients only see their own tokens | 公开客户端不能内省，其他客户端只能看到自己的令牌
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.AccessToken}, "client_id": {"spa"}}, false)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.AccessToken}, "client_id": {"other"}, "client_secret": {"other-secret"}}, false)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.AccessToken}, "client_id": {"rs"}, "client_secret": {"rs-secret"}}, false)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &introspect))
+	assert.True(t, introspect.Active)
+
+	// Revoking the refresh token also revokes its access token | 撤销刷新令牌时同时撤销其访问令牌
+	w = postForm("/oauth2/revoke", url.Values{
+		"token":           {tokenResp.RefreshToken},
+		"token_type_hint": {"refresh_token"},
+	}, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = postForm("/oauth2/introspect", url.Values{"token": {tokenResp.AccessToken}}, true)
+	assert.JSONEq(t, `{"active":false}`, w.Body.String())
+
+	// Unknown tokens still answer 200 | 未知令牌同样返回200
+	w = postForm("/oauth2/revoke", url.Values{"token": {"unknown"}}, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Dynamic client registration behind an initial access token | 需要初始访问令牌的动态客户端注册
+	router.POST("/oauth2/register", plugin.OAuth2RegisterHandler)
+	register := func(bearer string) *httptest.ResponseRecorder {
+		body := `{"redirect_uris":["https://other.example.com/cb"],"client_name":"Other"}`
+		req := httptest.NewRequest(http.MethodPost, "/oauth2/register", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		return serve(req)
+	}
+	assert.Equal(t, http.StatusForbidden, register("").Code) // Closed by default | 默认关闭
+	plugin.OAuth2Handler().SetRegistrationToken("initial-token")
+	assert.Equal(t, http.StatusUnauthorized, register("").Code)
+	w = register("initial-token")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var registered oauth2.ClientRegistrationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &registered))
+	assert.NotEmpty(t, registered.ClientSecret)
+	_, err = mgr.GetOAuth2Server().GetClient(registered.ClientID)
+	assert.NoError(t, err)
+}
+
+// TestOIDCEndpoints 测试OIDC ID令牌、用户信息和发现端点
+func TestOIDCEndpoints(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{
+		ClientID:     "rp",
+		ClientSecret: "rp-secret",
+		RedirectURIs: []string{"https://rp.example.com/cb"},
+		Scopes:       []string{"openid", "email"},
+	})
+	loginToken, err := mgr.Login("user1")
+	assert.NoError(t, err)
+
+	provider, err := NewOIDCProvider(mgr, "https://sso.example.com", nil)
+	assert.NoError(t, err)
+	provider.SetClaimsProvider(func(userID string) (map[string]any, error) {
+		return map[string]any{"email": userID + "@example.com", "name": "User One"}, nil
+	})
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	plugin.OAuth2Handler().SetAutoApprove(true)
+	plugin.RegisterOAuth2Routes(router)
+	plugin.RegisterOIDCRoutes(router, provider)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/authorize?response_type=code&client_id=rp&scope=openid+email&nonce=abc123", nil)
+	req.Header.Set("satoken", loginToken)
+	w := serve(req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	assert.NoError(t, err)
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {location.Query().Get("code")},
+		"redirect_uri": {"https://rp.example.com/cb"},
+	}
+	req = httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("rp", "rp-secret")
+	w = serve(req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokenResp oauth2.TokenResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokenResp))
+	assert.NotEmpty(t, tokenResp.IDToken)
+
+	// auth_time is the login time of the session | auth_time为会话的登录时间
+	claims, err := provider.VerifyIDToken(tokenResp.IDToken, "rp")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", claims.Nonce)
+	sess, _ := mgr.GetSession("user1")
+	assert.Equal(t, sess.GetInt64(manager.SessionKeyLoginTime), claims.AuthTime)
+
+	req = httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
+	w = serve(req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"sub":"user1","email":"user1@example.com"}`, w.Body.String())
+
+	w = serve(httptest.NewRequest(http.MethodGet, "/oauth2/userinfo", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	w = serve(httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var discovery map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &discovery))
+	assert.Equal(t, "https://sso.example.com", discovery["issuer"])
+	assert.Equal(t, "https://sso.example.com/oauth2/jwks", discovery["jwks_uri"])
+
+	w = serve(httptest.NewRequest(http.MethodGet, "/oauth2/jwks", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"kty":"RSA"`)
+}
+
+// TestOAuth2Consent 测试授权端点的同意页跳转
+func TestOAuth2Consent(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	server := mgr.GetOAuth2Server()
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		Scopes:       []string{"read", "write"},
+	})
+	loginToken, err := mgr.Login("user1")
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	plugin.OAuth2Handler().SetConsentURL("/consent")
+	router := ginfw.New()
+	plugin.RegisterOAuth2Routes(router)
+
+	authorize := func(scope string) *url.URL {
+		req := httptest.NewRequest(http.MethodGet, "/oauth2/authorize?response_type=code&client_id=app&scope="+scope, nil)
+		req.Header.Set("satoken", loginToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.Header().Get("Location"))
+		assert.NoError(t, err)
+		return location
+	}
+
+	// First visit goes to the consent page | 首次访问跳转到同意页
+	location := authorize("read")
+	assert.Equal(t, "/consent", location.Path)
+	assert.Equal(t, "app", location.Query().Get("client_id"))
+	assert.Equal(t, "read", location.Query().Get("scope"))
+	assert.Contains(t, location.Query().Get("redirect"), "/oauth2/authorize")
+
+	// Invalid scopes are rejected before the consent page | 无效权限范围在同意页之前被拒绝
+	location = authorize("admin")
+	assert.Equal(t, "invalid_scope", location.Query().Get("error"))
+
+	// Approved scopes skip the consent page | 已批准的权限范围跳过同意页
+	_, err = server.GrantConsent("user1", "app", []string{"read"})
+	assert.NoError(t, err)
+	location = authorize("read")
+	assert.Equal(t, "app.example.com", location.Host)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	// Wider scopes ask again | 扩大权限范围时再次询问
+	location = authorize("read+write")
+	assert.Equal(t, "/consent", location.Path)
+}
+
+// TestOAuth2PermissionBridge 测试OAuth2访问令牌用于权限中间件和注解
+func TestOAuth2PermissionBridge(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig().SetAcceptOAuth2Token(true))
+	stputil.SetManager(mgr)
+	server := mgr.GetOAuth2Server()
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeAuthorizationCode, oauth2.GrantTypeClientCredentials},
+		Scopes:       []string{"order:read", "order:write"},
+	})
+	code, err := server.GenerateAuthorizationCode("app", "https://app.example.com/cb", "user1", []string{"order:read"})
+	assert.NoError(t, err)
+	userToken, err := server.ExchangeCodeForToken(code.Code, "app", "s3cret", "https://app.example.com/cb")
+	assert.NoError(t, err)
+	clientToken, err := server.ClientCredentialsToken("app", "s3cret", []string{"order:read"})
+	assert.NoError(t, err)
+
+	// First-party login with its own permissions | 第一方登录及其自身权限
+	loginToken, err := mgr.Login("user2")
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.SetPermissions("user2", []string{"order:*"}))
+	assert.NoError(t, mgr.SetRoles("user2", []string{"admin"}))
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	router.GET("/orders", plugin.PermissionRequired("order:read"), func(c *ginfw.Context) {
+		loginID, err := NewContext(NewGinContext(c), mgr).GetCallerID()
+		assert.NoError(t, err)
+		c.String(http.StatusOK, loginID)
+	})
+	router.GET("/profile", plugin.AuthMiddleware(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "profile")
+	})
+	router.GET("/me", GetHandler(func(c *ginfw.Context) {
+		c.String(http.StatusOK, "me")
+	}, &Annotation{CheckLogin: true}))
+	plugin.RegisterOAuth2Routes(router)
+	router.POST("/orders", GetHandler(func(c *ginfw.Context) {
+		c.String(http.StatusOK, "created")
+	}, &Annotation{CheckPermission: []string{"order:write"}}))
+	router.GET("/admin", plugin.RoleRequired("admin"), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "admin")
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		value  string
+		status int
+		body   string
+	}{
+		{"oauth2 granted scope", http.MethodGet, "/orders", "Authorization", "Bearer " + userToken.Token, http.StatusOK, "user1"},
+		{"oauth2 missing scope", http.MethodPost, "/orders", "Authorization", "Bearer " + userToken.Token, http.StatusForbidden, ""},
+		{"oauth2 no delegated roles", http.MethodGet, "/admin", "Authorization", "Bearer " + userToken.Token, http.StatusUnauthorized, ""},
+		{"oauth2 not a login", http.MethodGet, "/profile", "Authorization", "Bearer " + userToken.Token, http.StatusUnauthorized, ""},
+		{"oauth2 not a login annotation", http.MethodGet, "/me", "Authorization", "Bearer " + userToken.Token, http.StatusUnauthorized, ""},
+		{"oauth2 not a resource owner", http.MethodGet, "/oauth2/authorize?response_type=code&client_id=app&redirect_uri=https://app.example.com/cb&scope=order:write", "Authorization", "Bearer " + userToken.Token, http.StatusUnauthorized, ""},
+		{"client credentials rejected", http.MethodPost, "/orders", "Authorization", "Bearer " + clientToken.Token, http.StatusUnauthorized, ""},
+		{"first-party login", http.MethodGet, "/orders", "satoken", loginToken, http.StatusOK, "user2"},
+		{"first-party annotation", http.MethodPost, "/orders", "satoken", loginToken, http.StatusOK, "created"},
+		{"first-party role", http.MethodGet, "/admin", "satoken", loginToken, http.StatusOK, "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(tt.header, tt.value)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, w.Body.String())
+			}
+		})
+	}
+
+	// Bridging is off by default | 默认不接受OAuth2访问令牌
+	mgr.GetConfig().SetAcceptOAuth2Token(false)
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken.Token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestLoginStateCodes 测试被踢下线、被顶下线等登录状态返回不同的错误码
+func TestLoginStateCodes(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig().SetIsConcurrent(false))
+	stputil.SetManager(mgr)
+
+	replaced, err := mgr.Login("1000", "web")
+	assert.NoError(t, err)
+	kicked, err := mgr.Login("1000", "web")
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.Kickout("1000", "web"))
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	router.GET("/profile", plugin.AuthMiddleware(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/annotated", CheckLogin(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"no token", "", core.CodeNotLogin},
+		{"invalid token", "forged", core.CodeTokenInvalid},
+		{"kicked out", kicked, core.CodeKickedOut},
+		{"replaced", replaced, core.CodeTokenReplaced},
+	}
+	for _, path := range []string{"/profile", "/annotated"} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.token != "" {
+					req.Header.Set("satoken", tt.token)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+				var body map[string]any
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, float64(tt.code), body["code"])
+			})
+		}
+	}
+}
+
+// TestOAuth2DeviceFlow 测试设备授权、用户码验证和设备轮询端点
+func TestOAuth2DeviceFlow(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	server := mgr.GetOAuth2Server()
+	server.RegisterClient(&oauth2.Client{
+		ClientID:   "cli",
+		GrantTypes: []oauth2.GrantType{oauth2.GrantTypeDeviceCode},
+		Scopes:     []string{"read"},
+		Public:     true,
+	})
+	server.SetDevicePollInterval(0)
+	loginToken, err := mgr.Login("user1")
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	plugin.OAuth2Handler().SetVerificationURI("https://auth.example.com/device")
+	plugin.OAuth2Handler().SetTrustedOrigins("https://auth.example.com/")
+	router := ginfw.New()
+	plugin.RegisterOAuth2Routes(router)
+
+	origin := "https://auth.example.com"
+	serve := func(method, path string, form url.Values, login bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Origin", origin)
+		if login {
+			req.Header.Set("satoken", loginToken)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	poll := func(deviceCode string) *httptest.ResponseRecorder {
+		return serve(http.MethodPost, "/oauth2/token", url.Values{
+			"grant_type":  {string(oauth2.GrantTypeDeviceCode)},
+			"device_code": {deviceCode},
+			"client_id":   {"cli"},
+		}, false)
+	}
+
+	// Device asks for a code pair | 设备请求代码对
+	w := serve(http.MethodPost, "/oauth2/device_authorization", url.Values{"client_id": {"cli"}, "scope": {"read"}}, false)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var device oauth2.DeviceAuthorizationResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &device))
+	assert.Equal(t, "https://auth.example.com/device", device.VerificationURI)
+	assert.Equal(t, "https://auth.example.com/device?user_code="+device.UserCode, device.VerificationURIComplete)
+	assert.Len(t, device.UserCode, 9)
+
+	w = poll(device.DeviceCode)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error":"authorization_pending"`)
+
+	// Verification requires a logged-in user | 验证需要用户已登录
+	w = serve(http.MethodGet, "/oauth2/device?user_code="+device.UserCode, nil, false)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = serve(http.MethodGet, "/oauth2/device?user_code="+device.UserCode, nil, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	// Decisions need an explicit action from a trusted origin | 决定需要来自可信来源的显式action
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}}, true)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	origin = "https://evil.example.com"
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}, "action": {"approve"}}, true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	origin = ""
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}, "action": {"approve"}}, true)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	origin = "https://auth.example.com"
+
+	w = serve(http.MethodGet, "/oauth2/device?user_code="+device.UserCode, nil, true)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}, "action": {"approve"}}, true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"approved"`)
+
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}, "action": {"approve"}}, true)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Approved device gets tokens for the logged-in user | 已批准的设备获得登录用户的令牌
+	w = poll(device.DeviceCode)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokenResp oauth2.TokenResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokenResp))
+	token, err := server.ValidateAccessToken(tokenResp.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", token.UserID)
+
+	// Denied codes answer access_denied | 被拒绝的代码返回access_denied
+	w = serve(http.MethodPost, "/oauth2/device_authorization", url.Values{"client_id": {"cli"}}, false)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &device))
+	w = serve(http.MethodPost, "/oauth2/device", url.Values{"user_code": {device.UserCode}, "action": {"deny"}}, true)
+	assert.Contains(t, w.Body.String(), `"status":"denied"`)
+	w = poll(device.DeviceCode)
+	assert.Contains(t, w.Body.String(), `"error":"access_denied"`)
+
+	// Without trusted origins only the review page is mounted | 未设置可信来源时只挂载查看页面
+	untrusted := ginfw.New()
+	NewPlugin(mgr).RegisterOAuth2Routes(untrusted)
+	req := httptest.NewRequest(http.MethodPost, "/oauth2/device", strings.NewReader(url.Values{"user_code": {device.UserCode}, "action": {"approve"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("satoken", loginToken)
+	w = httptest.NewRecorder()
+	untrusted.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestPolicyRequired 测试基于属性的策略中间件
+func TestPolicyRequired(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	assert.NoError(t, mgr.RegisterPolicyExpr("doc:edit",
+		`subject.id == resource.owner && context.params.id != "" && context.method == "PUT"`))
+
+	docs := map[string]map[string]any{"1": {"owner": "1000"}}
+	load := func(id string) (any, error) {
+		doc, ok := docs[id]
+		if !ok {
+			return nil, errors.New("no such document")
+		}
+		return doc, nil
+	}
+
+	owner, err := mgr.Login("1000")
+	assert.NoError(t, err)
+	other, err := mgr.Login("2000")
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	router.PUT("/docs/:id", plugin.PolicyRequired("doc:edit", ResourceParam("id", load)), func(c *ginfw.Context) {
+		resource, ok := GetResource(c)
+		assert.True(t, ok)
+		c.JSON(http.StatusOK, resource)
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		token  string
+		status int
+	}{
+		{"owner", "/docs/1", owner, http.StatusOK},
+		{"not owner", "/docs/1", other, http.StatusForbidden},
+		{"missing resource", "/docs/2", owner, http.StatusNotFound},
+		{"not logged in", "/docs/1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("satoken", tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+// TestTenantResolution 测试按请求解析租户
+func TestTenantResolution(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	acme, err := mgr.Tenant("acme").Login("1000")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resolver core.TenantResolver
+		host     string
+		header   string
+		status   int
+	}{
+		{"header tenant", HeaderTenantResolver("X-Tenant-ID"), "example.com", "acme", http.StatusOK},
+		{"other header tenant", HeaderTenantResolver("X-Tenant-ID"), "example.com", "globex", http.StatusUnauthorized},
+		{"no tenant", HeaderTenantResolver("X-Tenant-ID"), "example.com", "", http.StatusUnauthorized},
+		{"subdomain tenant", SubdomainTenantResolver("example.com"), "acme.example.com:8080", "", http.StatusOK},
+		{"other subdomain", SubdomainTenantResolver("example.com"), "globex.example.com", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mgr.SetTenantResolver(tt.resolver)
+			router := ginfw.New()
+			router.GET("/me", NewPlugin(mgr).AuthMiddleware(), func(c *ginfw.Context) {
+				saCtx, _ := GetSaToken(c)
+				c.String(http.StatusOK, saCtx.GetTenantID())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Host = tt.host
+			req.Header.Set("satoken", acme)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "acme", w.Body.String())
+			}
+		})
+	}
+
+	// Tenants rejected by the validator resolve to the root | 被校验器拒绝的租户解析到根管理器
+	mgr.SetTenantResolver(HeaderTenantResolver("X-Tenant-ID"))
+	mgr.SetTenantValidator(func(tenantID string) bool { return tenantID == "acme" })
+	root, err := mgr.Login("1000")
+	assert.NoError(t, err)
+	router := ginfw.New()
+	router.GET("/me", NewPlugin(mgr).AuthMiddleware(), func(c *ginfw.Context) {
+		saCtx, _ := GetSaToken(c)
+		c.String(http.StatusOK, saCtx.GetTenantID())
+	})
+	for _, tc := range []struct{ token, tenant, want string }{{acme, "acme", "acme"}, {root, "globex", ""}} {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("satoken", tc.token)
+		req.Header.Set("X-Tenant-ID", tc.tenant)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tc.want, w.Body.String())
+	}
+}
+
+// TestTenantOAuth2 测试OAuth2端点与令牌桥接使用请求所属租户的服务器
+func TestTenantOAuth2(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig().SetAcceptOAuth2Token(true))
+	mgr.SetTenantResolver(HeaderTenantResolver("X-Tenant-ID"))
+	mgr.GetOAuth2Server().RegisterClient(&oauth2.Client{
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeAuthorizationCode},
+		Scopes:       []string{"order:read"},
+	})
+	// Clients are shared, codes live in the tenant | 客户端共享，授权码位于租户内
+	acme := mgr.Tenant("acme").GetOAuth2Server()
+	code, err := acme.GenerateAuthorizationCode("app", "https://app.example.com/cb", "user1", []string{"order:read"})
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(mgr)
+	router := ginfw.New()
+	plugin.RegisterOAuth2Routes(router)
+	router.GET("/orders", plugin.PermissionRequired("order:read"), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "orders")
+	})
+
+	exchange := func(tenant string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"grant_type":    {"authorization_code"},
+			"code":          {code.Code},
+			"client_id":     {"app"},
+			"client_secret": {"s3cret"},
+			"redirect_uri":  {"https://app.example.com/cb"},
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oauth2/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Tenant-ID", tenant)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusBadRequest, exchange("").Code)
+	w := exchange("acme")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &token))
+	assert.NotEmpty(t, token.AccessToken)
+
+	tests := []struct {
+		name   string
+		tenant string
+		status int
+	}{
+		{"issuing tenant", "acme", http.StatusOK},
+		{"root", "", http.StatusUnauthorized},
+		{"other tenant", "globex", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+			req.Header.Set("X-Tenant-ID", tt.tenant)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+// TestLoginTypeMiddleware 测试按登录类型检查的中间件和注解
+func TestLoginTypeMiddleware(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	storage := memory.NewStorage()
+	userMgr := manager.NewManager(storage, config.DefaultConfig())
+	adminMgr := manager.NewManager(storage, config.DefaultConfig().SetLoginType("admin").SetTokenName("admin-token"))
+	stputil.SetManager(userMgr)
+	stputil.PutManager(adminMgr)
+	assert.Same(t, adminMgr, stputil.Use("admin"))
+
+	userToken, err := userMgr.Login("1")
+	assert.NoError(t, err)
+	adminToken, err := adminMgr.Login("1")
+	assert.NoError(t, err)
+
+	plugin := NewPlugin(userMgr)
+	router := ginfw.New()
+	router.GET("/admin", plugin.Use("admin").AuthMiddleware(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/admin-annotated", WithAnnotation(ParseTag("login,type=admin")), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/user", plugin.AuthMiddleware(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	// Unknown login types are rejected per request instead of panicking | 未注册的登录类型在请求时被拒绝而不是panic
+	assert.NotPanics(t, func() {
+		router.GET("/unknown-annotated", WithAnnotation(ParseTag("login,type=ghost")), func(c *ginfw.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+		router.GET("/unknown-middleware", Middleware(&Annotation{CheckLogin: true, LoginType: "ghost"}), func(c *ginfw.Context) {
+			c.String(http.StatusOK, "ok")
+		})
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		token  string
+		status int
+	}{
+		{"admin token", "/admin", "admin-token", adminToken, http.StatusOK},
+		{"user token on admin route", "/admin", "admin-token", userToken, http.StatusUnauthorized},
+		{"admin annotation", "/admin-annotated", "admin-token", adminToken, http.StatusOK},
+		{"user header on admin annotation", "/admin-annotated", "satoken", adminToken, http.StatusUnauthorized},
+		{"user token", "/user", "satoken", userToken, http.StatusOK},
+		{"admin token on user route", "/user", "satoken", adminToken, http.StatusUnauthorized},
+		{"unknown annotation type", "/unknown-annotated", "satoken", userToken, http.StatusInternalServerError},
+		{"unknown middleware type", "/unknown-middleware", "satoken", userToken, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set(tt.header, tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+
+	// Login types registered after the route are resolved on the next request | 在路由之后注册的登录类型在下次请求时解析
+	ghostMgr := manager.NewManager(storage, config.DefaultConfig().SetLoginType("ghost").SetTokenName("ghost-token"))
+	stputil.PutManager(ghostMgr)
+	ghostToken, err := ghostMgr.Login("1")
+	assert.NoError(t, err)
+	req := httptest.NewRequest(http.MethodGet, "/unknown-annotated", nil)
+	req.Header.Set("ghost-token", ghostToken)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCheckDisableService(t *testing.T) {
+	ginfw.SetMode(ginfw.TestMode)
+	mgr := manager.NewManager(memory.NewStorage(), config.DefaultConfig())
+	stputil.SetManager(mgr)
+
+	ann := ParseTag("sa_check_disable=comment|payment,sa_disable_level=2")
+	assert.True(t, ann.CheckDisable)
+	assert.Equal(t, []string{"comment", "payment"}, ann.DisableService)
+	assert.Equal(t, 2, ann.DisableLevel)
+
+	token, err := mgr.Login("1")
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.DisableWithInfo(&manager.DisableInfo{LoginID: "1", Service: "comment", Reason: "spam"}, time.Hour))
+
+	router := ginfw.New()
+	router.GET("/comment", CheckDisable("comment"), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/comment-level", WithAnnotation(ann), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/upload", CheckDisable("upload"), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	router.GET("/profile", CheckDisable(), func(c *ginfw.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"banned service", "/comment", http.StatusForbidden},
+		{"ban below annotated level", "/comment-level", http.StatusOK},
+		{"other service", "/upload", http.StatusOK},
+		{"whole account", "/profile", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("satoken", token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
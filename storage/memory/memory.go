@@ -71,6 +71,30 @@ func (s *Storage) Set(key string, value any, expiration time.Duration) error {
 	return nil
 }
 
+// SetNX 仅当键不存在（或已过期）时设置键值对，返回是否设置成功
+func (s *Storage) SetNX(key string, value any, expiration time.Duration) (bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, exists := s.data[key]; exists && !existing.isExpired(now.Unix()) {
+		return false, nil
+	}
+
+	var exp int64
+	if expiration > 0 {
+		exp = now.Add(expiration).Unix()
+	}
+
+	s.data[key] = &item{
+		value:      value,
+		expiration: exp,
+	}
+
+	return true, nil
+}
+
 // Get 获取值
 func (s *Storage) Get(key string) (any, error) {
 	now := time.Now().Unix()
@@ -269,6 +293,11 @@ func matchPattern(key, pattern string) bool {
 	// 移除前缀 **/（支持 Redis 风格）
 	pattern = strings.TrimPrefix(pattern, "**/")
 
+	// 含转义符：按 Redis 规则将 \x 视为字面字符
+	if strings.Contains(pattern, `\`) {
+		return matchParts(key, splitPattern(pattern))
+	}
+
 	// 没有通配符，精确匹配
 	if !strings.Contains(pattern, "*") {
 		return key == pattern
@@ -303,9 +332,32 @@ func simpleWildcardMatch(s, pattern string) bool {
 		return true
 	}
 
-	parts := strings.Split(pattern, "*")
+	return matchParts(s, strings.Split(pattern, "*"))
+}
+
+// splitPattern 按未转义的 * 切分模式，并还原转义字符
+func splitPattern(pattern string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			part.WriteByte(pattern[i])
+		case c == '*':
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(c)
+		}
+	}
+	return append(parts, part.String())
+}
+
+// matchParts 依次匹配以 * 分隔的字面片段
+func matchParts(s string, parts []string) bool {
 	if len(parts) == 1 {
-		return s == pattern
+		return s == parts[0]
 	}
 
 	// 检查第一部分
@@ -3,13 +3,19 @@ module github.com/click33/sa-token-go/storage/redis
 go 1.21
 
 require (
+	github.com/alicebob/miniredis/v2 v2.39.0
 	github.com/click33/sa-token-go/core v0.1.3
+	github.com/click33/sa-token-go/storage/memory v0.1.3
 	github.com/redis/go-redis/v9 v9.5.1
 )
 
 require (
 	github.com/cespare/xxhash/v2 v2.2.0 // indirect
 	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 )
 
-replace github.com/click33/sa-token-go/core => ../../core
+replace (
+	github.com/click33/sa-token-go/core => ../../core
+	github.com/click33/sa-token-go/storage/memory => ../memory
+)
@@ -0,0 +1,1076 @@
+package redis_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/click33/sa-token-go/core/adapter"
+	"github.com/click33/sa-token-go/core/oauth2"
+	"github.com/click33/sa-token-go/core/oidc"
+	"github.com/click33/sa-token-go/storage/memory"
+	"github.com/click33/sa-token-go/storage/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// OAuth2 integration suite, every flow runs against memory and Redis storage | OAuth2集成测试，每个流程分别在内存和Redis存储上运行
+
+const (
+	testClientID     = "test-client"
+	testClientSecret = "test-secret"
+	testRedirectURI  = "https://app.example.com/callback"
+)
+
+// backends Storage factories under test | 待测试的存储工厂
+var backends = map[string]func(t *testing.T) adapter.Storage{
+	"memory": func(t *testing.T) adapter.Storage {
+		return memory.NewStorage()
+	},
+	"redis": func(t *testing.T) adapter.Storage {
+		mr := miniredis.RunT(t)
+		client := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return redis.NewStorageFromClient(client)
+	},
+}
+
+// oauth2Flows Flows run against every backend | 在每个存储上运行的流程
+var oauth2Flows = map[string]func(t *testing.T, server *oauth2.OAuth2Server){
+	"authorization code":    testAuthorizationCodeFlow,
+	"code reuse":            testAuthorizationCodeReuse,
+	"code client mismatch":  testAuthorizationCodeClientMismatch,
+	"refresh token":         testRefreshTokenFlow,
+	"revoke token":          testRevokeTokenFlow,
+	"invalid client secret": testInvalidClientSecret,
+	"client credentials":    testClientCredentialsFlow,
+	"password":              testPasswordFlow,
+	"grant type allow-list": testGrantTypeAllowList,
+	"pkce s256":             testPKCES256Flow,
+	"pkce plain":            testPKCEPlainFlow,
+	"pkce public client":    testPKCEPublicClient,
+	"pkce required":         testPKCERequired,
+	"scope validation":      testScopeValidation,
+	"scope wildcard":        testScopeWildcard,
+	"scope downscoping":     testScopeDownscoping,
+	"scope check":           testValidateAccessTokenWithScope,
+	"hashed client secret":  testHashedClientSecret,
+	"secret rotation":       testClientSecretRotation,
+	"dynamic registration":  testDynamicClientRegistration,
+	"openid connect":        testOpenIDConnectFlow,
+	"consent":               testConsentFlow,
+	"revoke consent":        testRevokeConsent,
+	"device code":           testDeviceCodeFlow,
+	"device code denied":    testDeviceCodeDenied,
+	"device code slow down": testDeviceCodeSlowDown,
+}
+
+func TestOAuth2Flows(t *testing.T) {
+	for backend, newStorage := range backends {
+		for name, flow := range oauth2Flows {
+			t.Run(backend+"/"+name, func(t *testing.T) {
+				storage := newStorage(t)
+				server := oauth2.NewOAuth2Server(storage, "satoken:")
+				server.SetClientStore(oauth2.NewStorageClientStore(storage, "satoken:"))
+				if err := server.RegisterClient(&oauth2.Client{
+					ClientID:     testClientID,
+					ClientSecret: testClientSecret,
+					RedirectURIs: []string{testRedirectURI},
+					GrantTypes: []oauth2.GrantType{
+						oauth2.GrantTypeAuthorizationCode,
+						oauth2.GrantTypeRefreshToken,
+						oauth2.GrantTypeClientCredentials,
+						oauth2.GrantTypePassword,
+					},
+					Scopes: []string{"read", "write"},
+				}); err != nil {
+					t.Fatalf("RegisterClient failed: %v", err)
+				}
+				server.SetPasswordVerifier(func(username, password string) (string, error) {
+					if username == "alice" && password == "wonderland" {
+						return "user1", nil
+					}
+					return "", errors.New("bad credentials")
+				})
+				flow(t, server)
+			})
+		}
+	}
+}
+
+// TestOAuth2ClientStore Clients registered on one node are visible on another | 在一个节点注册的客户端对其他节点可见
+func TestOAuth2ClientStore(t *testing.T) {
+	for backend, newStorage := range backends {
+		t.Run(backend, func(t *testing.T) {
+			storage := newStorage(t)
+			nodeA := oauth2.NewOAuth2Server(storage, "satoken:")
+			nodeA.SetClientStore(oauth2.NewStorageClientStore(storage, "satoken:"))
+			nodeB := oauth2.NewOAuth2Server(storage, "satoken:")
+			nodeB.SetClientStore(oauth2.NewStorageClientStore(storage, "satoken:"))
+
+			if err := nodeA.RegisterClient(&oauth2.Client{
+				ClientID:     "shared",
+				ClientSecret: "shared-secret",
+				GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+			}); err != nil {
+				t.Fatalf("RegisterClient failed: %v", err)
+			}
+
+			token, err := nodeB.ClientCredentialsToken("shared", "shared-secret", nil)
+			if err != nil {
+				t.Fatalf("node B should see the client: %v", err)
+			}
+			if _, err := nodeA.ValidateAccessToken(token.Token); err != nil {
+				t.Errorf("node A should accept node B's token: %v", err)
+			}
+
+			clients, err := nodeB.ListClients()
+			if err != nil || len(clients) != 1 || clients[0].ClientID != "shared" {
+				t.Errorf("unexpected client list: %v, %v", clients, err)
+			}
+
+			nodeB.UnregisterClient("shared")
+			if _, err := nodeA.GetClient("shared"); !errors.Is(err, oauth2.ErrClientNotFound) {
+				t.Errorf("expected ErrClientNotFound after unregister, got %v", err)
+			}
+		})
+	}
+}
+
+// TestOAuth2DeviceCodeExpired Polls after expiration get ErrDeviceCodeExpired | 过期后轮询返回ErrDeviceCodeExpired
+func TestOAuth2DeviceCodeExpired(t *testing.T) {
+	for backend, newStorage := range backends {
+		t.Run(backend, func(t *testing.T) {
+			storage := newStorage(t)
+			server := oauth2.NewOAuth2Server(storage, "satoken:")
+			registerDeviceClient(t, server)
+			device, err := server.CreateDeviceAuthorization("tv", "", nil)
+			if err != nil {
+				t.Fatalf("CreateDeviceAuthorization failed: %v", err)
+			}
+
+			// Move the authorization past its expiration | 将设备授权移到过期之后
+			device.CreateTime -= device.ExpiresIn + 1
+			if err := storage.Set("satoken:"+oauth2.DeviceCodeKeySuffix+device.DeviceCode, device, time.Minute); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+
+			if _, err := server.ApproveDeviceAuthorization(device.UserCode, "user1", 0); !errors.Is(err, oauth2.ErrInvalidUserCode) {
+				t.Errorf("expected ErrInvalidUserCode, got %v", err)
+			}
+			if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrDeviceCodeExpired) {
+				t.Errorf("expected ErrDeviceCodeExpired, got %v", err)
+			}
+		})
+	}
+}
+
+// TestOAuth2RefreshRotation Rotation keeps the refresh expiry and is won by one request | 轮换保留刷新令牌的过期时间，且只有一个请求能成功
+func TestOAuth2RefreshRotation(t *testing.T) {
+	for backend, newStorage := range backends {
+		t.Run(backend, func(t *testing.T) {
+			storage := newStorage(t)
+			server := oauth2.NewOAuth2Server(storage, "satoken:")
+			if err := server.RegisterClient(&oauth2.Client{
+				ClientID:     testClientID,
+				ClientSecret: testClientSecret,
+				RedirectURIs: []string{testRedirectURI},
+			}); err != nil {
+				t.Fatalf("RegisterClient failed: %v", err)
+			}
+			token := issueToken(t, server)
+
+			// The rotated token expires with the original one | 轮换后的令牌与原令牌同时过期
+			if err := storage.Expire("satoken:"+oauth2.RefreshKeySuffix+token.RefreshToken, time.Hour); err != nil {
+				t.Fatalf("Expire failed: %v", err)
+			}
+			refreshed, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret)
+			if err != nil {
+				t.Fatalf("RefreshAccessToken failed: %v", err)
+			}
+			ttl, err := storage.TTL("satoken:" + oauth2.RefreshKeySuffix + refreshed.RefreshToken)
+			if err != nil || ttl <= 0 || ttl > time.Hour {
+				t.Errorf("rotation should carry the expiry forward, got %v (%v)", ttl, err)
+			}
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			succeeded := 0
+			for i := 0; i < 8; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := server.RefreshAccessToken(refreshed.RefreshToken, testClientID, testClientSecret); err == nil {
+						mu.Lock()
+						succeeded++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+			if succeeded != 1 {
+				t.Errorf("exactly one concurrent refresh should succeed, got %d", succeeded)
+			}
+		})
+	}
+}
+
+// registerDeviceClient Registers the public client "tv" for the device grant | 注册用于设备授权的公开客户端"tv"
+func registerDeviceClient(t *testing.T, server *oauth2.OAuth2Server) {
+	t.Helper()
+	if err := server.RegisterClient(&oauth2.Client{
+		ClientID:   "tv",
+		GrantTypes: []oauth2.GrantType{oauth2.GrantTypeDeviceCode, oauth2.GrantTypeRefreshToken},
+		Scopes:     []string{"read", "write"},
+		Public:     true,
+	}); err != nil {
+		t.Fatalf("RegisterClient failed: %v", err)
+	}
+}
+
+// generateCode Issues an authorization code for user1 | 为user1签发授权码
+func generateCode(t *testing.T, server *oauth2.OAuth2Server, scopes ...string) *oauth2.AuthorizationCode {
+	t.Helper()
+	code, err := server.GenerateAuthorizationCode(testClientID, testRedirectURI, "user1", scopes)
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCode failed: %v", err)
+	}
+	return code
+}
+
+// issueToken Runs the authorization code flow up to the token response | 执行授权码流程直到获得令牌
+func issueToken(t *testing.T, server *oauth2.OAuth2Server) *oauth2.AccessToken {
+	t.Helper()
+	code := generateCode(t, server, "read")
+	token, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+	return token
+}
+
+func testAuthorizationCodeFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	token := issueToken(t, server)
+
+	validated, err := server.ValidateAccessToken(token.Token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken failed: %v", err)
+	}
+	if validated.UserID != "user1" || validated.ClientID != testClientID {
+		t.Errorf("unexpected token owner: %+v", validated)
+	}
+	if len(validated.Scopes) != 1 || validated.Scopes[0] != "read" {
+		t.Errorf("unexpected scopes: %v", validated.Scopes)
+	}
+	if validated.TokenType != oauth2.TokenTypeBearer {
+		t.Errorf("unexpected token type: %s", validated.TokenType)
+	}
+}
+
+func testAuthorizationCodeReuse(t *testing.T, server *oauth2.OAuth2Server) {
+	code := generateCode(t, server, "read")
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI); err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI); !errors.Is(err, oauth2.ErrAuthCodeUsed) {
+		t.Errorf("expected ErrAuthCodeUsed, got %v", err)
+	}
+}
+
+func testAuthorizationCodeClientMismatch(t *testing.T, server *oauth2.OAuth2Server) {
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "other-client",
+		ClientSecret: "other-secret",
+		RedirectURIs: []string{testRedirectURI},
+	})
+
+	code := generateCode(t, server)
+	if _, err := server.ExchangeCodeForToken(code.Code, "other-client", "other-secret", testRedirectURI); !errors.Is(err, oauth2.ErrClientMismatch) {
+		t.Errorf("expected ErrClientMismatch, got %v", err)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, "https://evil.example.com"); !errors.Is(err, oauth2.ErrRedirectURIMismatch) {
+		t.Errorf("expected ErrRedirectURIMismatch, got %v", err)
+	}
+}
+
+func testRefreshTokenFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	token := issueToken(t, server)
+
+	refreshed, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if refreshed.Token == token.Token {
+		t.Error("refresh should issue a new access token")
+	}
+	if _, err := server.ValidateAccessToken(token.Token); err == nil {
+		t.Error("old access token should be invalid after refresh")
+	}
+	if _, err := server.ValidateAccessToken(refreshed.Token); err != nil {
+		t.Errorf("refreshed access token should be valid: %v", err)
+	}
+
+	// Refresh tokens rotate, the consumed one cannot be reused | 刷新令牌会轮换，已消费的刷新令牌不能再次使用
+	if refreshed.RefreshToken == "" || refreshed.RefreshToken == token.RefreshToken {
+		t.Errorf("refresh should issue a new refresh token: %+v", refreshed)
+	}
+	if _, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret); !errors.Is(err, oauth2.ErrInvalidRefreshToken) {
+		t.Errorf("second use: expected ErrInvalidRefreshToken, got %v", err)
+	}
+	if _, err := server.ValidateAccessToken(refreshed.Token); err != nil {
+		t.Errorf("failed reuse must not revoke the new access token: %v", err)
+	}
+	if _, err := server.RefreshAccessToken(refreshed.RefreshToken, testClientID, testClientSecret); err != nil {
+		t.Errorf("rotated refresh token should be usable: %v", err)
+	}
+}
+
+func testRevokeTokenFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	token := issueToken(t, server)
+
+	if err := server.RevokeToken(token.Token); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := server.ValidateAccessToken(token.Token); err == nil {
+		t.Error("revoked access token should be invalid")
+	}
+	if _, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret); err == nil {
+		t.Error("refresh token should be revoked with its access token")
+	}
+}
+
+func testInvalidClientSecret(t *testing.T, server *oauth2.OAuth2Server) {
+	code := generateCode(t, server)
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, "wrong", testRedirectURI); !errors.Is(err, oauth2.ErrInvalidClientCredentials) {
+		t.Errorf("expected ErrInvalidClientCredentials, got %v", err)
+	}
+}
+
+func testClientCredentialsFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	token, err := server.ClientCredentialsToken(testClientID, testClientSecret, []string{"read"})
+	if err != nil {
+		t.Fatalf("ClientCredentialsToken failed: %v", err)
+	}
+	if token.RefreshToken != "" {
+		t.Error("client_credentials must not issue a refresh token")
+	}
+	validated, err := server.ValidateAccessToken(token.Token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken failed: %v", err)
+	}
+	if validated.UserID != "" || validated.ClientID != testClientID {
+		t.Errorf("unexpected token owner: %+v", validated)
+	}
+	if _, err := server.ClientCredentialsToken(testClientID, "wrong", nil); !errors.Is(err, oauth2.ErrInvalidClientCredentials) {
+		t.Errorf("expected ErrInvalidClientCredentials, got %v", err)
+	}
+}
+
+func testPasswordFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	token, err := server.PasswordToken(testClientID, testClientSecret, "alice", "wonderland", []string{"read"})
+	if err != nil {
+		t.Fatalf("PasswordToken failed: %v", err)
+	}
+	if token.UserID != "user1" || token.RefreshToken == "" {
+		t.Errorf("unexpected password token: %+v", token)
+	}
+	if _, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret); err != nil {
+		t.Errorf("password token should be refreshable: %v", err)
+	}
+	if _, err := server.PasswordToken(testClientID, testClientSecret, "alice", "wrong", nil); !errors.Is(err, oauth2.ErrInvalidUserCredentials) {
+		t.Errorf("expected ErrInvalidUserCredentials, got %v", err)
+	}
+}
+
+func testGrantTypeAllowList(t *testing.T, server *oauth2.OAuth2Server) {
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "service",
+		ClientSecret: "service-secret",
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+	})
+
+	if _, err := server.GenerateAuthorizationCode("service", testRedirectURI, "user1", nil); !errors.Is(err, oauth2.ErrUnauthorizedClient) {
+		t.Errorf("authorization_code: expected ErrUnauthorizedClient, got %v", err)
+	}
+	if _, err := server.PasswordToken("service", "service-secret", "alice", "wonderland", nil); !errors.Is(err, oauth2.ErrUnauthorizedClient) {
+		t.Errorf("password: expected ErrUnauthorizedClient, got %v", err)
+	}
+	if _, err := server.ClientCredentialsToken("service", "service-secret", nil); err != nil {
+		t.Errorf("client_credentials should be allowed: %v", err)
+	}
+
+	// Without refresh_token grant no refresh token is issued | 未允许refresh_token时不签发刷新令牌
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "no-refresh",
+		ClientSecret: "no-refresh-secret",
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypePassword},
+	})
+	token, err := server.PasswordToken("no-refresh", "no-refresh-secret", "alice", "wonderland", nil)
+	if err != nil {
+		t.Fatalf("PasswordToken failed: %v", err)
+	}
+	if token.RefreshToken != "" {
+		t.Error("refresh token issued to a client without refresh_token grant")
+	}
+}
+
+// testVerifier PKCE code_verifier used by the PKCE flows | PKCE流程使用的code_verifier
+var testVerifier = strings.Repeat("verifier-", 6)
+
+func s256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func testPKCES256Flow(t *testing.T, server *oauth2.OAuth2Server) {
+	code, err := server.GenerateAuthorizationCodeWithPKCE(testClientID, testRedirectURI, "user1", nil, s256(testVerifier), oauth2.CodeChallengeMethodS256)
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCodeWithPKCE failed: %v", err)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI); !errors.Is(err, oauth2.ErrInvalidCodeVerifier) {
+		t.Errorf("missing verifier: expected ErrInvalidCodeVerifier, got %v", err)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI, strings.Repeat("x", 43)); !errors.Is(err, oauth2.ErrInvalidCodeVerifier) {
+		t.Errorf("wrong verifier: expected ErrInvalidCodeVerifier, got %v", err)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI, testVerifier); err != nil {
+		t.Errorf("ExchangeCodeForToken with verifier failed: %v", err)
+	}
+}
+
+func testPKCEPlainFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	code, err := server.GenerateAuthorizationCodeWithPKCE(testClientID, testRedirectURI, "user1", nil, testVerifier, "")
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCodeWithPKCE failed: %v", err)
+	}
+	if code.CodeChallengeMethod != oauth2.CodeChallengeMethodPlain {
+		t.Errorf("method should default to plain, got %q", code.CodeChallengeMethod)
+	}
+	if _, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI, testVerifier); err != nil {
+		t.Errorf("ExchangeCodeForToken with verifier failed: %v", err)
+	}
+
+	if _, err := server.GenerateAuthorizationCodeWithPKCE(testClientID, testRedirectURI, "user1", nil, testVerifier, "S512"); !errors.Is(err, oauth2.ErrUnsupportedPKCEMethod) {
+		t.Errorf("expected ErrUnsupportedPKCEMethod, got %v", err)
+	}
+	if _, err := server.GenerateAuthorizationCodeWithPKCE(testClientID, testRedirectURI, "user1", nil, "short", oauth2.CodeChallengeMethodPlain); !errors.Is(err, oauth2.ErrInvalidCodeChallenge) {
+		t.Errorf("expected ErrInvalidCodeChallenge, got %v", err)
+	}
+}
+
+func testPKCEPublicClient(t *testing.T, server *oauth2.OAuth2Server) {
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "spa",
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeAuthorizationCode, oauth2.GrantTypeRefreshToken, oauth2.GrantTypeClientCredentials},
+		Public:       true,
+	})
+
+	if _, err := server.GenerateAuthorizationCode("spa", testRedirectURI, "user1", nil); !errors.Is(err, oauth2.ErrPKCERequired) {
+		t.Errorf("expected ErrPKCERequired, got %v", err)
+	}
+
+	code, err := server.GenerateAuthorizationCodeWithPKCE("spa", testRedirectURI, "user1", nil, s256(testVerifier), oauth2.CodeChallengeMethodS256)
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCodeWithPKCE failed: %v", err)
+	}
+	token, err := server.ExchangeCodeForToken(code.Code, "spa", "", testRedirectURI, testVerifier)
+	if err != nil {
+		t.Fatalf("public client exchange failed: %v", err)
+	}
+	if _, err := server.RefreshAccessToken(token.RefreshToken, "spa", ""); err != nil {
+		t.Errorf("public client refresh failed: %v", err)
+	}
+	if _, err := server.ClientCredentialsToken("spa", "", nil); !errors.Is(err, oauth2.ErrUnauthorizedClient) {
+		t.Errorf("public client must not use client_credentials, got %v", err)
+	}
+}
+
+func testPKCERequired(t *testing.T, server *oauth2.OAuth2Server) {
+	server.SetRequirePKCE(true)
+
+	if _, err := server.GenerateAuthorizationCode(testClientID, testRedirectURI, "user1", nil); !errors.Is(err, oauth2.ErrPKCERequired) {
+		t.Errorf("expected ErrPKCERequired, got %v", err)
+	}
+	if _, err := server.GenerateAuthorizationCodeWithPKCE(testClientID, testRedirectURI, "user1", nil, s256(testVerifier), oauth2.CodeChallengeMethodS256); err != nil {
+		t.Errorf("PKCE request should be accepted: %v", err)
+	}
+}
+
+func testScopeValidation(t *testing.T, server *oauth2.OAuth2Server) {
+	if _, err := server.GenerateAuthorizationCode(testClientID, testRedirectURI, "user1", []string{"read", "admin"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("authorization_code: expected ErrInvalidScope, got %v", err)
+	}
+	if _, err := server.ClientCredentialsToken(testClientID, testClientSecret, []string{"admin"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("client_credentials: expected ErrInvalidScope, got %v", err)
+	}
+	if _, err := server.PasswordToken(testClientID, testClientSecret, "alice", "wonderland", []string{"admin"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("password: expected ErrInvalidScope, got %v", err)
+	}
+
+	// No requested scope falls back to the client's scopes | 未请求权限范围时使用客户端的权限范围
+	code := generateCode(t, server)
+	if strings.Join(code.Scopes, " ") != "read write" {
+		t.Errorf("expected default scopes [read write], got %v", code.Scopes)
+	}
+
+	// Duplicates are collapsed | 重复的权限范围会被合并
+	code = generateCode(t, server, "read", "read")
+	if len(code.Scopes) != 1 {
+		t.Errorf("expected deduplicated scopes, got %v", code.Scopes)
+	}
+}
+
+func testScopeWildcard(t *testing.T, server *oauth2.OAuth2Server) {
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "wildcard",
+		ClientSecret: "wildcard-secret",
+		RedirectURIs: []string{testRedirectURI},
+		Scopes:       []string{"user:*", "order:*:view"},
+	})
+
+	for _, scope := range []string{"user:read", "user:profile:edit", "order:42:view"} {
+		if _, err := server.GenerateAuthorizationCode("wildcard", testRedirectURI, "user1", []string{scope}); err != nil {
+			t.Errorf("scope %q should be allowed: %v", scope, err)
+		}
+	}
+	for _, scope := range []string{"user", "order:42:edit", "admin"} {
+		if _, err := server.GenerateAuthorizationCode("wildcard", testRedirectURI, "user1", []string{scope}); !errors.Is(err, oauth2.ErrInvalidScope) {
+			t.Errorf("scope %q: expected ErrInvalidScope, got %v", scope, err)
+		}
+	}
+}
+
+func testScopeDownscoping(t *testing.T, server *oauth2.OAuth2Server) {
+	code := generateCode(t, server, "read", "write")
+	token, err := server.ExchangeCodeForToken(code.Code, testClientID, testClientSecret, testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+
+	narrowed, err := server.RefreshAccessToken(token.RefreshToken, testClientID, testClientSecret, "read")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken with subset failed: %v", err)
+	}
+	if len(narrowed.Scopes) != 1 || narrowed.Scopes[0] != "read" {
+		t.Errorf("expected narrowed scopes [read], got %v", narrowed.Scopes)
+	}
+
+	// The refresh token keeps the original grant | 刷新令牌保留最初的授权
+	if _, err := server.RefreshAccessToken(narrowed.RefreshToken, testClientID, testClientSecret, "admin"); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("widening: expected ErrInvalidScope, got %v", err)
+	}
+	restored, err := server.RefreshAccessToken(narrowed.RefreshToken, testClientID, testClientSecret)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	if strings.Join(restored.Scopes, " ") != "read write" {
+		t.Errorf("expected original scopes [read write], got %v", restored.Scopes)
+	}
+}
+
+func testValidateAccessTokenWithScope(t *testing.T, server *oauth2.OAuth2Server) {
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "wildcard",
+		ClientSecret: "wildcard-secret",
+		RedirectURIs: []string{testRedirectURI},
+		Scopes:       []string{"user:*", "read"},
+	})
+	code, err := server.GenerateAuthorizationCode("wildcard", testRedirectURI, "user1", []string{"user:*", "read"})
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCode failed: %v", err)
+	}
+	token, err := server.ExchangeCodeForToken(code.Code, "wildcard", "wildcard-secret", testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+
+	if _, err := server.ValidateAccessTokenWithScope(token.Token, "read", "user:delete"); err != nil {
+		t.Errorf("granted scopes should pass: %v", err)
+	}
+	if _, err := server.ValidateAccessTokenWithScope(token.Token); err != nil {
+		t.Errorf("no required scope should pass: %v", err)
+	}
+	if _, err := server.ValidateAccessTokenWithScope(token.Token, "write"); !errors.Is(err, oauth2.ErrInsufficientScope) {
+		t.Errorf("expected ErrInsufficientScope, got %v", err)
+	}
+	if _, err := server.ValidateAccessTokenWithScope("unknown", "read"); !errors.Is(err, oauth2.ErrInvalidAccessToken) {
+		t.Errorf("expected ErrInvalidAccessToken, got %v", err)
+	}
+}
+
+func testHashedClientSecret(t *testing.T, server *oauth2.OAuth2Server) {
+	client := &oauth2.Client{ClientID: "hashed", ClientSecret: "plain-secret"}
+	if err := server.RegisterClient(client); err != nil {
+		t.Fatalf("RegisterClient failed: %v", err)
+	}
+	if client.ClientSecret != "plain-secret" || len(client.Secrets) != 0 {
+		t.Error("RegisterClient must not modify the passed client")
+	}
+
+	stored, err := server.GetClient("hashed")
+	if err != nil {
+		t.Fatalf("GetClient failed: %v", err)
+	}
+	if stored.ClientSecret != "" || len(stored.Secrets) != 1 {
+		t.Fatalf("secret should be stored hashed: %+v", stored)
+	}
+	if strings.Contains(stored.Secrets[0].Hash, "plain-secret") || !strings.HasPrefix(stored.Secrets[0].Hash, "$pbkdf2-sha256$") {
+		t.Errorf("unexpected secret hash: %s", stored.Secrets[0].Hash)
+	}
+
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "hashed",
+		ClientSecret: "plain-secret",
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+	})
+	if _, err := server.ClientCredentialsToken("hashed", "plain-secret", nil); err != nil {
+		t.Errorf("hashed secret should verify: %v", err)
+	}
+	for _, secret := range []string{"", "plain-secre", "plain-secret "} {
+		if _, err := server.ClientCredentialsToken("hashed", secret, nil); !errors.Is(err, oauth2.ErrInvalidClientCredentials) {
+			t.Errorf("secret %q: expected ErrInvalidClientCredentials, got %v", secret, err)
+		}
+	}
+}
+
+func testClientSecretRotation(t *testing.T, server *oauth2.OAuth2Server) {
+	authenticate := func(secret string) error {
+		_, err := server.ClientCredentialsToken(testClientID, secret, nil)
+		return err
+	}
+
+	// Overlap window keeps the old secret valid | 重叠窗口内旧密钥仍然有效
+	second, err := server.RotateClientSecret(testClientID, time.Hour)
+	if err != nil {
+		t.Fatalf("RotateClientSecret failed: %v", err)
+	}
+	if err := authenticate(testClientSecret); err != nil {
+		t.Errorf("old secret should work during overlap: %v", err)
+	}
+	if err := authenticate(second); err != nil {
+		t.Errorf("new secret should work: %v", err)
+	}
+
+	// Without overlap only the newest secret remains | 无重叠时只保留最新密钥
+	third, err := server.RotateClientSecret(testClientID, 0)
+	if err != nil {
+		t.Fatalf("RotateClientSecret failed: %v", err)
+	}
+	for _, old := range []string{testClientSecret, second} {
+		if err := authenticate(old); !errors.Is(err, oauth2.ErrInvalidClientCredentials) {
+			t.Errorf("retired secret: expected ErrInvalidClientCredentials, got %v", err)
+		}
+	}
+	if err := authenticate(third); err != nil {
+		t.Errorf("newest secret should work: %v", err)
+	}
+
+	client, _ := server.GetClient(testClientID)
+	if len(client.Secrets) != 1 {
+		t.Errorf("expired secrets should be pruned, got %d", len(client.Secrets))
+	}
+}
+
+func testDynamicClientRegistration(t *testing.T, server *oauth2.OAuth2Server) {
+	server.SetRegistrationScopes([]string{"read", "user:*"})
+
+	registered, err := server.RegisterDynamicClient(&oauth2.ClientRegistrationRequest{
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeAuthorizationCode, oauth2.GrantTypeClientCredentials},
+		Scope:        "read user:profile",
+		ClientName:   "Dynamic App",
+	})
+	if err != nil {
+		t.Fatalf("RegisterDynamicClient failed: %v", err)
+	}
+	if registered.ClientID == "" || registered.ClientSecret == "" || registered.TokenEndpointAuthMethod != oauth2.AuthMethodClientSecretBasic {
+		t.Fatalf("unexpected registration response: %+v", registered)
+	}
+	token, err := server.ClientCredentialsToken(registered.ClientID, registered.ClientSecret, []string{"user:profile"})
+	if err != nil {
+		t.Fatalf("registered client should authenticate: %v", err)
+	}
+	if _, err := server.ClientCredentialsToken(registered.ClientID, registered.ClientSecret, []string{"write"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("expected ErrInvalidScope, got %v", err)
+	}
+	if token.ClientID != registered.ClientID {
+		t.Errorf("unexpected token client: %s", token.ClientID)
+	}
+
+	public, err := server.RegisterDynamicClient(&oauth2.ClientRegistrationRequest{
+		RedirectURIs:            []string{"com.example.app:/callback"},
+		TokenEndpointAuthMethod: oauth2.AuthMethodNone,
+	})
+	if err != nil {
+		t.Fatalf("public registration failed: %v", err)
+	}
+	if public.ClientSecret != "" || public.Scope != "read user:*" {
+		t.Errorf("unexpected public registration: %+v", public)
+	}
+	if client, _ := server.GetClient(public.ClientID); client == nil || !client.Public {
+		t.Error("auth method none should register a public client")
+	}
+
+	invalid := map[string]struct {
+		req  *oauth2.ClientRegistrationRequest
+		want error
+	}{
+		"missing redirect_uris": {&oauth2.ClientRegistrationRequest{}, oauth2.ErrInvalidRedirectURI},
+		"fragment in redirect":  {&oauth2.ClientRegistrationRequest{RedirectURIs: []string{"https://app.example.com/cb#x"}}, oauth2.ErrInvalidRedirectURI},
+		"relative redirect":     {&oauth2.ClientRegistrationRequest{RedirectURIs: []string{"/cb"}}, oauth2.ErrInvalidRedirectURI},
+		"unknown grant type":    {&oauth2.ClientRegistrationRequest{GrantTypes: []oauth2.GrantType{"implicit"}}, oauth2.ErrInvalidClientMetadata},
+		"unknown auth method":   {&oauth2.ClientRegistrationRequest{TokenEndpointAuthMethod: "private_key_jwt"}, oauth2.ErrInvalidClientMetadata},
+		"scope not allowed":     {&oauth2.ClientRegistrationRequest{RedirectURIs: []string{testRedirectURI}, Scope: "admin"}, oauth2.ErrInvalidClientMetadata},
+		"public client_credentials": {&oauth2.ClientRegistrationRequest{
+			GrantTypes:              []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+			TokenEndpointAuthMethod: oauth2.AuthMethodNone,
+		}, oauth2.ErrInvalidClientMetadata},
+		"password grant": {&oauth2.ClientRegistrationRequest{
+			GrantTypes: []oauth2.GrantType{oauth2.GrantTypePassword},
+		}, oauth2.ErrInvalidClientMetadata},
+	}
+	for name, tc := range invalid {
+		if _, err := server.RegisterDynamicClient(tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", name, tc.want, err)
+		}
+	}
+
+	// Without an allow-list dynamic clients get no scopes | 未设置允许列表时动态客户端没有任何权限范围
+	server.SetRegistrationScopes(nil)
+	if _, err := server.RegisterDynamicClient(&oauth2.ClientRegistrationRequest{
+		GrantTypes: []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+		Scope:      "admin",
+	}); !errors.Is(err, oauth2.ErrInvalidClientMetadata) {
+		t.Errorf("scopes should not be granted without an allow-list, got %v", err)
+	}
+	unscoped, err := server.RegisterDynamicClient(&oauth2.ClientRegistrationRequest{
+		GrantTypes: []oauth2.GrantType{oauth2.GrantTypeClientCredentials},
+	})
+	if err != nil {
+		t.Fatalf("registration without scopes failed: %v", err)
+	}
+	if _, err := server.ClientCredentialsToken(unscoped.ClientID, unscoped.ClientSecret, []string{"admin"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("dynamic client without scopes must not be unrestricted, got %v", err)
+	}
+}
+
+func testOpenIDConnectFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	provider, err := oidc.NewProvider(server, "https://sso.example.com/", nil)
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	provider.SetClaimsProvider(func(userID string) (map[string]any, error) {
+		return map[string]any{"name": "Alice", "email": "alice@example.com", "internal": "secret"}, nil
+	})
+	server.RegisterClient(&oauth2.Client{
+		ClientID:     "rp",
+		ClientSecret: "rp-secret",
+		RedirectURIs: []string{testRedirectURI},
+		GrantTypes:   []oauth2.GrantType{oauth2.GrantTypeAuthorizationCode, oauth2.GrantTypeRefreshToken, oauth2.GrantTypeClientCredentials},
+		Scopes:       []string{"openid", "profile", "email"},
+	})
+
+	authTime := time.Now().Add(-time.Hour).Unix()
+	code, err := server.CreateAuthorizationCode(&oauth2.AuthorizationRequest{
+		ClientID:    "rp",
+		RedirectURI: testRedirectURI,
+		UserID:      "user1",
+		Scopes:      []string{"openid", "profile"},
+		Nonce:       "n-0S6_WzA2Mj",
+		AuthTime:    authTime,
+	})
+	if err != nil {
+		t.Fatalf("CreateAuthorizationCode failed: %v", err)
+	}
+	token, err := server.ExchangeCodeForToken(code.Code, "rp", "rp-secret", testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(token.IDToken, "rp")
+	if err != nil {
+		t.Fatalf("VerifyIDToken failed: %v", err)
+	}
+	if claims.Issuer != "https://sso.example.com" || claims.Subject != "user1" {
+		t.Errorf("unexpected iss/sub: %s %s", claims.Issuer, claims.Subject)
+	}
+	if claims.Nonce != "n-0S6_WzA2Mj" || claims.AuthTime != authTime {
+		t.Errorf("unexpected nonce/auth_time: %s %d", claims.Nonce, claims.AuthTime)
+	}
+	if claims.AtHash != oidc.AccessTokenHash(token.Token) {
+		t.Errorf("at_hash does not match the access token")
+	}
+	if _, err := provider.VerifyIDToken(token.IDToken, "other"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("expected ErrInvalidIDToken for another audience, got %v", err)
+	}
+
+	// Refreshed ID tokens keep auth_time and drop the nonce | 刷新后的ID令牌保留auth_time，不含nonce
+	refreshed, err := server.RefreshAccessToken(token.RefreshToken, "rp", "rp-secret")
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	refreshedClaims, err := provider.VerifyIDToken(refreshed.IDToken, "rp")
+	if err != nil {
+		t.Fatalf("VerifyIDToken after refresh failed: %v", err)
+	}
+	if refreshedClaims.Nonce != "" || refreshedClaims.AuthTime != authTime {
+		t.Errorf("unexpected refreshed claims: %+v", refreshedClaims)
+	}
+
+	// UserInfo only releases claims of granted scopes | UserInfo只释放已授予权限范围的声明
+	info, err := provider.UserInfo(refreshed.Token)
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if info["sub"] != "user1" || info["name"] != "Alice" {
+		t.Errorf("missing profile claims: %v", info)
+	}
+	if _, ok := info["email"]; ok {
+		t.Errorf("email released without the email scope: %v", info)
+	}
+	if _, ok := info["internal"]; ok {
+		t.Errorf("non-standard claim released: %v", info)
+	}
+
+	// No openid scope, no ID token | 没有openid权限范围时不签发ID令牌
+	plain := generateCode(t, server, "read")
+	plainToken, err := server.ExchangeCodeForToken(plain.Code, testClientID, testClientSecret, testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+	if plainToken.IDToken != "" {
+		t.Errorf("ID token issued without the openid scope")
+	}
+	if _, err := provider.UserInfo(plainToken.Token); !errors.Is(err, oauth2.ErrInsufficientScope) {
+		t.Errorf("expected ErrInsufficientScope, got %v", err)
+	}
+
+	// Client credentials tokens have no end user | 客户端凭证令牌没有终端用户
+	clientToken, err := server.ClientCredentialsToken("rp", "rp-secret", []string{"openid"})
+	if err != nil {
+		t.Fatalf("ClientCredentialsToken failed: %v", err)
+	}
+	if clientToken.IDToken != "" {
+		t.Errorf("ID token issued without an end user")
+	}
+	if _, err := provider.UserInfo(clientToken.Token); !errors.Is(err, oauth2.ErrInvalidAccessToken) {
+		t.Errorf("expected ErrInvalidAccessToken, got %v", err)
+	}
+
+	// JWKS publishes the key named in the ID token header | JWKS公开ID令牌头中指定的密钥
+	keys := provider.JWKS().Keys
+	if len(keys) != 1 || keys[0].Alg != oidc.SigningAlgorithm || keys[0].Kid == "" {
+		t.Errorf("unexpected JWKS: %+v", keys)
+	}
+	if doc := provider.Discovery(); doc.JWKSURI != "https://sso.example.com"+oidc.JWKSPath {
+		t.Errorf("unexpected jwks_uri: %s", doc.JWKSURI)
+	}
+}
+
+func testConsentFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	if server.HasConsent("user1", testClientID, []string{"read"}) {
+		t.Fatal("consent should not exist before authorization")
+	}
+
+	// Issuing a code does not imply consent | 签发授权码不代表用户已同意
+	generateCode(t, server, "read")
+	if server.HasConsent("user1", testClientID, []string{"read"}) {
+		t.Fatal("authorization code should not record consent")
+	}
+
+	if _, err := server.GrantConsent("user1", testClientID, []string{"read"}); err != nil {
+		t.Fatalf("GrantConsent failed: %v", err)
+	}
+	if !server.HasConsent("user1", testClientID, []string{"read"}) {
+		t.Error("consent should cover the authorized scope")
+	}
+	if server.HasConsent("user1", testClientID, []string{"read", "write"}) {
+		t.Error("consent should not cover a scope never approved")
+	}
+	if server.HasConsent("user2", testClientID, []string{"read"}) {
+		t.Error("consent should be per user")
+	}
+
+	// Later approvals are merged | 之后的批准会合并
+	consent, err := server.GrantConsent("user1", testClientID, []string{"write"})
+	if err != nil {
+		t.Fatalf("GrantConsent failed: %v", err)
+	}
+	if !consent.Covers([]string{"read", "write"}) || consent.CreateTime == 0 || consent.UpdateTime < consent.CreateTime {
+		t.Errorf("unexpected merged consent: %+v", consent)
+	}
+	if !server.HasConsent("user1", testClientID, nil) {
+		t.Error("empty request should check the client's default scopes")
+	}
+	if _, err := server.GrantConsent("user1", testClientID, []string{"admin"}); !errors.Is(err, oauth2.ErrInvalidScope) {
+		t.Errorf("expected ErrInvalidScope, got %v", err)
+	}
+
+	server.RegisterClient(&oauth2.Client{ClientID: "other", ClientSecret: "other-secret", RedirectURIs: []string{testRedirectURI}})
+	if _, err := server.GrantConsent("user1", "other", nil); err != nil {
+		t.Fatalf("GrantConsent failed: %v", err)
+	}
+	// A user ID extending user1 must not show up | 以user1开头的其他用户不应出现
+	if _, err := server.GrantConsent("user1:evil", testClientID, nil); err != nil {
+		t.Fatalf("GrantConsent failed: %v", err)
+	}
+
+	consents, err := server.ListConsents("user1")
+	if err != nil {
+		t.Fatalf("ListConsents failed: %v", err)
+	}
+	if len(consents) != 2 || consents[0].ClientID != "other" || consents[1].ClientID != testClientID {
+		t.Errorf("unexpected authorized applications: %+v", consents)
+	}
+
+	// IDs containing ':' must not collide | 含':'的ID不能发生冲突
+	server.RegisterClient(&oauth2.Client{ClientID: "evil:" + testClientID, ClientSecret: "evil-secret", RedirectURIs: []string{testRedirectURI}})
+	if _, err := server.GrantConsent("user2", "evil:"+testClientID, nil); err != nil {
+		t.Fatalf("GrantConsent failed: %v", err)
+	}
+	if server.HasConsent("user2:evil", testClientID, nil) {
+		t.Error("consent of user2 must not be visible to user2:evil")
+	}
+}
+
+func testRevokeConsent(t *testing.T, server *oauth2.OAuth2Server) {
+	first := issueToken(t, server)
+	// Refreshing consumes the first refresh token, the rotated one must be revoked | 刷新会消费第一个刷新令牌，轮换后的刷新令牌需被撤销
+	second, err := server.RefreshAccessToken(first.RefreshToken, testClientID, testClientSecret)
+	if err != nil {
+		t.Fatalf("RefreshAccessToken failed: %v", err)
+	}
+	password, err := server.PasswordToken(testClientID, testClientSecret, "alice", "wonderland", nil)
+	if err != nil {
+		t.Fatalf("PasswordToken failed: %v", err)
+	}
+
+	// Tokens of another user stay valid | 其他用户的令牌保持有效
+	otherCode, err := server.GenerateAuthorizationCode(testClientID, testRedirectURI, "user2", []string{"read"})
+	if err != nil {
+		t.Fatalf("GenerateAuthorizationCode failed: %v", err)
+	}
+	other, err := server.ExchangeCodeForToken(otherCode.Code, testClientID, testClientSecret, testRedirectURI)
+	if err != nil {
+		t.Fatalf("ExchangeCodeForToken failed: %v", err)
+	}
+
+	if err := server.RevokeConsent("user1", testClientID); err != nil {
+		t.Fatalf("RevokeConsent failed: %v", err)
+	}
+
+	if _, err := server.GetConsent("user1", testClientID); !errors.Is(err, oauth2.ErrConsentNotFound) {
+		t.Errorf("expected ErrConsentNotFound, got %v", err)
+	}
+	for _, token := range []*oauth2.AccessToken{second, password} {
+		if _, err := server.ValidateAccessToken(token.Token); !errors.Is(err, oauth2.ErrInvalidAccessToken) {
+			t.Errorf("access token should be revoked, got %v", err)
+		}
+	}
+	for _, refresh := range []string{first.RefreshToken, second.RefreshToken, password.RefreshToken} {
+		if _, err := server.RefreshAccessToken(refresh, testClientID, testClientSecret); !errors.Is(err, oauth2.ErrInvalidRefreshToken) {
+			t.Errorf("refresh token should be revoked, got %v", err)
+		}
+	}
+	if _, err := server.ValidateAccessToken(other.Token); err != nil {
+		t.Errorf("other user's token should stay valid: %v", err)
+	}
+}
+
+func testDeviceCodeFlow(t *testing.T, server *oauth2.OAuth2Server) {
+	registerDeviceClient(t, server)
+	server.SetDevicePollInterval(0)
+
+	if _, err := server.CreateDeviceAuthorization(testClientID, testClientSecret, nil); !errors.Is(err, oauth2.ErrUnauthorizedClient) {
+		t.Errorf("expected ErrUnauthorizedClient, got %v", err)
+	}
+	device, err := server.CreateDeviceAuthorization("tv", "", []string{"read"})
+	if err != nil {
+		t.Fatalf("CreateDeviceAuthorization failed: %v", err)
+	}
+	if len(device.UserCode) != oauth2.UserCodeLength || device.DeviceCode == "" || device.ExpiresIn <= 0 {
+		t.Errorf("unexpected device authorization: %+v", device)
+	}
+
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrAuthorizationPending) {
+		t.Errorf("expected ErrAuthorizationPending, got %v", err)
+	}
+
+	// User codes are case-insensitive and may be entered with a separator | 用户码不区分大小写，可带分隔符输入
+	entered := strings.ToLower(oauth2.FormatUserCode(device.UserCode))
+	pending, err := server.GetDeviceAuthorization(entered)
+	if err != nil || pending.ClientID != "tv" {
+		t.Fatalf("GetDeviceAuthorization failed: %+v, %v", pending, err)
+	}
+	if _, err := server.ApproveDeviceAuthorization(entered, "user1", 0); err != nil {
+		t.Fatalf("ApproveDeviceAuthorization failed: %v", err)
+	}
+	if _, err := server.ApproveDeviceAuthorization(entered, "user2", 0); !errors.Is(err, oauth2.ErrInvalidUserCode) {
+		t.Errorf("user codes are single-use, got %v", err)
+	}
+	if !server.HasConsent("user1", "tv", []string{"read"}) {
+		t.Error("approval should be remembered as consent")
+	}
+
+	token, err := server.PollDeviceToken(device.DeviceCode, "tv", "")
+	if err != nil {
+		t.Fatalf("PollDeviceToken failed: %v", err)
+	}
+	if token.UserID != "user1" || token.ClientID != "tv" || token.RefreshToken == "" || strings.Join(token.Scopes, " ") != "read" {
+		t.Errorf("unexpected device token: %+v", token)
+	}
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrInvalidDeviceCode) {
+		t.Errorf("device codes are single-use, got %v", err)
+	}
+}
+
+func testDeviceCodeDenied(t *testing.T, server *oauth2.OAuth2Server) {
+	registerDeviceClient(t, server)
+	server.SetDevicePollInterval(0)
+
+	device, err := server.CreateDeviceAuthorization("tv", "", nil)
+	if err != nil {
+		t.Fatalf("CreateDeviceAuthorization failed: %v", err)
+	}
+	if _, err := server.DenyDeviceAuthorization(device.UserCode); err != nil {
+		t.Fatalf("DenyDeviceAuthorization failed: %v", err)
+	}
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrDeviceAccessDenied) {
+		t.Errorf("expected ErrDeviceAccessDenied, got %v", err)
+	}
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrInvalidDeviceCode) {
+		t.Errorf("denied device code should be gone, got %v", err)
+	}
+}
+
+func testDeviceCodeSlowDown(t *testing.T, server *oauth2.OAuth2Server) {
+	registerDeviceClient(t, server)
+
+	device, err := server.CreateDeviceAuthorization("tv", "", nil)
+	if err != nil {
+		t.Fatalf("CreateDeviceAuthorization failed: %v", err)
+	}
+	if device.Interval != int64(oauth2.DefaultDevicePollInterval.Seconds()) {
+		t.Errorf("unexpected interval %d", device.Interval)
+	}
+
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrAuthorizationPending) {
+		t.Errorf("expected ErrAuthorizationPending, got %v", err)
+	}
+	if _, err := server.PollDeviceToken(device.DeviceCode, "tv", ""); !errors.Is(err, oauth2.ErrSlowDown) {
+		t.Errorf("expected ErrSlowDown, got %v", err)
+	}
+	pending, err := server.GetDeviceAuthorization(device.UserCode)
+	if err != nil {
+		t.Fatalf("GetDeviceAuthorization failed: %v", err)
+	}
+	if pending.Interval != device.Interval+5 {
+		t.Errorf("slow_down should widen the interval by 5s, got %d", pending.Interval)
+	}
+}
@@ -106,6 +106,13 @@ func (s *Storage) Set(key string, value any, expiration time.Duration) error {
 	return s.client.Set(ctx, s.getKey(key), value, expiration).Err()
 }
 
+// SetNX 仅当键不存在时设置键值对，返回是否设置成功
+func (s *Storage) SetNX(key string, value any, expiration time.Duration) (bool, error) {
+	ctx, cancel := s.withTimeout()
+	defer cancel()
+	return s.client.SetNX(ctx, s.getKey(key), value, expiration).Result()
+}
+
 // Get 获取值
 func (s *Storage) Get(key string) (any, error) {
 	ctx, cancel := s.withTimeout()
@@ -14,15 +14,20 @@ import (
 // Global Manager instance | 全局Manager实例
 var (
 	globalManager *manager.Manager
+	managers      = make(map[string]*manager.Manager) // Managers by login type | 按登录类型注册的Manager
 	once          sync.Once
 	mu            sync.RWMutex
 )
 
 // SetManager sets the global Manager (must be called first) | 设置全局Manager（必须先调用此方法）
+// It is also registered under its login type for Use() | 同时按其登录类型注册，供Use()使用
 func SetManager(mgr *manager.Manager) {
 	mu.Lock()
 	defer mu.Unlock()
 	globalManager = mgr
+	if mgr != nil {
+		managers[mgr.GetLoginType()] = mgr
+	}
 }
 
 // GetManager gets the global Manager | 获取全局Manager
@@ -35,11 +40,55 @@ func GetManager() *manager.Manager {
 	return globalManager
 }
 
+// PutManager registers a Manager under its login type without changing the global one | 按登录类型注册Manager，不改变全局Manager
+// e.g. PutManager(core.NewBuilder().LoginType("admin").TokenName("admin-token").Build()) | 例如注册"admin"账号体系
+func PutManager(mgr *manager.Manager) {
+	if mgr == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	managers[mgr.GetLoginType()] = mgr
+}
+
+// Use gets the Manager of a login type, e.g. stputil.Use("admin").Login("1") | 获取指定登录类型的Manager，例如stputil.Use("admin").Login("1")
+// "" returns the global Manager, panics when the type is not registered | ""返回全局Manager，类型未注册时panic
+func Use(loginType string) *manager.Manager {
+	if loginType == "" {
+		return GetManager()
+	}
+	mgr, err := LookupManager(loginType)
+	if err != nil {
+		panic(err.Error())
+	}
+	return mgr
+}
+
+// LookupManager gets the Manager of a login type, returns an error instead of panicking when it is not registered | 获取指定登录类型的Manager，未注册时返回错误而不是panic
+func LookupManager(loginType string) (*manager.Manager, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if loginType == "" {
+		if globalManager == nil {
+			return nil, fmt.Errorf("StpUtil not initialized, please call SetManager() first or use builder.NewBuilder().Build()")
+		}
+		return globalManager, nil
+	}
+	mgr, ok := managers[loginType]
+	if !ok {
+		return nil, fmt.Errorf("StpUtil login type %q not registered, please call PutManager() first", loginType)
+	}
+	return mgr, nil
+}
+
 // CloseManager closes global Manager and releases resources | 关闭全局 Manager 并释放资源
 func CloseManager() {
 	mu.Lock()
 	defer mu.Unlock()
 	if globalManager != nil {
+		if managers[globalManager.GetLoginType()] == globalManager {
+			delete(managers, globalManager.GetLoginType())
+		}
 		globalManager.Close()
 		globalManager = nil // 置 nil 避免后续误用
 	}
@@ -92,6 +141,11 @@ func GetTokenValue(loginID interface{}, device ...string) (string, error) {
 	return GetManager().GetTokenValue(toString(loginID), device...)
 }
 
+// GetTokenDigest gets the at-rest form of the token for a login ID | 获取登录ID对应Token的存储形式
+func GetTokenDigest(loginID interface{}, device ...string) (string, error) {
+	return GetManager().GetTokenDigest(toString(loginID), device...)
+}
+
 // GetTokenInfo gets token information | 获取Token信息
 func GetTokenInfo(tokenValue string) (*manager.TokenInfo, error) {
 	return GetManager().GetTokenInfo(tokenValue)
@@ -126,6 +180,56 @@ func GetDisableTime(loginID interface{}) (int64, error) {
 	return GetManager().GetDisableTime(toString(loginID))
 }
 
+// DisableService disables one service of an account, such as "comment" | 封禁账号的指定服务，例如"comment"
+func DisableService(loginID interface{}, service string, duration time.Duration) error {
+	return GetManager().DisableService(toString(loginID), service, duration)
+}
+
+// DisableLevel disables one service of an account at a level | 按等级封禁账号的指定服务
+func DisableLevel(loginID interface{}, service string, level int, duration time.Duration) error {
+	return GetManager().DisableLevel(toString(loginID), service, level, duration)
+}
+
+// DisableWithInfo disables an account with a ban record carrying reason and operator | 使用带原因和操作人的封禁记录封禁账号
+func DisableWithInfo(info *manager.DisableInfo, duration time.Duration) error {
+	return GetManager().DisableWithInfo(info, duration)
+}
+
+// UntieService lifts the bans of the given services | 解除指定服务的封禁
+func UntieService(loginID interface{}, services ...string) error {
+	return GetManager().UntieService(toString(loginID), services...)
+}
+
+// IsDisableService checks if a service of the account is disabled | 检查账号的指定服务是否被封禁
+func IsDisableService(loginID interface{}, service string) bool {
+	return GetManager().IsDisableService(toString(loginID), service)
+}
+
+// IsDisableLevel checks if a service of the account is disabled at level or above | 检查账号的指定服务是否被封禁且等级不低于level
+func IsDisableLevel(loginID interface{}, service string, level int) bool {
+	return GetManager().IsDisableLevel(toString(loginID), service, level)
+}
+
+// CheckDisableLevel returns an error when the service is disabled at level or above | 服务被封禁且等级不低于level时返回错误
+func CheckDisableLevel(loginID interface{}, service string, level int) error {
+	return GetManager().CheckDisable(toString(loginID), service, level)
+}
+
+// GetDisableLevel gets the disable level of a service, -2 when not disabled | 获取服务的封禁等级，未封禁时返回-2
+func GetDisableLevel(loginID interface{}, service string) int {
+	return GetManager().GetDisableLevel(toString(loginID), service)
+}
+
+// GetDisableInfo gets the ban record of a service | 获取服务的封禁记录
+func GetDisableInfo(loginID interface{}, service string) (*manager.DisableInfo, error) {
+	return GetManager().GetDisableInfo(toString(loginID), service)
+}
+
+// GetDisableList lists the ban records of an account | 列出账号的封禁记录
+func GetDisableList(loginID interface{}) ([]*manager.DisableInfo, error) {
+	return GetManager().GetDisableList(toString(loginID))
+}
+
 // ============ Session Management | Session管理 ============
 
 // GetSession gets session by login ID | 根据登录ID获取Session
@@ -197,6 +301,77 @@ func HasRolesOr(loginID interface{}, roles []string) bool {
 	return GetManager().HasRolesOr(toString(loginID), roles)
 }
 
+// ============ Role Definitions | 角色定义 ============
+
+// SaveRole creates or replaces a role definition | 创建或替换角色定义
+func SaveRole(role *manager.Role) error {
+	return GetManager().SaveRole(role)
+}
+
+// GetRole gets a role definition | 获取角色定义
+func GetRole(name string) (*manager.Role, error) {
+	return GetManager().GetRole(name)
+}
+
+// DeleteRole deletes a role definition | 删除角色定义
+func DeleteRole(name string) error {
+	return GetManager().DeleteRole(name)
+}
+
+// ListRoles lists role definitions | 列出角色定义
+func ListRoles() ([]*manager.Role, error) {
+	return GetManager().ListRoles()
+}
+
+// GrantRolePermissions adds permissions to a role | 为角色添加权限
+func GrantRolePermissions(name string, permissions ...string) error {
+	return GetManager().GrantRolePermissions(name, permissions...)
+}
+
+// SetRoleInherits sets the roles a role inherits | 设置角色继承的角色
+func SetRoleInherits(name string, parents ...string) error {
+	return GetManager().SetRoleInherits(name, parents...)
+}
+
+// ============ Tenants | 租户 ============
+
+// Tenant gets the manager of a tenant, e.g. stputil.Tenant("acme").Kickout("1000") | 获取租户管理器，例如stputil.Tenant("acme").Kickout("1000")
+func Tenant(tenantID string) *manager.Manager {
+	return GetManager().Tenant(tenantID)
+}
+
+// SetTenantConfig registers configuration overrides for a tenant | 注册租户的配置覆盖项
+func SetTenantConfig(tenantID string, fn manager.TenantConfigFunc) {
+	GetManager().SetTenantConfig(tenantID, fn)
+}
+
+// SetTenantValidator sets which tenants requests may resolve to | 设置请求可以解析到的租户
+func SetTenantValidator(validator manager.TenantValidator) {
+	GetManager().SetTenantValidator(validator)
+}
+
+// ============ Access Policies | 访问策略 ============
+
+// RegisterPolicy registers a policy for an action | 为操作注册策略
+func RegisterPolicy(action string, policy manager.Policy) {
+	GetManager().RegisterPolicy(action, policy)
+}
+
+// RegisterPolicyExpr registers an expression policy for an action | 为操作注册表达式策略
+func RegisterPolicyExpr(action, expr string) error {
+	return GetManager().RegisterPolicyExpr(action, expr)
+}
+
+// Check checks if loginID may perform action on resource | 检查loginID能否对资源执行操作
+func Check(loginID interface{}, action string, resource any) error {
+	return GetManager().Check(toString(loginID), action, resource)
+}
+
+// Can reports whether loginID may perform action on resource | 判断loginID能否对资源执行操作
+func Can(loginID interface{}, action string, resource any) bool {
+	return GetManager().Can(toString(loginID), action, resource)
+}
+
 // ============ Token标签 ============
 
 // SetTokenTag 设置Token标签
@@ -216,6 +391,11 @@ func GetTokenValueList(loginID interface{}) ([]string, error) {
 	return GetManager().GetTokenValueListByLoginID(toString(loginID))
 }
 
+// GetTokenDigestList 获取指定账号所有Token的存储形式
+func GetTokenDigestList(loginID interface{}) ([]string, error) {
+	return GetManager().GetTokenDigestListByLoginID(toString(loginID))
+}
+
 // GetSessionCount 获取指定账号的Session数量
 func GetSessionCount(loginID interface{}) (int, error) {
 	return GetManager().GetSessionCountByLoginID(toString(loginID))
@@ -312,11 +492,18 @@ func LoginWithRefreshToken(loginID interface{}, device ...string) (*security.Ref
 	return globalManager.LoginWithRefreshToken(fmt.Sprintf("%v", loginID), deviceType)
 }
 
-func RefreshAccessToken(refreshToken string) (*security.RefreshTokenInfo, error) {
+func LoginWithBoundRefreshToken(loginID interface{}, device, fingerprint string) (*security.RefreshTokenInfo, error) {
+	if globalManager == nil {
+		panic("Manager not initialized. Call stputil.SetManager() first")
+	}
+	return globalManager.LoginWithRefreshToken(fmt.Sprintf("%v", loginID), device, fingerprint)
+}
+
+func RefreshAccessToken(refreshToken string, fingerprint ...string) (*security.RefreshTokenInfo, error) {
 	if globalManager == nil {
 		panic("Manager not initialized. Call stputil.SetManager() first")
 	}
-	return globalManager.RefreshAccessToken(refreshToken)
+	return globalManager.RefreshAccessToken(refreshToken, fingerprint...)
 }
 
 func RevokeRefreshToken(refreshToken string) error {
@@ -326,6 +513,27 @@ func RevokeRefreshToken(refreshToken string) error {
 	return globalManager.RevokeRefreshToken(refreshToken)
 }
 
+func ListRefreshTokens(loginID interface{}) ([]*security.RefreshTokenInfo, error) {
+	if globalManager == nil {
+		panic("Manager not initialized. Call stputil.SetManager() first")
+	}
+	return globalManager.ListRefreshTokens(fmt.Sprintf("%v", loginID))
+}
+
+func ListRefreshTokenDigests(loginID interface{}) ([]*security.RefreshTokenInfo, error) {
+	if globalManager == nil {
+		panic("Manager not initialized. Call stputil.SetManager() first")
+	}
+	return globalManager.ListRefreshTokenDigests(fmt.Sprintf("%v", loginID))
+}
+
+func RevokeAllRefreshTokens(loginID interface{}, device ...string) error {
+	if globalManager == nil {
+		panic("Manager not initialized. Call stputil.SetManager() first")
+	}
+	return globalManager.RevokeAllRefreshTokens(fmt.Sprintf("%v", loginID), device...)
+}
+
 func GetOAuth2Server() *oauth2.OAuth2Server {
 	if globalManager == nil {
 		panic("Manager not initialized. Call stputil.SetManager() first")
@@ -341,10 +549,7 @@ func CheckDisable(tokenValue string) error {
 	if err != nil {
 		return err
 	}
-	if IsDisable(loginID) {
-		return fmt.Errorf("account is disabled")
-	}
-	return nil
+	return GetManager().CheckDisable(loginID, manager.DefaultDisableService, manager.MinDisableLevel)
 }
 
 // CheckPermission checks if the token has the specified permission | 检查Token是否拥有指定权限